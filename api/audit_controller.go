@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// contextRepo is a repo which can attribute its changes to the user of a request,
+// like the audit log decorator.
+type contextRepo interface {
+	WithContext(ctx context.Context) entity.Repo
+}
+
+// repoFor returns the repo for the changes of a request. Repos without a context,
+// like the mock in the tests, are used as they are.
+func repoFor(repo entity.Repo, r *http.Request) entity.Repo {
+	if c, ok := repo.(contextRepo); ok {
+		return c.WithContext(r.Context())
+	}
+	return repo
+}
+
+type AuditController struct {
+	Repo entity.Repo
+}
+
+func (a AuditController) RegisterRoutes(r chi.Router) {
+	r.Use(managers)
+	r.Get("/", a.ReadAuditEntries)
+}
+
+func (a AuditController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Pattern: "/", Summary: "Read the audit log", Response: []entity.AuditEntry{}, Query: []openapi.Param{
+			{Name: "entity", Description: "kind of the changed entities, e.g. order"},
+			{Name: "id", Description: "id of the changed entity"},
+		}},
+	}
+}
+
+// ReadAuditEntries returns the audit log, newest first, filtered by the entity and id
+// query parameters, e.g. /audit?entity=order&id=1.
+func (a AuditController) ReadAuditEntries(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("entity")
+	id := r.URL.Query().Get("id")
+	entries, err := a.Repo.GetAuditEntries(kind, id)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not read audit log", err)
+		return
+	}
+	SendJson(w, http.StatusOK, entries)
+	fmt.Println("Found audit entries")
+}
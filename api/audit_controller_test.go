@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRead(t *testing.T) {
+	entries := []entity.AuditEntry{
+		{ID: 2, ActorID: 3, Action: entity.AuditUpdate, Entity: "order", EntityID: "1", Diff: `{"TableNumber":{"After":5,"Before":4}}`},
+		{ID: 1, ActorID: 3, Action: entity.AuditCreate, Entity: "order", EntityID: "1"},
+	}
+	tests := []struct {
+		name       string
+		query      string
+		entity     string
+		id         string
+		err        error
+		statusCode int
+	}{
+		{name: "one order", query: "?entity=order&id=1", entity: "order", id: "1", statusCode: http.StatusOK},
+		{name: "all entries", statusCode: http.StatusOK},
+		{name: "inner error", query: "?entity=order", entity: "order", err: errors.New("failed"), statusCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			if tt.err != nil {
+				repo.On("GetAuditEntries", tt.entity, tt.id).Return(nil, tt.err)
+			} else {
+				repo.On("GetAuditEntries", tt.entity, tt.id).Return(entries, nil)
+			}
+			AuditController{Repo: repo}.ReadAuditEntries(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			repo.AssertExpectations(t)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			var resp []entity.AuditEntry
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.Equal(t, entries, resp)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Role checks for the controller routes, admins pass all of them.
+var (
+	managers = auth.RequireRole(entity.RoleManager)
+	waiters  = auth.RequireRole(entity.RoleManager, entity.RoleWaiter)
+	staff    = auth.RequireRole(entity.RoleManager, entity.RoleWaiter, entity.RoleKitchen)
+	kitchen  = auth.RequireRole(entity.RoleManager, entity.RoleKitchen)
+)
+
+// approvals checks approver PINs with the lockout of the PIN logins, which all controllers
+// share, so PINs can not be tried out by spreading the attempts over several routes.
+func approvals(repo entity.Repo, lockout *auth.Lockout) auth.Approvals {
+	return auth.Approvals{Users: repo, Lockout: lockout}
+}
+
+// authorize checks a permission inside a handler, for checks that depend on the request
+// body. It answers the request if the permission is missing.
+func authorize(w http.ResponseWriter, r *http.Request, approvals auth.Approvals, permission string) bool {
+	_, err := approvals.Authorize(r, permission)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Not authorized", err)
+		return false
+	}
+	return true
+}
+
+// userId returns the logged in user of a request, who orders, discounts and payments
+// are attributed to. It is 0 for requests that are not authenticated.
+func userId(r *http.Request) uint {
+	claims, _ := auth.FromContext(r.Context())
+	return claims.UserID
+}
+
+type AuthController struct {
+	Repo    entity.Repo
+	Tokens  auth.Tokens
+	Lockout *auth.Lockout
+}
+
+type login struct {
+	Username string
+	Password string
+}
+
+func (l login) Validate() error {
+	var v entity.Validator
+	v.Required("Username", l.Username)
+	v.Required("Password", l.Password)
+	return v.Err()
+}
+
+type pinLogin struct {
+	Username string
+	Pin      string
+}
+
+func (l pinLogin) Validate() error {
+	var v entity.Validator
+	v.Required("Username", l.Username)
+	v.Required("Pin", l.Pin)
+	return v.Err()
+}
+
+type loginToken struct {
+	Token     string
+	ExpiresAt time.Time
+	User      entity.User
+}
+
+// RegisterRoutes adds the logins, which have to be mounted outside of auth.Authenticate.
+func (a AuthController) RegisterRoutes(r chi.Router) {
+	r.Post("/login", a.Login)
+	r.Post("/pin", a.PinLogin)
+}
+
+func (a AuthController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/login", Summary: "Log in with username and password", Request: login{}, Response: loginToken{}, Public: true},
+		{Method: http.MethodPost, Pattern: "/pin", Summary: "Log in with username and PIN", Request: pinLogin{}, Response: loginToken{}, Public: true},
+	}
+}
+
+func (a AuthController) Login(w http.ResponseWriter, r *http.Request) {
+	var l login
+	if !decode(w, r, &l) {
+		return
+	}
+	a.login(w, r, l.Username, func(user entity.User) error {
+		return auth.CheckPassword(user, l.Password)
+	})
+}
+
+// PinLogin is the quick login of staff on shared tablets with their 4 digit PIN.
+func (a AuthController) PinLogin(w http.ResponseWriter, r *http.Request) {
+	var l pinLogin
+	if !decode(w, r, &l) {
+		return
+	}
+	a.login(w, r, l.Username, func(user entity.User) error {
+		return auth.CheckPin(user, l.Pin)
+	})
+}
+
+func (a AuthController) login(w http.ResponseWriter, r *http.Request, username string, check func(entity.User) error) {
+	if a.Lockout != nil && a.Lockout.Locked(username) {
+		SendProblem(w, r, auth.ErrLockedOut)
+		fmt.Println("Locked out login of", username)
+		return
+	}
+	user, err := a.Repo.GetUserByUsername(username)
+	if err == nil {
+		err = check(user)
+	}
+	if err != nil {
+		notFoundErr := entity.RecordNotFoundError{}
+		if errors.As(err, &notFoundErr) || errors.Is(err, auth.ErrInvalidCredentials) {
+			if a.Lockout != nil {
+				a.Lockout.Fail(username)
+			}
+			SendProblem(w, r, auth.ErrInvalidCredentials)
+			fmt.Println("Invalid login of", username)
+		} else {
+			SendProblem(w, r, err)
+			fmt.Println("Inner error, can not log in", err)
+		}
+		return
+	}
+	if a.Lockout != nil {
+		a.Lockout.Reset(username)
+	}
+	a.sendToken(w, r, user)
+}
+
+func (a AuthController) sendToken(w http.ResponseWriter, r *http.Request, user entity.User) {
+	token, expiresAt, err := a.Tokens.Issue(user)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not issue token", err)
+		return
+	}
+	SendJson(w, http.StatusOK, loginToken{Token: token, ExpiresAt: expiresAt, User: user})
+	fmt.Println("Logged in", user.Username)
+}
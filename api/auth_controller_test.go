@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLogin(t *testing.T) {
+	tokens := auth.Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	hash, err := auth.HashPassword("geheim")
+	require.NoError(t, err)
+	user := entity.User{Model: gorm.Model{ID: 3}, Username: "anna", PasswordHash: hash, Role: entity.RoleWaiter}
+
+	tests := []struct {
+		name       string
+		payload    login
+		existing   entity.User
+		err        error
+		statusCode int
+	}{
+		{name: "successful login", payload: login{Username: "anna", Password: "geheim"}, existing: user, statusCode: http.StatusOK},
+		{name: "wrong password", payload: login{Username: "anna", Password: "falsch"}, existing: user, statusCode: http.StatusUnauthorized},
+		{name: "unknown user", payload: login{Username: "anna", Password: "geheim"}, err: entity.WrapRecordNotFoundError("User", "anna", nil), statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPost, "/auth/login", b)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetUserByUsername", tt.payload.Username).Return(tt.existing, tt.err)
+			AuthController{Repo: repo, Tokens: tokens}.Login(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			var resp map[string]any
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.NotContains(t, resp["User"], "PasswordHash")
+			claims, err := tokens.Parse(resp["Token"].(string))
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, claims.UserID)
+		})
+	}
+}
+
+func TestPinLogin(t *testing.T) {
+	tokens := auth.Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	hash, err := auth.HashPassword("1234")
+	require.NoError(t, err)
+	waiter := entity.User{Model: gorm.Model{ID: 3}, Username: "anna", PinHash: hash, Role: entity.RoleWaiter}
+	admin := entity.User{Model: gorm.Model{ID: 1}, Username: "admin", PinHash: hash, Role: entity.RoleAdmin}
+	lockout := auth.NewLockout(2, time.Minute)
+
+	tests := []struct {
+		name       string
+		payload    pinLogin
+		existing   entity.User
+		statusCode int
+	}{
+		{name: "successful login", payload: pinLogin{Username: "anna", Pin: "1234"}, existing: waiter, statusCode: http.StatusOK},
+		{name: "admins need their password", payload: pinLogin{Username: "admin", Pin: "1234"}, existing: admin, statusCode: http.StatusUnauthorized},
+		{name: "wrong PIN", payload: pinLogin{Username: "anna", Pin: "0000"}, existing: waiter, statusCode: http.StatusUnauthorized},
+		{name: "wrong PIN again", payload: pinLogin{Username: "anna", Pin: "0001"}, existing: waiter, statusCode: http.StatusUnauthorized},
+		{name: "locked out", payload: pinLogin{Username: "anna", Pin: "1234"}, existing: waiter, statusCode: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPost, "/auth/pin", b)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetUserByUsername", tt.payload.Username).Return(tt.existing, nil)
+			AuthController{Repo: repo, Tokens: tokens, Lockout: lockout}.PinLogin(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestRoutesRequireRole(t *testing.T) {
+	tokens := auth.Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	repo := new(entity.MockRepo)
+	repo.On("DeleteOrder", uint(1), uint(1)).Return(nil)
+	repo.On("CreateUser", mock.Anything).Return(nil)
+	r := chi.NewRouter()
+	r.Use(auth.Authenticate(tokens, repo))
+	r.Route("/orders", OrdersController{Repo: repo}.RegisterRoutes)
+	r.Route("/users", UsersController{Repo: repo}.RegisterRoutes)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		role       string
+		statusCode int
+	}{
+		{name: "waiter can not delete orders", method: http.MethodDelete, path: "/orders/1", role: entity.RoleWaiter, statusCode: http.StatusForbidden},
+		{name: "manager deletes orders", method: http.MethodDelete, path: "/orders/1", role: entity.RoleManager, statusCode: http.StatusNoContent},
+		{name: "manager can not create users", method: http.MethodPost, path: "/users", body: `{"Username":"ben","Password":"x","Role":"waiter"}`, role: entity.RoleManager, statusCode: http.StatusForbidden},
+		{name: "admin creates users", method: http.MethodPost, path: "/users", body: `{"Username":"ben","Password":"x","Role":"waiter"}`, role: entity.RoleAdmin, statusCode: http.StatusCreated},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := entity.User{Model: gorm.Model{ID: uint(i + 1)}, Username: tt.role, Role: tt.role}
+			repo.On("GetUser", user.ID).Return(user, nil)
+			token, _, err := tokens.Issue(user)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("If-Match", `"1"`)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+		})
+	}
+	created := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(entity.User)
+	assert.Empty(t, created.Password)
+	assert.NotEmpty(t, created.PasswordHash)
+}
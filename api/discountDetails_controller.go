@@ -1,9 +1,10 @@
 package api
 
 import (
-	"encoding/json"
 	"fmt"
+	"gorestserviceagain/auth"
 	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
 	"net/http"
 	"strconv"
 
@@ -11,29 +12,35 @@ import (
 )
 
 type DiscountDetailController struct {
-	Repo entity.Repo
+	Repo    entity.Repo
+	Lockout *auth.Lockout
 }
 
 func (d DiscountDetailController) RegisterRoutes(r chi.Router) {
-	r.Post("/discountPrice", d.CreateDiscount)
-	r.Get("/{orderId}/dishes/{dishId}", d.GetPriceAfterDiscount)
+	r.With(waiters, approvals(d.Repo, d.Lockout).Require(auth.PermDiscountUpTo20)).Post("/discountPrice", d.CreateDiscount)
+	r.With(waiters).Get("/{orderId}/dishes/{dishId}", d.GetPriceAfterDiscount)
+}
+
+func (d DiscountDetailController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/discountPrice", Summary: "Add a discount on a dish of an order", Request: entity.DiscountDetail{}, Response: entity.DiscountDetail{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/{orderId}/dishes/{dishId}", Summary: "Read the price of a dish of an order after the discount", Response: priceAfterDiscount{}},
+	}
 }
 
 func (d DiscountDetailController) CreateDiscount(w http.ResponseWriter, r *http.Request) {
 	var price entity.DiscountDetail
 	var order entity.Order
 	var dish entity.Dish
-	err := json.NewDecoder(r.Body).Decode(&price)
-	if err != nil {
-		fmt.Println(entity.ErrJson)
-		SendErr(w, http.StatusUnprocessableEntity, entity.ErrJson.Error())
+	if !decode(w, r, &price) {
 		return
 	}
+	price.UserID = userId(r)
 
-	order, err = d.Repo.GetOrder(price.OrderID)
+	order, err := d.Repo.GetOrder(price.OrderID)
 	if err != nil {
 		fmt.Println("Order does not exsits")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
 		return
 	} else {
 		fmt.Printf("Found the order and id is %v\n", price.OrderID)
@@ -42,26 +49,26 @@ func (d DiscountDetailController) CreateDiscount(w http.ResponseWriter, r *http.
 	dish, err = d.Repo.GetDish(price.DishID)
 	if err != nil {
 		fmt.Println("Dish does not exsits")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
 		return
 	} else {
 		fmt.Printf("Founf the dish and id is %v\n", price.DishID)
 	}
 
-	err = comparePrice(price, dish)
-	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+	if !authorizeDiscount(w, r, approvals(d.Repo, d.Lockout), price, dish) {
+		return
 	}
 	price.Order = order
 	price.Dish = dish
 
-	err = d.Repo.CreateDiscount(&price)
+	err = repoFor(d.Repo, r).CreateDiscount(&price)
 	if err != nil {
 		fmt.Println("Can not add discount price, discount with same orderId anf dishId has exist")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
 		return
 	} else {
-		SendJson(w, http.StatusCreated, price)
+		setETag(w, price.Version)
+		Respond(w, r, http.StatusCreated, price)
 		fmt.Println("Added discount price")
 	}
 
@@ -75,7 +82,7 @@ func (d DiscountDetailController) GetPriceAfterDiscount(w http.ResponseWriter, r
 	price, err := d.Repo.GetPriceAfterDiscount(uint(orderId), uint(dishId))
 
 	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
 		return
 	}
 	originalPrice := price.Dish.Price
@@ -84,10 +91,20 @@ func (d DiscountDetailController) GetPriceAfterDiscount(w http.ResponseWriter, r
 	newPrice.OriginalPrice = originalPrice
 	newPrice.DiscountPrice = originalPrice * ((100 - price.Discount) / 100)
 
-	SendJson(w, http.StatusOK, newPrice)
+	setETag(w, price.Version)
+	Respond(w, r, http.StatusOK, newPrice)
 	fmt.Printf("OrderId: %d\n", newPrice.OrderID)
 	fmt.Printf("DishId: %d\n", newPrice.DischID)
 	fmt.Printf("Original price: %v\n", newPrice.OriginalPrice)
 	fmt.Printf("Discount price: %v\n", newPrice.DiscountPrice)
 
 }
+
+// authorizeDiscount checks discounts above 20%, which need discount.apply.any. Smaller
+// discounts are checked by the routes already.
+func authorizeDiscount(w http.ResponseWriter, r *http.Request, approvals auth.Approvals, discount entity.DiscountDetail, dish entity.Dish) bool {
+	if comparePrice(discount, dish) == nil {
+		return true
+	}
+	return authorize(w, r, approvals, auth.PermDiscountAny)
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"gorestserviceagain/auth"
 	"gorestserviceagain/entity"
 	"net/http"
 	"net/http/httptest"
@@ -25,8 +26,12 @@ func TestDiscountCreate(t *testing.T) {
 	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14.}
 	dish := entity.Dish{Model: gorm.Model{ID: 2}, Name: "Fish filet", Price: 10.}
 	discountDetail := entity.DiscountDetail{OrderID: 1, DishID: 2, Discount: 2., Order: order, Dish: dish}
-	errOrderNotFound := errors.New("order not found")
-	errDishNotFound := errors.New("dish not found")
+	errOrderNotFound := entity.WrapRecordNotFoundError("Order", 1, gorm.ErrRecordNotFound)
+	errDishNotFound := entity.WrapRecordNotFoundError("Dish", 2, gorm.ErrRecordNotFound)
+	highDiscount := entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 50, Order: order, Dish: dish, UserID: 3}
+	pin, err := auth.HashPassword("1234")
+	require.NoError(t, err)
+	manager := entity.User{Model: gorm.Model{ID: 7}, Username: "maria", PinHash: pin, Role: entity.RoleManager}
 
 	tests := []struct {
 		name             string
@@ -39,6 +44,8 @@ func TestDiscountCreate(t *testing.T) {
 		orderErr         error
 		dishErr          error
 		discountErr      error
+		role             string
+		approverPin      string
 	}{
 		{
 			name:          "successful creatation",
@@ -51,13 +58,22 @@ func TestDiscountCreate(t *testing.T) {
 					"OrderID":  float64(discountDetail.OrderID),
 					"DishID":   float64(discountDetail.DishID),
 					"Discount": float64(discountDetail.Discount),
+					"UserID":   float64(0),
+					"Version":  float64(0),
 					"Dish": map[string]interface{}{
-						"CreatedAt": "0001-01-01T00:00:00Z",
-						"DeletedAt": interface{}(nil),
-						"ID":        float64(dish.ID),
-						"Name":      dish.Name,
-						"Price":     float64(dish.Price),
-						"UpdatedAt": "0001-01-01T00:00:00Z",
+						"Category":     "",
+						"CreatedAt":    "0001-01-01T00:00:00Z",
+						"DeletedAt":    interface{}(nil),
+						"Description":  "",
+						"ID":           float64(dish.ID),
+						"Name":         dish.Name,
+						"Price":        float64(dish.Price),
+						"SKU":          "",
+						"SoldOut":      false,
+						"TaxRate":      float64(0),
+						"Translations": interface{}(nil),
+						"UpdatedAt":    "0001-01-01T00:00:00Z",
+						"Version":      float64(0),
 					},
 					"Order": map[string]interface{}{
 						"CreatedAt":      "0001-01-01T00:00:00Z",
@@ -65,8 +81,14 @@ func TestDiscountCreate(t *testing.T) {
 						"DiscountDetail": interface{}(nil),
 						"FinalPrice":     float64(order.FinalPrice),
 						"ID":             float64(order.ID),
+						"Items":          interface{}(nil),
+						"Payments":       interface{}(nil),
+						"Signatures":     interface{}(nil),
+						"ShiftID":        float64(0),
 						"TableNumber":    float64(order.TableNumber),
 						"UpdatedAt":      "0001-01-01T00:00:00Z",
+						"UserID":         float64(0),
+						"Version":        float64(0),
 					},
 				},
 			},
@@ -77,8 +99,8 @@ func TestDiscountCreate(t *testing.T) {
 			payload:       discountDetail,
 			existingOrder: order,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": errOrderNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, errOrderNotFound.Error()),
 			},
 		},
 		{
@@ -88,8 +110,8 @@ func TestDiscountCreate(t *testing.T) {
 			existingOrder: order,
 			existingDish:  dish,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": errDishNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, errDishNotFound.Error()),
 			},
 		},
 		{
@@ -97,16 +119,53 @@ func TestDiscountCreate(t *testing.T) {
 			existingOrder:    order,
 			existingDish:     dish,
 			existingDiscount: discountDetail,
-			payload: entity.DiscountDetail{
-				OrderID:  order.ID,
-				DishID:   dish.ID,
-				Discount: 50,
-				Order:    order,
-				Dish:     dish,
+			payload:          highDiscount,
+			role:             entity.RoleWaiter,
+			expected: expectations{
+				statusCode: http.StatusForbidden,
+				respPayload: problem(http.StatusForbidden, fmt.Sprintf("%v: %s, a manager can approve with the %s and %s headers",
+					auth.ErrPermission, auth.PermDiscountAny, auth.ApproverHeader, auth.ApproverPinHeader)),
 			},
+		},
+		{
+			name:          "manager gives high discount",
+			existingOrder: order,
+			existingDish:  dish,
+			payload:       highDiscount,
+			role:          entity.RoleManager,
+			expected:      expectations{statusCode: http.StatusCreated},
+		},
+		{
+			name:          "manager approves high discount",
+			existingOrder: order,
+			existingDish:  dish,
+			payload:       highDiscount,
+			role:          entity.RoleWaiter,
+			approverPin:   "1234",
+			expected:      expectations{statusCode: http.StatusCreated},
+		},
+		{
+			name:          "wrong approver PIN",
+			existingOrder: order,
+			existingDish:  dish,
+			payload:       highDiscount,
+			role:          entity.RoleWaiter,
+			approverPin:   "4321",
+			expected: expectations{
+				statusCode:  http.StatusForbidden,
+				respPayload: problem(http.StatusForbidden, fmt.Sprintf("%v: wrong approver or PIN", auth.ErrApproval)),
+			},
+		},
+		{
+			name:          "discount above 100 percent",
+			existingOrder: order,
+			existingDish:  dish,
+			payload:       entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 500, Order: order, Dish: dish},
+			role:          entity.RoleManager,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": "Added discount is too high"},
+				statusCode: http.StatusUnprocessableEntity,
+				respPayload: problem(http.StatusUnprocessableEntity, fmt.Sprintf("%v: Discount must be between 0 and 100", entity.ErrInvalidData),
+					entity.FieldError{Field: "Discount", Rule: entity.RuleRange, Message: "must be between 0 and 100"}),
 			},
 		},
 	}
@@ -116,8 +175,16 @@ func TestDiscountCreate(t *testing.T) {
 			b := bytes.NewBuffer(nil)
 			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
 			r := httptest.NewRequest(http.MethodPost, "/discountPrice/", b)
+			if tt.role != "" {
+				r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: tt.role}))
+			}
+			if tt.approverPin != "" {
+				r.Header.Set(auth.ApproverHeader, manager.Username)
+				r.Header.Set(auth.ApproverPinHeader, tt.approverPin)
+			}
 
 			repo := new(entity.MockRepo)
+			repo.On("GetUserByUsername", manager.Username).Return(manager, nil)
 			repo.On("GetOrder", tt.existingOrder.ID).Return(tt.existingOrder, tt.orderErr)
 			repo.On("GetDish", tt.existingDish.ID).Return(tt.existingDish, tt.dishErr)
 			repo.On("CreateDiscount", tt.payload).Return(tt.discountErr)
@@ -169,8 +236,8 @@ func TestPriceAfterDiscountGet(t *testing.T) {
 			existing: discountDetail,
 			err:      entity.ErrEntityNotFound,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": entity.ErrEntityNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, entity.ErrEntityNotFound.Error()),
 			},
 		},
 	}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+)
+
+func toDiscountDetail(d dto.DiscountRequest) entity.DiscountDetail {
+	return entity.DiscountDetail{OrderID: d.OrderID, DishID: d.DishID, Discount: d.Discount}
+}
+
+func NewDiscountResponse(d entity.DiscountDetail) dto.DiscountResponse {
+	return dto.DiscountResponse{OrderID: d.OrderID, DishID: d.DishID, Discount: d.Discount, UserID: d.UserID, Version: d.Version}
+}
+
+func NewPriceAfterDiscountResponse(p priceAfterDiscount) dto.PriceAfterDiscountResponse {
+	return dto.PriceAfterDiscountResponse{
+		OrderID:       p.OrderID,
+		DishID:        p.DischID,
+		OriginalPrice: p.OriginalPrice,
+		DiscountPrice: p.DiscountPrice,
+	}
+}
@@ -1,10 +1,12 @@
 package api
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
+	"gorestserviceagain/auth"
 	"gorestserviceagain/entity"
+	"gorestserviceagain/menu"
+	"gorestserviceagain/openapi"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -12,44 +14,116 @@ import (
 )
 
 type DishesController struct {
-	Repo entity.Repo
+	Repo    entity.Repo
+	Lockout *auth.Lockout
 }
 
 func (d DishesController) RegisterRoutes(r chi.Router) {
-	r.Post("/", d.CreateDish)
-	r.Get("/", d.ReadAllDishes)
-	r.Get("/{id}", d.ReadDishById)
-	r.Put("/{id}", d.UpdateDishById)
-	r.Delete("/{id}", d.DeleteDishById)
+	edit := approvals(d.Repo, d.Lockout).Require(auth.PermDishEdit)
+	r.With(edit).Post("/", d.CreateDish)
+	r.With(edit).Post("/import", d.ImportDishes)
+	r.With(staff).Get("/", d.ReadAllDishes)
+	r.With(staff).Get("/search", d.SearchDishes)
+	r.With(managers).Get("/margins", d.ReadMargins)
+	r.With(staff).Get("/{id}", d.ReadDishById)
+	r.With(edit).Put("/{id}", d.UpdateDishById)
+	r.With(edit).Patch("/{id}", d.PatchDishById)
+	r.With(edit).Delete("/{id}", d.DeleteDishById)
+	r.With(staff).Get("/{id}/recipe", d.ReadRecipe)
+	r.With(edit).Put("/{id}/recipe", d.UpdateRecipe)
+}
+
+func (d DishesController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Create a dish", Request: entity.Dish{}, Response: entity.Dish{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Pattern: "/import", Summary: "Import the dishes of a menu file", Query: []openapi.Param{
+			{Name: "format", Enum: []string{menu.FormatJSON, menu.FormatCSV, menu.FormatYAML}, Description: "taken from the file name or content type by default"},
+			{Name: "dryRun", Type: "boolean", Description: "only check what would be imported"},
+		}, Consumes: []string{
+			menu.ContentType(menu.FormatJSON), menu.ContentType(menu.FormatCSV), menu.ContentType(menu.FormatYAML), "multipart/form-data",
+		}, Response: []entity.ImportRow{}},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List dishes", Response: []entity.Dish{}, Query: append([]openapi.Param{
+			{Name: "name", Description: "part of the name"},
+			{Name: "category"},
+			{Name: "priceMin", Type: "number"},
+			{Name: "priceMax", Type: "number"},
+		}, listParams...)},
+		{Method: http.MethodGet, Pattern: "/search", Summary: "Search dishes by name, description and translations", Response: []entity.Dish{}, Query: []openapi.Param{
+			{Name: "q", Description: "the words to search for"},
+			{Name: "limit", Type: "integer"},
+		}},
+		{Method: http.MethodGet, Pattern: "/margins", Summary: "Report the margins of the dishes", Response: entity.MarginReport{}, Query: DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read a dish", Response: entity.Dish{}},
+		{Method: http.MethodPut, Pattern: "/{id}", Summary: "Replace a dish", Description: "Fields which are left out are set to their zero value, translations which are left out are removed.", Request: entity.Dish{}, Status: http.StatusNoContent},
+		{Method: http.MethodPatch, Pattern: "/{id}", Summary: "Update fields of a dish", Description: "Takes a JSON Merge Patch or a JSON Patch of the dish as it is read. Null removes a field, which sets it to its zero value.", Request: entity.Dish{}, Consumes: patchContentTypes, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Pattern: "/{id}", Summary: "Delete a dish", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Pattern: "/{id}/recipe", Summary: "Read the recipe of a dish", Response: []entity.RecipeItem{}},
+		{Method: http.MethodPut, Pattern: "/{id}/recipe", Summary: "Replace the recipe of a dish", Request: []entity.RecipeItem{}, Response: []entity.RecipeItem{}},
+	}
 }
 
 func (d DishesController) CreateDish(w http.ResponseWriter, r *http.Request) {
 	var dish entity.Dish
-	err := json.NewDecoder(r.Body).Decode(&dish)
-	if err != nil {
-		fmt.Println(entity.ErrJson)
-		SendErr(w, http.StatusUnprocessableEntity, entity.ErrJson.Error())
+	if !decode(w, r, &dish) {
 		return
 	}
-	err = d.Repo.CreateDish(&dish)
+	err := repoFor(d.Repo, r).CreateDish(&dish)
 	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
-		fmt.Println("Can not create dish")
+		SendProblem(w, r, err)
+		fmt.Println("Can not create dish", err)
 	} else {
-		SendJson(w, http.StatusCreated, dish)
+		setETag(w, dish.Version)
+		Respond(w, r, http.StatusCreated, dish)
 		fmt.Println("Added dish")
 	}
 }
 
+// ReadAllDishes returns a page of the dishes, filtered by name, category, priceMin and
+// priceMax, see parseDishQuery.
 func (d DishesController) ReadAllDishes(w http.ResponseWriter, r *http.Request) {
-	dishes, err := d.Repo.GetDishes()
+	query, err := parseDishQuery(r)
 	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
-		fmt.Println("Can not find dishes")
-	} else {
-		SendJson(w, http.StatusOK, dishes)
-		fmt.Println("Found dishes")
+		SendProblem(w, r, err)
+		fmt.Println("Invalid dish query", err)
+		return
+	}
+	dishes, err := d.Repo.GetDishes(query)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find dishes", err)
+		return
+	}
+	if len(dishes) > 0 {
+		setNextPage(w, r, query.ListOptions, len(dishes), dishes[len(dishes)-1].ID)
 	}
+	Respond(w, r, http.StatusOK, dishes)
+	fmt.Println("Found dishes")
+}
+
+// defaultSearchLimit is the number of dishes a search returns without a limit.
+const defaultSearchLimit = 20
+
+// SearchDishes finds dishes by the words of q in their names and descriptions, in all
+// languages. Words may be typed partly, e.g. "schni" finds Schnitzel, and with typos.
+func (d DishesController) SearchDishes(w http.ResponseWriter, r *http.Request) {
+	text := r.URL.Query().Get("q")
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			SendProblem(w, r, fmt.Errorf("%w: limit", entity.ErrBadRequest))
+			return
+		}
+		limit = min(l, maxLimit)
+	}
+	dishes, err := d.Repo.SearchDishes(text, limit)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not search dishes", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, dishes)
+	fmt.Println("Found", len(dishes), "dishes for", text)
 }
 
 func (d DishesController) ReadDishById(w http.ResponseWriter, r *http.Request) {
@@ -58,63 +132,170 @@ func (d DishesController) ReadDishById(w http.ResponseWriter, r *http.Request) {
 
 	dish, err := d.Repo.GetDish(uint(id))
 	if err != nil {
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, err.Error())
-			fmt.Println("Can not find dish")
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Unkown err")
-			fmt.Printf("Inner error, can not find dish")
-		}
+		SendProblem(w, r, err)
+		fmt.Println("Can not find dish", err)
 		return
 	}
-	SendJson(w, http.StatusOK, dish)
+	setETag(w, dish.Version)
+	Respond(w, r, http.StatusOK, dish)
 	fmt.Println("Found dish")
 }
 
 func (d DishesController) UpdateDishById(w http.ResponseWriter, r *http.Request) {
 	var dish entity.Dish
 	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
-	err := json.NewDecoder(r.Body).Decode(&dish)
-	if err != nil {
-		fmt.Println(entity.ErrJson)
-		SendErr(w, http.StatusUnprocessableEntity, entity.ErrInvalidData.Error())
+	version, ok := ifMatch(w, r)
+	if !ok || !decode(w, r, &dish) {
 		return
 	}
 	dish.ID = uint(id)
-	err = d.Repo.UpdateDish(&dish)
+	dish.Version = version
+	err := repoFor(d.Repo, r).UpdateDish(&dish)
 	if err != nil {
-		msg := err.Error()
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, msg)
-			fmt.Println("Can not update dish")
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Unkown error")
-			fmt.Println("Inner error", msg)
-		}
+		SendProblem(w, r, err)
+		fmt.Println("Can not update dish", err)
 		return
 	}
-	SendJson(w, http.StatusNoContent, nil)
+	setETag(w, dish.Version)
+	Respond(w, r, http.StatusNoContent, nil)
 	fmt.Println("Updated dish")
 }
 
+// PatchDishById applies a patch to the dish, see decodePatch.
+func (d DishesController) PatchDishById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	current, err := d.Repo.GetDish(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find dish", err)
+		return
+	}
+	if current.Version != version {
+		SendProblem(w, r, entity.ErrVersionMismatch)
+		fmt.Println("Can not patch dish", entity.ErrVersionMismatch)
+		return
+	}
+	var dish entity.Dish
+	if !decodePatch(w, r, current, &dish) {
+		return
+	}
+	dish.ID = uint(id)
+	dish.Version = version
+	err = repoFor(d.Repo, r).UpdateDish(&dish)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update dish", err)
+		return
+	}
+	setETag(w, dish.Version)
+	Respond(w, r, http.StatusNoContent, nil)
+	fmt.Println("Patched dish")
+}
+
 func (d DishesController) DeleteDishById(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	fmt.Printf("id: %+v\n", id)
-	err := d.Repo.DeleteDish(uint(id))
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	err := repoFor(d.Repo, r).DeleteDish(uint(id), version)
 	if err != nil {
-		msg := err.Error()
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, msg)
-			fmt.Println("Can not found id, can not delete dish")
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Unkown error")
-			fmt.Println("Internal error", msg)
-		}
+		SendProblem(w, r, err)
+		fmt.Println("Can not delete dish", err)
 		return
 	}
-	SendJson(w, http.StatusNoContent, nil)
+	Respond(w, r, http.StatusNoContent, nil)
 	fmt.Println("Delete dish")
 }
+
+func (d DishesController) ReadRecipe(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	recipe, err := d.Repo.GetRecipe(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find dish", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, recipe)
+	fmt.Println("Found recipe")
+}
+
+func (d DishesController) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
+	var recipe []entity.RecipeItem
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &recipe) || !valid(w, r, entity.ValidateEach(recipe)) {
+		return
+	}
+	err := repoFor(d.Repo, r).SetRecipe(uint(id), recipe)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update recipe", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, recipe)
+	fmt.Println("Updated recipe")
+}
+
+func (d DishesController) ReadMargins(w http.ResponseWriter, r *http.Request) {
+	from, to, err := ParseDateRange(r)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Invalid date range", err)
+		return
+	}
+	report, err := d.Repo.GetMarginReport(from, to)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not compute margins", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, report)
+	fmt.Println("Found margins")
+}
+
+// ImportDishes reads a menu file either as multipart upload in the field "file" or as
+// request body. The format is taken from the format parameter, the file name or the content type.
+func (d DishesController) ImportDishes(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	format := menu.Format(r.URL.Query().Get("format"))
+	var body io.Reader = r.Body
+	if file, header, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = menu.Format(header.Filename)
+		}
+	}
+	if format == "" {
+		format = menu.Format(r.Header.Get("Content-Type"))
+	}
+	items, err := menu.Decode(format, body)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not read menu", err)
+		return
+	}
+	rows, ok := menu.Validate(items)
+	if !ok {
+		Respond(w, r, http.StatusUnprocessableEntity, rows)
+		fmt.Println("Menu has invalid rows")
+		return
+	}
+	dishes := make([]entity.Dish, len(items))
+	for i, item := range items {
+		dishes[i] = item.Dish()
+	}
+	rows, err = repoFor(d.Repo, r).ImportDishes(dishes, dryRun)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not import menu", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, rows)
+	fmt.Printf("Imported %d dishes, dry run: %v\n", len(rows), dryRun)
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/patch"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMarginsRead(t *testing.T) {
+	type expectations struct {
+		statusCode int
+		from       time.Time
+		to         time.Time
+	}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	report := entity.MarginReport{
+		From:          from,
+		To:            to,
+		Dishes:        []entity.DishMargin{{DishID: 2, Name: "Fish filet", Category: "Main", Price: 10, Cost: 4, Margin: 6, MarginPercent: 60}},
+		Revenue:       100,
+		Cost:          40,
+		Margin:        60,
+		MarginPercent: 60,
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected expectations
+	}{
+		{
+			name:     "successful get margins",
+			query:    "?from=2024-03-01&to=2024-03-31",
+			expected: expectations{statusCode: http.StatusOK, from: from, to: to},
+		},
+		{
+			name:     "invalid date",
+			query:    "?from=yesterday",
+			expected: expectations{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:     "to is before from",
+			query:    "?from=2024-03-31&to=2024-03-01",
+			expected: expectations{statusCode: http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/dishes/margins"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetMarginReport", tt.expected.from, tt.expected.to).Return(report, nil)
+			DishesController{Repo: repo}.ReadMargins(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.statusCode == http.StatusOK {
+				var respPayload entity.MarginReport
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+				assert.Equal(t, report.Dishes, respPayload.Dishes)
+				assert.Equal(t, report.MarginPercent, respPayload.MarginPercent)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestDishesImport(t *testing.T) {
+	type expectations struct {
+		statusCode int
+		rows       []entity.ImportRow
+	}
+	dishes := []entity.Dish{
+		{SKU: "M-1", Name: "Schnitzel", Category: "Main", Price: 14.5, TaxRate: 19},
+		{SKU: "D-1", Name: "Beer", Category: "Drinks", Price: 4.2, TaxRate: 19},
+	}
+	imported := []entity.ImportRow{
+		{Row: 1, SKU: "M-1", Action: entity.ImportUpdate, DishID: 3},
+		{Row: 2, SKU: "D-1", Action: entity.ImportCreate},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		dryRun      bool
+		expected    expectations
+	}{
+		{
+			name:        "csv dry run",
+			query:       "?dryRun=true",
+			contentType: "text/csv",
+			body:        "SKU,Name,Category,Price,TaxRate\nM-1,Schnitzel,Main,\"14,5\",19\nD-1,Beer,Drinks,4.2,19\n",
+			dryRun:      true,
+			expected:    expectations{statusCode: http.StatusOK, rows: imported},
+		},
+		{
+			name:     "yaml import",
+			query:    "?format=yaml",
+			body:     "- sku: M-1\n  name: Schnitzel\n  category: Main\n  price: 14.5\n  taxRate: 19\n- sku: D-1\n  name: Beer\n  category: Drinks\n  price: 4.2\n  taxRate: 19\n",
+			expected: expectations{statusCode: http.StatusOK, rows: imported},
+		},
+		{
+			name:        "invalid rows",
+			contentType: "application/json",
+			body:        `[{"SKU": "M-1", "Name": "Schnitzel", "Price": -1}, {"SKU": "M-1", "Name": ""}]`,
+			expected: expectations{
+				statusCode: http.StatusUnprocessableEntity,
+				rows: []entity.ImportRow{
+					{Row: 1, SKU: "M-1", Action: entity.ImportInvalid, Errors: []string{"Price must not be negative"}},
+					{Row: 2, SKU: "M-1", Action: entity.ImportInvalid, Errors: []string{"SKU is already used in row 1", "Name is required"}},
+				},
+			},
+		},
+		{
+			name:     "unknown format",
+			body:     "SKU;Name",
+			expected: expectations{statusCode: http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/dishes/import"+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			repo := new(entity.MockRepo)
+			repo.On("ImportDishes", dishes, tt.dryRun).Return(imported, nil)
+			DishesController{Repo: repo}.ImportDishes(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.rows != nil {
+				var rows []entity.ImportRow
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&rows))
+				assert.Equal(t, tt.expected.rows, rows)
+			}
+		})
+	}
+}
+
+func TestMenuExport(t *testing.T) {
+	dishes := []entity.Dish{{Model: gorm.Model{ID: 3}, SKU: "M-1", Name: "Schnitzel", Category: "Main", Price: 14.5, TaxRate: 19}}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/menu/export?format=csv", nil)
+	repo := new(entity.MockRepo)
+	repo.On("GetDishes", entity.DishQuery{}).Return(dishes, nil)
+	MenuController{Repo: repo}.ExportMenu(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "SKU,Name,Category,Price,TaxRate,SoldOut\nM-1,Schnitzel,Main,14.5,19,false\n", string(body))
+}
+
+func TestDishesReadFiltered(t *testing.T) {
+	priceMin, priceMax := float32(5), float32(12.5)
+	tests := []struct {
+		name       string
+		query      string
+		expected   entity.DishQuery
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "filtered by name and price",
+			query:      "?name=schni&priceMin=5&priceMax=12.5&sort=name,-price",
+			expected:   entity.DishQuery{ListOptions: entity.ListOptions{Limit: defaultLimit, Sort: "name,-price"}, Name: "schni", PriceMin: &priceMin, PriceMax: &priceMax},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "limit is capped",
+			query:      "?limit=5000&category=Drinks",
+			expected:   entity.DishQuery{ListOptions: entity.ListOptions{Limit: maxLimit}, Category: "Drinks"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "unknown sort field",
+			query:      "?sort=colour",
+			expected:   entity.DishQuery{ListOptions: entity.ListOptions{Limit: defaultLimit, Sort: "colour"}},
+			err:        fmt.Errorf("%w: can not sort by %q", entity.ErrBadRequest, "colour"),
+			statusCode: http.StatusBadRequest,
+		},
+		{name: "invalid price", query: "?priceMin=cheap", statusCode: http.StatusBadRequest},
+		{name: "price range reversed", query: "?priceMin=10&priceMax=5", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/dishes"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetDishes", tt.expected).Return([]entity.Dish{{Name: "Schnitzel", Price: 12.5}}, tt.err)
+			DishesController{Repo: repo}.ReadAllDishes(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestDishesSearch(t *testing.T) {
+	schnitzel := entity.Dish{Name: "Schnitzel", Translations: []entity.DishTranslation{{Language: "en", Name: "Escalope"}}}
+	tests := []struct {
+		name       string
+		query      string
+		text       string
+		limit      int
+		err        error
+		statusCode int
+	}{
+		{name: "successful search", query: "?q=schni", text: "schni", limit: defaultSearchLimit, statusCode: http.StatusOK},
+		{name: "search with limit", query: "?q=escal&limit=5", text: "escal", limit: 5, statusCode: http.StatusOK},
+		{name: "nothing to search for", query: "?q=+", text: " ", limit: defaultSearchLimit, err: entity.ErrBadRequest, statusCode: http.StatusBadRequest},
+		{name: "invalid limit", query: "?q=schni&limit=all", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/dishes/search"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("SearchDishes", tt.text, tt.limit).Return([]entity.Dish{schnitzel}, tt.err)
+			DishesController{Repo: repo}.SearchDishes(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			var dishes []entity.Dish
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&dishes))
+			assert.Equal(t, []entity.Dish{schnitzel}, dishes)
+		})
+	}
+}
+
+func TestDishCreateInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		fields     []entity.FieldError
+	}{
+		{name: "malformed json", body: `{"Name": "Fries",`, statusCode: http.StatusBadRequest},
+		{
+			name:       "invalid fields",
+			body:       `{"Name": " ", "Price": -2, "TaxRate": 500, "Translations": [{"Name": "Chips"}]}`,
+			statusCode: http.StatusUnprocessableEntity,
+			fields: []entity.FieldError{
+				{Field: "Name", Rule: entity.RuleRequired, Message: "is required"},
+				{Field: "Price", Rule: entity.RuleMin, Message: "must be at least 0"},
+				{Field: "TaxRate", Rule: entity.RuleOneOf, Message: "must be one of [19 7 10.7 5.5 0]"},
+				{Field: "Translations[0].Language", Rule: entity.RuleRequired, Message: "is required"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/dishes", strings.NewReader(tt.body))
+
+			repo := new(entity.MockRepo)
+			DishesController{Repo: repo}.CreateDish(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, dto.ProblemContentType, res.Header.Get("Content-Type"))
+			var body dto.Problem
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+			assert.Equal(t, tt.fields, body.Errors)
+			repo.AssertNotCalled(t, "CreateDish", mock.Anything)
+		})
+	}
+}
+
+func TestDishCreateInvalidV2(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v2/dishes", strings.NewReader(`{"name": "Fries", "taxRate": 500, "translations": [{"name": "Chips"}]}`))
+
+	repo := new(entity.MockRepo)
+	WithVersion(V2)(http.HandlerFunc(DishesController{Repo: repo}.CreateDish)).ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var body dto.Problem
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, []entity.FieldError{
+		{Field: "taxRate", Rule: entity.RuleOneOf, Message: "must be one of [19 7 10.7 5.5 0]"},
+		{Field: "translations[0].language", Rule: entity.RuleRequired, Message: "is required"},
+	}, body.Errors)
+	repo.AssertNotCalled(t, "CreateDish", mock.Anything)
+}
+
+func TestDishPatchV2(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPatch, "/v2/dishes/3", strings.NewReader(`{"price": 0, "sku": null, "translations": null}`))
+	r.Header.Set("Content-Type", patch.MergePatchContentType)
+	r.Header.Set("If-Match", `"2"`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	repo := new(entity.MockRepo)
+	repo.On("GetDish", uint(3)).Return(entity.Dish{
+		Model: gorm.Model{ID: 3}, SKU: "S-1", Name: "Fries", Category: "Sides", Price: 4.5, TaxRate: 19, Version: 2,
+		Translations: []entity.DishTranslation{{ID: 1, DishID: 3, Language: "en", Name: "Chips"}},
+	}, nil)
+	repo.On("UpdateDish", entity.Dish{Model: gorm.Model{ID: 3}, Name: "Fries", Category: "Sides", TaxRate: 19, Version: 2}).Return(nil)
+	WithVersion(V2)(http.HandlerFunc(DishesController{Repo: repo}.PatchDishById)).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode, w.Body.String())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+)
+
+func toDish(d dto.DishRequest) entity.Dish {
+	return entity.Dish{
+		SKU:          d.SKU,
+		Name:         d.Name,
+		Description:  d.Description,
+		Category:     d.Category,
+		Price:        d.Price,
+		TaxRate:      d.TaxRate,
+		SoldOut:      d.SoldOut,
+		Translations: mapSlice(d.Translations, toDishTranslation),
+	}
+}
+
+func toDishTranslation(t dto.Translation) entity.DishTranslation {
+	return entity.DishTranslation{Language: t.Language, Name: t.Name, Description: t.Description}
+}
+
+func NewTranslation(t entity.DishTranslation) dto.Translation {
+	return dto.Translation{Language: t.Language, Name: t.Name, Description: t.Description}
+}
+
+func NewDishResponse(d entity.Dish) dto.DishResponse {
+	return dto.DishResponse{
+		ID:           d.ID,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+		SKU:          d.SKU,
+		Name:         d.Name,
+		Description:  d.Description,
+		Category:     d.Category,
+		Price:        d.Price,
+		TaxRate:      d.TaxRate,
+		SoldOut:      d.SoldOut,
+		Version:      d.Version,
+		Translations: nonNil(mapSlice(d.Translations, NewTranslation)),
+	}
+}
+
+func toRecipeItem(i dto.RecipeItemRequest) entity.RecipeItem {
+	return entity.RecipeItem{IngredientID: i.IngredientID, Quantity: i.Quantity}
+}
+
+func NewRecipeItemResponse(i entity.RecipeItem) dto.RecipeItemResponse {
+	return dto.RecipeItemResponse{
+		DishID:       i.DishID,
+		IngredientID: i.IngredientID,
+		Ingredient:   i.Ingredient.Name,
+		Unit:         i.Ingredient.Unit,
+		UnitCost:     i.Ingredient.UnitCost,
+		Quantity:     i.Quantity,
+	}
+}
+
+func NewImportRowResponse(r entity.ImportRow) dto.ImportRowResponse {
+	return dto.ImportRowResponse{Row: r.Row, SKU: r.SKU, Action: r.Action, DishID: r.DishID, Errors: r.Errors}
+}
+
+func NewMarginReportResponse(m entity.MarginReport) dto.MarginReportResponse {
+	return dto.MarginReportResponse{
+		From:          m.From,
+		To:            m.To,
+		Dishes:        nonNil(mapSlice(m.Dishes, NewDishMarginResponse)),
+		Categories:    nonNil(mapSlice(m.Categories, NewCategoryMarginResponse)),
+		Sales:         nonNil(mapSlice(m.Sales, NewSalesMarginResponse)),
+		Revenue:       m.Revenue,
+		Cost:          m.Cost,
+		Margin:        m.Margin,
+		MarginPercent: m.MarginPercent,
+	}
+}
+
+func NewDishMarginResponse(m entity.DishMargin) dto.DishMarginResponse {
+	return dto.DishMarginResponse{
+		DishID:        m.DishID,
+		Name:          m.Name,
+		Category:      m.Category,
+		Price:         m.Price,
+		Cost:          m.Cost,
+		Margin:        m.Margin,
+		MarginPercent: m.MarginPercent,
+	}
+}
+
+func NewCategoryMarginResponse(m entity.CategoryMargin) dto.CategoryMarginResponse {
+	return dto.CategoryMarginResponse{
+		Category:      m.Category,
+		Dishes:        m.Dishes,
+		Price:         m.Price,
+		Cost:          m.Cost,
+		Margin:        m.Margin,
+		MarginPercent: m.MarginPercent,
+	}
+}
+
+func NewSalesMarginResponse(m entity.SalesMargin) dto.SalesMarginResponse {
+	return dto.SalesMarginResponse{
+		DishID:        m.DishID,
+		Name:          m.Name,
+		Category:      m.Category,
+		Quantity:      m.Quantity,
+		Revenue:       m.Revenue,
+		Cost:          m.Cost,
+		Margin:        m.Margin,
+		MarginPercent: m.MarginPercent,
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errIfMatchRequired = entity.NewError(entity.ErrPreconditionRequired, "If-Match with the ETag of the record is required")
+
+// setETag tags the response with the version of the record it sends or changed.
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatch returns the version of the If-Match header, which updates and deletes need
+// to not overwrite changes they have not seen. A tag which is no version never matches.
+// It reports whether the version can be used, otherwise the response is sent already.
+func ifMatch(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" {
+		SendProblem(w, r, errIfMatchRequired)
+		fmt.Println("Missing If-Match")
+		return 0, false
+	}
+	version, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`), 10, 0)
+	if err != nil || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		SendProblem(w, r, entity.ErrVersionMismatch)
+		fmt.Println("Invalid If-Match", tag)
+		return 0, false
+	}
+	return uint(version), true
+}
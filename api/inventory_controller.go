@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type InventoryController struct {
+	Repo entity.Repo
+}
+
+func (i InventoryController) RegisterRoutes(r chi.Router) {
+	r.With(kitchen).Get("/", i.ReadInventory)
+	r.With(managers).Post("/ingredients", i.CreateIngredient)
+	r.With(kitchen).Get("/ingredients", i.ReadAllIngredients)
+	r.With(kitchen).Get("/ingredients/{id}", i.ReadIngredientById)
+	r.With(kitchen).Put("/ingredients/{id}", i.UpdateIngredientById)
+	r.With(managers).Delete("/ingredients/{id}", i.DeleteIngredientById)
+	r.With(managers).Get("/ingredients/{id}/costs", i.ReadIngredientCosts)
+}
+
+func (i InventoryController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Pattern: "/", Summary: "Read the stock of the ingredients", Response: []entity.InventoryItem{}},
+		{Method: http.MethodPost, Pattern: "/ingredients", Summary: "Create an ingredient", Request: entity.Ingredient{}, Response: entity.Ingredient{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/ingredients", Summary: "List ingredients", Response: []entity.Ingredient{}},
+		{Method: http.MethodGet, Pattern: "/ingredients/{id}", Summary: "Read an ingredient", Response: entity.Ingredient{}},
+		{Method: http.MethodPut, Pattern: "/ingredients/{id}", Summary: "Update an ingredient", Request: entity.Ingredient{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Pattern: "/ingredients/{id}", Summary: "Delete an ingredient", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Pattern: "/ingredients/{id}/costs", Summary: "Read the cost history of an ingredient", Response: []entity.IngredientCost{}},
+	}
+}
+
+func (i InventoryController) ReadInventory(w http.ResponseWriter, r *http.Request) {
+	inventory, err := i.Repo.GetInventory()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not read inventory", err)
+		return
+	}
+	SendJson(w, http.StatusOK, inventory)
+	fmt.Println("Found inventory")
+}
+
+func (i InventoryController) CreateIngredient(w http.ResponseWriter, r *http.Request) {
+	var ingredient entity.Ingredient
+	if !decodeJson(w, r, &ingredient) || !valid(w, r, ingredient.ValidateNew()) {
+		return
+	}
+	err := repoFor(i.Repo, r).CreateIngredient(&ingredient)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not create ingredient", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, ingredient)
+	fmt.Println("Added ingredient")
+}
+
+func (i InventoryController) ReadAllIngredients(w http.ResponseWriter, r *http.Request) {
+	ingredients, err := i.Repo.GetIngredients()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find ingredients", err)
+		return
+	}
+	SendJson(w, http.StatusOK, ingredients)
+	fmt.Println("Found ingredients")
+}
+
+func (i InventoryController) ReadIngredientById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	ingredient, err := i.Repo.GetIngredient(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find ingredient", err)
+		return
+	}
+	SendJson(w, http.StatusOK, ingredient)
+	fmt.Println("Found ingredient")
+}
+
+func (i InventoryController) UpdateIngredientById(w http.ResponseWriter, r *http.Request) {
+	var ingredient entity.Ingredient
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &ingredient) {
+		return
+	}
+	ingredient.ID = uint(id)
+	err := repoFor(i.Repo, r).UpdateIngredient(&ingredient)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update ingredient", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Updated ingredient")
+}
+
+func (i InventoryController) DeleteIngredientById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	err := repoFor(i.Repo, r).DeleteIngredient(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not delete ingredient", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Deleted ingredient")
+}
+
+func (i InventoryController) ReadIngredientCosts(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	costs, err := i.Repo.GetIngredientCosts(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find ingredient costs", err)
+		return
+	}
+	SendJson(w, http.StatusOK, costs)
+	fmt.Println("Found ingredient costs")
+}
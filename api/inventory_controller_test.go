@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestInventoryRead(t *testing.T) {
+	type expectations struct {
+		statusCode  int
+		respPayload any
+	}
+	item := entity.InventoryItem{IngredientID: 1, Name: "Potato", Unit: "kg", Stock: 2, LowStockThreshold: 5, LowStock: true, DishIDs: []uint{3}}
+
+	tests := []struct {
+		name        string
+		existing    []entity.InventoryItem
+		expected    expectations
+		respPayload any
+		err         error
+	}{
+		{
+			name:     "successful get inventory",
+			existing: []entity.InventoryItem{item},
+			expected: expectations{
+				statusCode: http.StatusOK,
+				respPayload: []interface{}{map[string]interface{}{
+					"IngredientID":      float64(item.IngredientID),
+					"Name":              item.Name,
+					"Unit":              item.Unit,
+					"Stock":             float64(item.Stock),
+					"LowStockThreshold": float64(item.LowStockThreshold),
+					"LowStock":          true,
+					"DishIDs":           []interface{}{float64(3)},
+				}},
+			},
+		},
+		{
+			name: "failed to read inventory",
+			err:  errors.New("mock repo says no"),
+			expected: expectations{
+				statusCode:  http.StatusInternalServerError,
+				respPayload: problem(http.StatusInternalServerError, "Unknown error"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/inventory/", nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetInventory").Return(tt.existing, tt.err)
+			InventoryController{Repo: repo}.ReadInventory(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.respPayload != nil {
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
+				assert.EqualValues(t, tt.expected.respPayload, tt.respPayload)
+			}
+		})
+	}
+}
+
+func TestIngredientUpdateById(t *testing.T) {
+	type expectations struct {
+		statusCode  int
+		respPayload any
+	}
+	ingredient := entity.Ingredient{Model: gorm.Model{ID: 1}, Name: "Potato", Unit: "kg", Stock: 20, LowStockThreshold: 5}
+	notFoundErr := entity.RecordNotFoundError{
+		Kind:  "Ingredient",
+		ID:    strconv.FormatInt(int64(ingredient.ID), 10),
+		Inner: errors.New("mock repo says no"),
+	}
+
+	tests := []struct {
+		name        string
+		payload     entity.Ingredient
+		respPayload any
+		existing    entity.Ingredient
+		expected    expectations
+		err         error
+	}{
+		{
+			name:     "successful restock",
+			existing: ingredient,
+			payload:  ingredient,
+			expected: expectations{
+				statusCode: http.StatusNoContent,
+			},
+		},
+		{
+			name:     "partial restock",
+			existing: ingredient,
+			payload:  entity.Ingredient{Model: ingredient.Model, Stock: 5},
+			expected: expectations{
+				statusCode: http.StatusNoContent,
+			},
+		},
+		{
+			name:     "negative stock",
+			existing: ingredient,
+			payload:  entity.Ingredient{Model: ingredient.Model, Stock: -1},
+			expected: expectations{
+				statusCode: http.StatusUnprocessableEntity,
+				respPayload: problem(http.StatusUnprocessableEntity, fmt.Sprintf("%v: Stock must be at least 0", entity.ErrInvalidData),
+					entity.FieldError{Field: "Stock", Rule: entity.RuleMin, Message: "must be at least 0"}),
+			},
+		},
+		{
+			name: "ingredient doesn't exist",
+			err:  notFoundErr,
+			expected: expectations{
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, notFoundErr.Error()),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPut, "/inventory/ingredients/{id}", b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", strconv.FormatUint(uint64(tt.existing.ID), 10))
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			repo := new(entity.MockRepo)
+			repo.On("UpdateIngredient", tt.payload).Return(tt.err)
+			InventoryController{Repo: repo}.UpdateIngredientById(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.respPayload != nil {
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
+				assert.EqualValues(t, tt.expected.respPayload, tt.respPayload)
+			}
+		})
+	}
+}
+
+func TestOrderItemsAdd(t *testing.T) {
+	type expectations struct {
+		statusCode  int
+		respPayload any
+	}
+	items := []entity.OrderItem{{DishID: 2, Quantity: 3}}
+
+	tests := []struct {
+		name     string
+		orderID  uint
+		payload  []entity.OrderItem
+		expected expectations
+		err      error
+		role     string
+	}{
+		{
+			name:     "successful add items",
+			orderID:  1,
+			payload:  items,
+			expected: expectations{statusCode: http.StatusCreated},
+		},
+		{
+			name:    "dish is sold out",
+			orderID: 1,
+			payload: items,
+			err:     entity.ErrDishSoldOut,
+			expected: expectations{
+				statusCode:  http.StatusConflict,
+				respPayload: problem(http.StatusConflict, entity.ErrDishSoldOut.Error()),
+			},
+		},
+		{
+			name:     "waiter can not override prices",
+			orderID:  1,
+			payload:  []entity.OrderItem{{DishID: 2, Quantity: 1, Price: 5}},
+			role:     entity.RoleWaiter,
+			expected: expectations{statusCode: http.StatusForbidden},
+		},
+		{
+			name:     "manager overrides price",
+			orderID:  1,
+			payload:  []entity.OrderItem{{DishID: 2, Quantity: 1, Price: 5}},
+			role:     entity.RoleManager,
+			expected: expectations{statusCode: http.StatusCreated},
+		},
+		{
+			name:    "invalid quantity",
+			orderID: 1,
+			payload: []entity.OrderItem{{DishID: 2, Quantity: 1}, {DishID: 3, Quantity: -1}},
+			expected: expectations{
+				statusCode: http.StatusUnprocessableEntity,
+				respPayload: problem(http.StatusUnprocessableEntity, fmt.Sprintf("%v: [1].Quantity must be positive", entity.ErrInvalidData),
+					entity.FieldError{Field: "[1].Quantity", Rule: entity.RulePositive, Message: "must be positive"}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPost, "/orders/{id}/items", b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", strconv.FormatUint(uint64(tt.orderID), 10))
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			if tt.role != "" {
+				r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: tt.role}))
+			}
+
+			repo := new(entity.MockRepo)
+			repo.On("AddOrderItems", tt.orderID, tt.payload).Return(tt.err)
+			OrdersController{Repo: repo}.AddOrderItems(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.respPayload != nil {
+				var respPayload any
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+				assert.EqualValues(t, tt.expected.respPayload, respPayload)
+			}
+		})
+	}
+}
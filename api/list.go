@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"net/http"
+	"strconv"
+)
+
+// List endpoints return at most maxLimit rows, defaultLimit if the request has no limit.
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// parseListOptions reads the limit, offset, cursor and sort query parameters.
+func parseListOptions(r *http.Request) (o entity.ListOptions, err error) {
+	q := r.URL.Query()
+	o.Limit = defaultLimit
+	if v := q.Get("limit"); v != "" {
+		if o.Limit, err = strconv.Atoi(v); err != nil || o.Limit <= 0 {
+			return o, fmt.Errorf("%w: limit", entity.ErrBadRequest)
+		}
+		o.Limit = min(o.Limit, maxLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		if o.Offset, err = strconv.Atoi(v); err != nil {
+			return o, fmt.Errorf("%w: offset", entity.ErrBadRequest)
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		after, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return o, fmt.Errorf("%w: cursor", entity.ErrBadRequest)
+		}
+		o.After = uint(after)
+	}
+	o.Sort = q.Get("sort")
+	return o, o.Validate()
+}
+
+func parseOrderQuery(r *http.Request) (q entity.OrderQuery, err error) {
+	if q.ListOptions, err = parseListOptions(r); err != nil {
+		return q, err
+	}
+	v := r.URL.Query()
+	q.Status = v.Get("status")
+	if t := v.Get("table"); t != "" {
+		table, err := strconv.Atoi(t)
+		if err != nil {
+			return q, fmt.Errorf("%w: table", entity.ErrBadRequest)
+		}
+		q.TableNumber = &table
+	}
+	if t := v.Get("createdFrom"); t != "" {
+		if q.CreatedFrom, err = parseTime(t); err != nil {
+			return q, fmt.Errorf("%w: createdFrom: %w", entity.ErrBadRequest, err)
+		}
+	}
+	if t := v.Get("createdTo"); t != "" {
+		if q.CreatedTo, err = parseTime(t); err != nil {
+			return q, fmt.Errorf("%w: createdTo: %w", entity.ErrBadRequest, err)
+		}
+		if len(t) == len(dateLayout) {
+			q.CreatedTo = q.CreatedTo.AddDate(0, 0, 1)
+		}
+	}
+	return q, q.Validate()
+}
+
+func parseDishQuery(r *http.Request) (q entity.DishQuery, err error) {
+	if q.ListOptions, err = parseListOptions(r); err != nil {
+		return q, err
+	}
+	v := r.URL.Query()
+	q.Name = v.Get("name")
+	q.Category = v.Get("category")
+	if q.PriceMin, err = parsePrice(v.Get("priceMin")); err != nil {
+		return q, fmt.Errorf("%w: priceMin", entity.ErrBadRequest)
+	}
+	if q.PriceMax, err = parsePrice(v.Get("priceMax")); err != nil {
+		return q, fmt.Errorf("%w: priceMax", entity.ErrBadRequest)
+	}
+	return q, q.Validate()
+}
+
+func parsePrice(v string) (*float32, error) {
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(v, 32)
+	if err != nil {
+		return nil, err
+	}
+	p := float32(price)
+	return &p, nil
+}
+
+// setNextPage links the next page of a full page in the Link header. Lists sorted by id
+// are paged with a cursor, which is also set as X-Next-Cursor, other lists with an offset.
+func setNextPage(w http.ResponseWriter, r *http.Request, o entity.ListOptions, count int, lastId uint) {
+	if count < o.Limit {
+		return
+	}
+	q := r.URL.Query()
+	if o.ByID() && o.Offset == 0 {
+		cursor := strconv.FormatUint(uint64(lastId), 10)
+		q.Set("cursor", cursor)
+		w.Header().Set("X-Next-Cursor", cursor)
+	} else {
+		q.Set("offset", strconv.Itoa(o.Offset+o.Limit))
+	}
+	w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, q.Encode()))
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/menu"
+	"gorestserviceagain/openapi"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type MenuController struct {
+	Repo entity.Repo
+}
+
+func (m MenuController) RegisterRoutes(r chi.Router) {
+	r.With(managers).Get("/export", m.ExportMenu)
+}
+
+func (m MenuController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Pattern: "/export", Summary: "Export the menu", Query: []openapi.Param{
+			{Name: "format", Enum: []string{menu.FormatJSON, menu.FormatCSV, menu.FormatYAML}},
+		}, Produces: []string{menu.ContentType(menu.FormatJSON), menu.ContentType(menu.FormatCSV), menu.ContentType(menu.FormatYAML)}},
+	}
+}
+
+func (m MenuController) ExportMenu(w http.ResponseWriter, r *http.Request) {
+	format := menu.FormatJSON
+	if v := r.URL.Query().Get("format"); v != "" {
+		format = menu.Format(v)
+	}
+	if format == "" {
+		SendProblem(w, r, menu.ErrUnknownFormat)
+		return
+	}
+	dishes, err := m.Repo.GetDishes(entity.DishQuery{})
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find dishes", err)
+		return
+	}
+	items := make([]menu.Item, len(dishes))
+	for i, d := range dishes {
+		items[i] = menu.FromDish(d)
+	}
+	w.Header().Set("Content-Type", menu.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"menu.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+	if err := menu.Encode(format, w, items); err != nil {
+		fmt.Println("Can not export menu", err)
+		return
+	}
+	fmt.Println("Exported menu")
+}
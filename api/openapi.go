@@ -0,0 +1,37 @@
+package api
+
+import (
+	"gorestserviceagain/openapi"
+	"reflect"
+)
+
+// listParams are the query parameters of the paged lists, see parseListOptions.
+var listParams = []openapi.Param{
+	{Name: "limit", Type: "integer", Description: "rows per page, at most 1000"},
+	{Name: "offset", Type: "integer", Description: "rows to skip"},
+	{Name: "cursor", Type: "integer", Description: "id of the last row of the previous page, from X-Next-Cursor"},
+	{Name: "sort", Description: "comma separated fields, descending with a leading -"},
+}
+
+// DateRangeParams are the query parameters read by ParseDateRange.
+var DateRangeParams = []openapi.Param{
+	{Name: "from", Description: "date or RFC 3339 time, 30 days ago by default"},
+	{Name: "to", Description: "date, which is included, or RFC 3339 time, now by default"},
+}
+
+// V2Operations returns ops with the v2 request and response types of their bodies.
+func V2Operations(ops []openapi.Operation) []openapi.Operation {
+	v2 := make([]openapi.Operation, len(ops))
+	for i, op := range ops {
+		if op.Request != nil {
+			if req, ok := requestsV2[reflect.PointerTo(reflect.TypeOf(op.Request))]; ok {
+				op.Request = reflect.Zero(req.typ).Interface()
+			}
+		}
+		if op.Response != nil {
+			op.Response = responseV2(op.Response)
+		}
+		v2[i] = op
+	}
+	return v2
+}
@@ -1,55 +1,138 @@
 package api
 
 import (
-	"encoding/json"
-	"errors"
+	"bytes"
 	"fmt"
+	"gorestserviceagain/auth"
 	"gorestserviceagain/entity"
+	"gorestserviceagain/export"
+	"gorestserviceagain/fiscal"
+	"gorestserviceagain/openapi"
+	"gorestserviceagain/printing"
+	"gorestserviceagain/receipt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	KitchenPrinter = "kitchen"
+	CounterPrinter = "counter"
+)
+
 type OrdersController struct {
-	Repo entity.Repo
+	Repo       entity.Repo
+	Restaurant receipt.Restaurant
+	Spooler    *printing.Spooler
+	Signer     entity.FiscalSigner
+	// Lockout is shared with the PIN logins, see approvals.
+	Lockout *auth.Lockout
 }
 
 func (o OrdersController) RegisterRoutes(r chi.Router) {
-	r.Post("/", o.CreateOrder)
-	r.Get("/", o.ReadAllOrders)
-	r.Get("/{id}", o.ReadOrderById)
-	r.Put("/{id}", o.UpdateOderById)
-	r.Put("/{orderId}/dishes/{dishId} ", o.UpdateDiscountById)
-	r.Delete("/{id}", o.DeleteOrderById)
+	r.With(waiters).Post("/", o.CreateOrder)
+	r.With(staff).Get("/", o.ReadAllOrders)
+	r.With(managers).Get("/export", o.ExportOrders)
+	r.With(staff).Get("/{id}", o.ReadOrderById)
+	r.With(waiters).Put("/{id}", o.UpdateOderById)
+	r.With(waiters).Patch("/{id}", o.PatchOrderById)
+	r.With(waiters, approvals(o.Repo, o.Lockout).Require(auth.PermDiscountUpTo20)).Put("/{orderId}/dishes/{dishId}", o.UpdateDiscountById)
+	r.With(waiters, approvals(o.Repo, o.Lockout).Require(auth.PermOrderVoid)).Delete("/{id}", o.DeleteOrderById)
+	r.With(waiters).Post("/{id}/items", o.AddOrderItems)
+	r.With(waiters, approvals(o.Repo, o.Lockout).Require(auth.PermOrderVoid)).Post("/{id}/items/{itemId}/void", o.VoidOrderItem)
+	r.With(waiters, approvals(o.Repo, o.Lockout).Require(auth.PermItemComp)).Post("/{id}/items/{itemId}/comp", o.CompOrderItem)
+	r.With(waiters).Post("/{id}/payments", o.AddPayment)
+	r.With(waiters).Get("/{id}/receipt", o.ReadReceipt)
+	r.With(staff).Post("/{id}/print", o.PrintOrder)
+}
+
+func (o OrdersController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Create an order", Description: "The order is added to the open shift, without one it is a conflict. Items are charged like added items, discounts and payments are added by their own routes.", Request: entity.Order{}, Response: entity.Order{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List orders", Response: []entity.Order{}, Query: append([]openapi.Param{
+			{Name: "status", Enum: []string{entity.OrderOpen, entity.OrderPaid, entity.OrderVoided}},
+			{Name: "table", Type: "integer"},
+			{Name: "createdFrom", Description: "date or RFC 3339 time"},
+			{Name: "createdTo", Description: "date, which is included, or RFC 3339 time"},
+		}, listParams...)},
+		{Method: http.MethodGet, Pattern: "/export", Summary: "Export the order items", Query: append([]openapi.Param{
+			{Name: "format", Enum: []string{export.FormatCSV, export.FormatXLSX, export.FormatDSFinVK}},
+		}, DateRangeParams...), Produces: []string{
+			export.ContentType(export.FormatCSV), export.ContentType(export.FormatXLSX), export.ContentType(export.FormatDSFinVK),
+		}},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read an order", Response: entity.Order{}},
+		{Method: http.MethodPut, Pattern: "/{id}", Summary: "Replace the table number and final price of an order", Description: "Fields which are left out are set to their zero value. The items are changed by their own routes.", Request: entity.Order{}, Status: http.StatusNoContent},
+		{Method: http.MethodPatch, Pattern: "/{id}", Summary: "Update the table number or final price of an order", Description: "Takes a JSON Merge Patch or a JSON Patch of the order as it is read. Null removes a field, which sets it to its zero value.", Request: entity.Order{}, Consumes: patchContentTypes, Status: http.StatusNoContent},
+		{Method: http.MethodPut, Pattern: "/{orderId}/dishes/{dishId}", Summary: "Update the discount on a dish of an order", Request: entity.DiscountDetail{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Pattern: "/{id}", Summary: "Void an order", Status: http.StatusNoContent},
+		{Method: http.MethodPost, Pattern: "/{id}/items", Summary: "Add items to an order", Request: []entity.OrderItem{}, Response: []entity.OrderItem{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Pattern: "/{id}/items/{itemId}/void", Summary: "Void an order item", Request: entity.ItemAdjustment{}, Response: entity.OrderItem{}},
+		{Method: http.MethodPost, Pattern: "/{id}/items/{itemId}/comp", Summary: "Comp an order item", Request: entity.ItemAdjustment{}, Response: entity.OrderItem{}},
+		{Method: http.MethodPost, Pattern: "/{id}/payments", Summary: "Add a payment to an order", Request: entity.Payment{}, Response: entity.Payment{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/{id}/receipt", Summary: "Render the receipt of an order", Query: []openapi.Param{
+			{Name: "format", Enum: []string{receipt.FormatText, receipt.FormatHTML, receipt.FormatPDF}},
+		}, Produces: []string{
+			receipt.ContentType(receipt.FormatText), receipt.ContentType(receipt.FormatHTML), receipt.ContentType(receipt.FormatPDF),
+		}},
+		{Method: http.MethodPost, Pattern: "/{id}/print", Summary: "Print the receipt or kitchen ticket of an order", Query: []openapi.Param{
+			{Name: "ticket", Enum: []string{KitchenPrinter}, Description: "the kitchen ticket instead of the receipt"},
+			{Name: "printer", Description: "the counter printer or, for kitchen tickets, the kitchen printer by default"},
+		}, Status: http.StatusAccepted},
+	}
 }
 
 func (o OrdersController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var order entity.Order
-	err := json.NewDecoder(r.Body).Decode(&order)
-	if err != nil {
-		fmt.Println(entity.ErrJson)
-		SendErr(w, http.StatusUnprocessableEntity, entity.ErrJson.Error())
+	if !decode(w, r, &order) || !authorizePrices(w, r, approvals(o.Repo, o.Lockout), order.Items) {
 		return
 	}
-	err = o.Repo.CreateOrder(&order)
+	// Discounts, payments and signatures are added by their own routes, which check them.
+	order.DiscountDetail, order.Payments, order.Signatures = nil, nil, nil
+	order.UserID = userId(r)
+	err := repoFor(o.Repo, r).CreateOrder(&order)
 	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
-		fmt.Println("Can not add order")
+		SendProblem(w, r, err)
+		fmt.Println("Can not add order", err)
 	} else {
-		SendJson(w, http.StatusCreated, order)
+		setETag(w, order.Version)
+		Respond(w, r, http.StatusCreated, order)
 		fmt.Println("Added order")
 	}
 }
+
+// authorizePrices requires PermPriceOverride if an item has a price. Items are charged
+// with the dish price, unless the price is overridden.
+func authorizePrices(w http.ResponseWriter, r *http.Request, approvals auth.Approvals, items []entity.OrderItem) bool {
+	for _, item := range items {
+		if item.Price != 0 {
+			return authorize(w, r, approvals, auth.PermPriceOverride)
+		}
+	}
+	return true
+}
+
+// ReadAllOrders returns a page of the orders, filtered by status, table and createdFrom
+// and createdTo, see parseOrderQuery.
 func (o OrdersController) ReadAllOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := o.Repo.GetOrders()
+	query, err := parseOrderQuery(r)
 	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
-		fmt.Println("Can not find orders")
-	} else {
-		SendJson(w, http.StatusOK, orders)
-		fmt.Println("Found order")
+		SendProblem(w, r, err)
+		fmt.Println("Invalid order query", err)
+		return
+	}
+	orders, err := o.Repo.GetOrders(query)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find orders", err)
+		return
 	}
+	if len(orders) > 0 {
+		setNextPage(w, r, query.ListOptions, len(orders), orders[len(orders)-1].ID)
+	}
+	Respond(w, r, http.StatusOK, orders)
+	fmt.Println("Found order")
 }
 func (o OrdersController) ReadOrderById(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
@@ -57,103 +140,392 @@ func (o OrdersController) ReadOrderById(w http.ResponseWriter, r *http.Request)
 
 	order, err := o.Repo.GetOrder(uint(id))
 	if err != nil {
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, err.Error())
-			fmt.Println("Can not find order")
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Unknown error")
-			fmt.Println("Inner issue, can not find order")
-		}
+		SendProblem(w, r, err)
+		fmt.Println("Can not find order", err)
 		return
 	}
-	SendJson(w, http.StatusOK, order)
+	setETag(w, order.Version)
+	Respond(w, r, http.StatusOK, order)
 	fmt.Println("Found order")
 }
 
 func (o OrdersController) UpdateOderById(w http.ResponseWriter, r *http.Request) {
 	var order entity.Order
 	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
-	err := json.NewDecoder(r.Body).Decode(&order)
-	if err != nil {
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
-		fmt.Println(entity.ErrJson)
+	version, ok := ifMatch(w, r)
+	if !ok || !decode(w, r, &order) {
 		return
 	}
 	order.ID = uint(id)
-	err = o.Repo.UpdateOrder(&order)
-	if err != nil {
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, err.Error())
-			fmt.Println("Can not update the order")
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Unknown error")
-			fmt.Println("Inner error, can not update order")
-		}
+	order.Version = version
+	err := repoFor(o.Repo, r).UpdateOrder(&order)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update the order", err)
 		return
 	}
-	SendJson(w, http.StatusNoContent, nil)
+	setETag(w, order.Version)
+	Respond(w, r, http.StatusNoContent, nil)
 	fmt.Println("Order is updated")
 }
 
+// PatchOrderById applies a patch to the order, see decodePatch.
+func (o OrdersController) PatchOrderById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	current, err := o.Repo.GetOrder(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find order", err)
+		return
+	}
+	if current.Version != version {
+		SendProblem(w, r, entity.ErrVersionMismatch)
+		fmt.Println("Can not patch the order", entity.ErrVersionMismatch)
+		return
+	}
+	var order entity.Order
+	if !decodePatch(w, r, current, &order) {
+		return
+	}
+	order.ID = uint(id)
+	order.Version = version
+	err = repoFor(o.Repo, r).UpdateOrder(&order)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update the order", err)
+		return
+	}
+	setETag(w, order.Version)
+	Respond(w, r, http.StatusNoContent, nil)
+	fmt.Println("Order is patched")
+}
+
 func (o OrdersController) UpdateDiscountById(w http.ResponseWriter, r *http.Request) {
 	var discount entity.DiscountDetail
 	orderId, _ := strconv.ParseUint(chi.URLParam(r, "orderId"), 10, 64)
 	dishId, _ := strconv.ParseUint(chi.URLParam(r, "dishId"), 10, 64)
-	err := json.NewDecoder(r.Body).Decode(&discount)
-	if err != nil {
-		fmt.Println("Can not convert json to object")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+	version, ok := ifMatch(w, r)
+	if !ok || !decode(w, r, &discount) {
+		return
 	}
 
 	discount.OrderID = uint(orderId)
 	discount.DishID = uint(dishId)
-	_, err = o.Repo.GetOrder(discount.OrderID)
+	discount.Version = version
+	discount.UserID = userId(r)
+	_, err := o.Repo.GetOrder(discount.OrderID)
 	if err != nil {
-		fmt.Println("Customer does not exsist")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
+		fmt.Println("Customer does not exsist", err)
 		return
 	}
 	fmt.Printf("Found the custome and id is %v\n", discount.OrderID)
 
-	_, err = o.Repo.GetDish(discount.DishID)
+	dish, err := o.Repo.GetDish(discount.DishID)
 	if err != nil {
-		fmt.Println("Dish does not exsist")
-		SendErr(w, http.StatusUnprocessableEntity, err.Error())
+		SendProblem(w, r, err)
+		fmt.Println("Dish does not exsist", err)
 		return
 	}
 	fmt.Printf("Found the dish and id is %v\n", discount.DishID)
+	if !authorizeDiscount(w, r, approvals(o.Repo, o.Lockout), discount, dish) {
+		return
+	}
 
-	err = o.Repo.UpdateDiscount(&discount)
+	err = repoFor(o.Repo, r).UpdateDiscount(&discount)
 	if err != nil {
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, err.Error())
-		} else {
-			SendErr(w, http.StatusInternalServerError, "Uknown error")
-		}
+		SendProblem(w, r, err)
 		return
 	}
-	SendJson(w, http.StatusNoContent, nil)
+	setETag(w, discount.Version)
+	Respond(w, r, http.StatusNoContent, nil)
 	fmt.Println("Discount is updated")
 }
 
 func (o OrdersController) DeleteOrderById(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	fmt.Printf("id: %#v\n", id)
-	err := o.Repo.DeleteOrder(uint(id))
-	if err != nil {
-		notFoundErr := entity.RecordNotFoundError{}
-		if errors.As(err, &notFoundErr) {
-			SendErr(w, http.StatusNotFound, err.Error())
-			fmt.Println("Can not find the id, so can not delete the order")
-		} else {
-			SendErr(w, http.StatusInternalServerError, err.Error())
-			fmt.Println("Inner error, can not delete oreder")
-		}
+	version, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
-	SendJson(w, http.StatusNoContent, nil)
+	// The voided order can not be loaded anymore once it is deleted.
+	var voided entity.Order
+	if o.Signer != nil {
+		voided, _ = o.Repo.GetOrder(uint(id))
+	}
+	err := repoFor(o.Repo, r).DeleteOrder(uint(id), version)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not delete the order", err)
+		return
+	}
+	if len(voided.Items) > 0 {
+		o.sign(r, voided, entity.FiscalVoided)
+	}
+	Respond(w, r, http.StatusNoContent, nil)
 	fmt.Println("Deleted order")
 }
+
+func (o OrdersController) AddOrderItems(w http.ResponseWriter, r *http.Request) {
+	var items []entity.OrderItem
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &items) || !valid(w, r, entity.ValidateEach(items)) || !authorizePrices(w, r, approvals(o.Repo, o.Lockout), items) {
+		return
+	}
+	err := repoFor(o.Repo, r).AddOrderItems(uint(id), items)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not add items to order", err)
+		return
+	}
+	Respond(w, r, http.StatusCreated, items)
+	fmt.Println("Added order items")
+	o.printKitchenTicket(uint(id), items)
+}
+
+// VoidOrderItem takes back an item entered by mistake. The item stays on the order with
+// the reason, but is not charged anymore.
+func (o OrdersController) VoidOrderItem(w http.ResponseWriter, r *http.Request) {
+	o.adjustOrderItem(w, r, entity.ItemVoided)
+}
+
+// CompOrderItem gives an item to the guest on the house.
+func (o OrdersController) CompOrderItem(w http.ResponseWriter, r *http.Request) {
+	o.adjustOrderItem(w, r, entity.ItemComped)
+}
+
+func (o OrdersController) adjustOrderItem(w http.ResponseWriter, r *http.Request, status string) {
+	var adjustment entity.ItemAdjustment
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	itemId, _ := strconv.ParseUint(chi.URLParam(r, "itemId"), 10, 64)
+	if !decodeJson(w, r, &adjustment) {
+		return
+	}
+	adjustment.Status = status
+	adjustment.UserID = userId(r)
+	if !valid(w, r, adjustment.Validate()) {
+		return
+	}
+	item, err := repoFor(o.Repo, r).AdjustOrderItem(uint(id), uint(itemId), adjustment)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not", status, "order item", err)
+		return
+	}
+	Respond(w, r, http.StatusOK, item)
+	fmt.Println("Order item", item.ID, status)
+}
+
+// printKitchenTicket sends the new items to the kitchen printer, if there is one.
+func (o OrdersController) printKitchenTicket(id uint, items []entity.OrderItem) {
+	if o.Spooler == nil {
+		return
+	}
+	p, ok := o.Spooler.Printer(KitchenPrinter)
+	if !ok {
+		return
+	}
+	order, err := o.Repo.GetOrder(id)
+	if err != nil {
+		fmt.Println("Can not print kitchen ticket", err)
+		return
+	}
+	added := make(map[uint]bool)
+	for _, item := range items {
+		added[item.ID] = true
+	}
+	var ticket []entity.OrderItem
+	for _, item := range order.Items {
+		if added[item.ID] {
+			ticket = append(ticket, item)
+		}
+	}
+	err = o.Spooler.Submit(p.Name, printing.KitchenTicket(order, ticket, p.Columns))
+	if err != nil {
+		fmt.Println("Can not print kitchen ticket", err)
+	}
+}
+
+func (o OrdersController) AddPayment(w http.ResponseWriter, r *http.Request) {
+	var payment entity.Payment
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &payment) {
+		return
+	}
+	payment.OrderID = uint(id)
+	payment.UserID = userId(r)
+	err := repoFor(o.Repo, r).AddPayment(&payment)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not add payment", err)
+		return
+	}
+	o.signPaid(r, payment.OrderID)
+	Respond(w, r, http.StatusCreated, payment)
+	fmt.Println("Added payment")
+}
+
+// signPaid signs the order with the fiscal signer once its payments cover the total.
+func (o OrdersController) signPaid(r *http.Request, id uint) {
+	if o.Signer == nil {
+		return
+	}
+	order, err := o.Repo.GetOrder(id)
+	if err != nil {
+		fmt.Println("Can not sign order", err)
+		return
+	}
+	if order.Signature(entity.FiscalPaid) != nil || !order.PaidInFull() {
+		return
+	}
+	o.sign(r, order, entity.FiscalPaid)
+}
+
+// sign records the fiscal signature of a paid or voided order. A failing TSE must not
+// stop the sale, the missing signature is logged and shows on the receipt instead.
+func (o OrdersController) sign(r *http.Request, order entity.Order, kind string) {
+	signature, err := o.Signer.Sign(fiscal.NewTransaction(order, kind))
+	if err == nil {
+		err = repoFor(o.Repo, r).AddSignature(&signature)
+	}
+	if err != nil {
+		fmt.Println("Can not sign order", err)
+		return
+	}
+	fmt.Printf("Signed %s order %d, transaction %d\n", kind, order.ID, signature.TransactionNumber)
+}
+
+func (o OrdersController) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	from, to, err := ParseDateRange(r)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Invalid date range", err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if format == export.FormatDSFinVK {
+		o.exportDSFinVK(w, from, to)
+		return
+	}
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not export orders", err)
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"orders-%s-%s.%s\"",
+		from.Format(dateLayout), to.Format(dateLayout), format))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, errors from here on can only be logged.
+	err = writer.Write(export.OrderHeader)
+	if err == nil {
+		err = o.Repo.ExportOrders(from, to, func(row entity.OrderExportRow) error {
+			return writer.Write(export.OrderRecord(row))
+		})
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		fmt.Println("Can not export orders", err)
+		return
+	}
+	fmt.Println("Exported orders")
+}
+
+func (o OrdersController) exportDSFinVK(w http.ResponseWriter, from time.Time, to time.Time) {
+	w.Header().Set("Content-Type", export.ContentType(export.FormatDSFinVK))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"dsfinvk-%s-%s.zip\"",
+		from.Format(dateLayout), to.Format(dateLayout)))
+	w.WriteHeader(http.StatusOK)
+
+	err := export.WriteDSFinVK(w,
+		func(fn func(entity.OrderExportRow) error) error { return o.Repo.ExportOrders(from, to, fn) },
+		func(fn func(entity.FiscalSignature) error) error { return o.Repo.ExportSignatures(from, to, fn) })
+	if err != nil {
+		fmt.Println("Can not export orders", err)
+		return
+	}
+	fmt.Println("Exported orders for DSFinV-K")
+}
+
+func (o OrdersController) ReadReceipt(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = receipt.FormatText
+	}
+	if format != receipt.FormatText && format != receipt.FormatHTML && format != receipt.FormatPDF {
+		SendProblem(w, r, fmt.Errorf("%w: %q", receipt.ErrUnknownFormat, format))
+		return
+	}
+	order, err := o.Repo.GetOrder(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find order", err)
+		return
+	}
+	var buf bytes.Buffer
+	err = receipt.Render(format, &buf, receipt.New(o.Restaurant, order))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not render receipt", err)
+		return
+	}
+	w.Header().Set("Content-Type", receipt.ContentType(format))
+	buf.WriteTo(w)
+	fmt.Println("Printed receipt")
+}
+
+// PrintOrder prints the receipt, or with ticket=kitchen the kitchen ticket of all items,
+// on the printer given by the printer parameter.
+func (o OrdersController) PrintOrder(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	ticket := r.URL.Query().Get("ticket")
+	name := r.URL.Query().Get("printer")
+	if name == "" {
+		name = CounterPrinter
+		if ticket == KitchenPrinter {
+			name = KitchenPrinter
+		}
+	}
+	if o.Spooler == nil {
+		SendProblem(w, r, printing.ErrUnknownPrinter)
+		return
+	}
+	p, ok := o.Spooler.Printer(name)
+	if !ok {
+		SendProblem(w, r, fmt.Errorf("%w: %s", printing.ErrUnknownPrinter, name))
+		return
+	}
+	order, err := o.Repo.GetOrder(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find order", err)
+		return
+	}
+	var data []byte
+	if ticket == KitchenPrinter {
+		data = printing.KitchenTicket(order, order.Items, p.Columns)
+	} else {
+		data = printing.Receipt(receipt.New(o.Restaurant, order), p.Columns)
+	}
+	err = o.Spooler.Submit(p.Name, data)
+	if err != nil {
+		SendProblem(w, r, fmt.Errorf("%w: %w", entity.ErrUnavailable, err))
+		fmt.Println("Can not print order", err)
+		return
+	}
+	Respond(w, r, http.StatusAccepted, nil)
+	fmt.Println("Sent order to printer", p.Name)
+}
@@ -1,19 +1,28 @@
 package api
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"gorestserviceagain/auth"
 	"gorestserviceagain/entity"
+	"gorestserviceagain/fiscal"
+	"gorestserviceagain/patch"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
 )
 
@@ -42,8 +51,14 @@ func TestOrderCreate(t *testing.T) {
 					"DiscountDetail": interface{}(nil),
 					"FinalPrice":     float64(order.FinalPrice),
 					"ID":             float64(order.ID),
+					"Items":          interface{}(nil),
+					"Payments":       interface{}(nil),
+					"Signatures":     interface{}(nil),
+					"ShiftID":        float64(0),
 					"TableNumber":    float64(order.TableNumber),
 					"UpdatedAt":      "0001-01-01T00:00:00Z",
+					"UserID":         float64(0),
+					"Version":        float64(0),
 				},
 			},
 		},
@@ -52,7 +67,7 @@ func TestOrderCreate(t *testing.T) {
 			err:  entity.ErrInvalidData,
 			expected: expectations{
 				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": entity.ErrInvalidData.Error()},
+				respPayload: problem(http.StatusUnprocessableEntity, entity.ErrInvalidData.Error()),
 			},
 		},
 	}
@@ -81,6 +96,102 @@ func TestOrderCreate(t *testing.T) {
 	}
 }
 
+func TestOrderCreateV2(t *testing.T) {
+	// The id and the creation time of the body are not taken over.
+	body := `{"id": 7, "createdAt": "2020-01-01T00:00:00Z", "tableNumber": 2, "items": [{"dishId": 3, "quantity": 2}]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v2/orders/", strings.NewReader(body))
+
+	repo := new(entity.MockRepo)
+	repo.On("CreateOrder", entity.Order{TableNumber: 2, Items: []entity.OrderItem{{DishID: 3, Quantity: 2}}}).Return(nil)
+	WithVersion(V2)(http.HandlerFunc(OrdersController{Repo: repo}.CreateOrder)).ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	var respPayload map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+	assert.Equal(t, map[string]any{
+		"id":          float64(0),
+		"createdAt":   "0001-01-01T00:00:00Z",
+		"updatedAt":   "0001-01-01T00:00:00Z",
+		"version":     float64(0),
+		"tableNumber": float64(2),
+		"finalPrice":  float64(0),
+		"total":       float64(0),
+		"paid":        float64(0),
+		"items": []any{map[string]any{
+			"id": float64(0), "orderId": float64(0), "dishId": float64(3), "quantity": float64(2), "price": float64(0), "taxRate": float64(0),
+		}},
+		"discounts": []any{},
+		"payments":  []any{},
+	}, respPayload)
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCreateV2Prices(t *testing.T) {
+	repo := new(entity.MockRepo)
+	// Items without a price are passed on with price 0, the repo charges them the dish price.
+	repo.On("CreateOrder", entity.Order{TableNumber: 2, UserID: 3, Items: []entity.OrderItem{{DishID: 3, Quantity: 1}}}).Return(nil)
+	create := func(body string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v2/orders/", strings.NewReader(body))
+		r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: entity.RoleWaiter}))
+		WithVersion(V2)(http.HandlerFunc(OrdersController{Repo: repo}.CreateOrder)).ServeHTTP(w, r)
+		return w.Result().StatusCode
+	}
+
+	assert.Equal(t, http.StatusCreated, create(`{"tableNumber": 2, "items": [{"dishId": 3, "quantity": 1}]}`))
+	assert.Equal(t, http.StatusForbidden, create(`{"tableNumber": 2, "items": [{"dishId": 3, "quantity": 1, "price": 0.01}]}`))
+	repo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestOrderCreateChecked(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		created    *entity.Order
+		statusCode int
+	}{
+		{
+			name:       "payments, signatures and discounts are not taken",
+			role:       entity.RoleWaiter,
+			body:       `{"TableNumber": 2, "Payments": [{"Method": "cash", "Amount": 40}], "Signatures": [{"Kind": "paid", "Signature": "forged"}], "DiscountDetail": [{"DishID": 3, "Discount": 100}]}`,
+			created:    &entity.Order{TableNumber: 2, UserID: 3},
+			statusCode: http.StatusCreated,
+		},
+		{
+			name:       "price override needs permission",
+			role:       entity.RoleWaiter,
+			body:       `{"TableNumber": 2, "Items": [{"DishID": 3, "Quantity": 1, "Price": 0.01}]}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "price override by manager",
+			role:       entity.RoleManager,
+			body:       `{"TableNumber": 2, "Items": [{"DishID": 3, "Quantity": 1, "Price": 0.01}]}`,
+			created:    &entity.Order{TableNumber: 2, UserID: 3, Items: []entity.OrderItem{{DishID: 3, Quantity: 1, Price: 0.01}}},
+			statusCode: http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/orders/", strings.NewReader(tt.body))
+			r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: tt.role}))
+
+			repo := new(entity.MockRepo)
+			if tt.created != nil {
+				repo.On("CreateOrder", *tt.created).Return(nil)
+			}
+			OrdersController{Repo: repo}.CreateOrder(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestOrdersReadAll(t *testing.T) {
 	type expectations struct {
 		statusCode  int
@@ -108,8 +219,14 @@ func TestOrdersReadAll(t *testing.T) {
 					"DiscountDetail": interface{}(nil),
 					"FinalPrice":     float64(order.FinalPrice),
 					"ID":             float64(order.ID),
+					"Items":          interface{}(nil),
+					"Payments":       interface{}(nil),
+					"Signatures":     interface{}(nil),
+					"ShiftID":        float64(0),
 					"TableNumber":    float64(order.TableNumber),
 					"UpdatedAt":      "0001-01-01T00:00:00Z",
+					"UserID":         float64(0),
+					"Version":        float64(0),
 				},
 				},
 			},
@@ -118,8 +235,8 @@ func TestOrdersReadAll(t *testing.T) {
 			name: "can not find orders",
 			err:  entity.ErrEntityNotFound,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": entity.ErrEntityNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, entity.ErrEntityNotFound.Error()),
 			},
 		},
 	}
@@ -131,7 +248,7 @@ func TestOrdersReadAll(t *testing.T) {
 			r := httptest.NewRequest(http.MethodGet, "/orders/", b)
 
 			repo := new(entity.MockRepo)
-			repo.On("GetOrders").Return(tt.existing, tt.err)
+			repo.On("GetOrders", entity.OrderQuery{ListOptions: entity.ListOptions{Limit: defaultLimit}}).Return(tt.existing, tt.err)
 			OrdersController{Repo: repo}.ReadAllOrders(w, r)
 
 			res := w.Result()
@@ -150,7 +267,7 @@ func TestOrderReadById(t *testing.T) {
 		statusCode  int
 		respPayload any
 	}
-	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14.}
+	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14., Version: 3}
 	notFoundErr := entity.RecordNotFoundError{
 		Kind:  "Order",
 		ID:    strconv.FormatInt(int64(order.ID), 10),
@@ -176,8 +293,14 @@ func TestOrderReadById(t *testing.T) {
 					"DiscountDetail": interface{}(nil),
 					"FinalPrice":     float64(order.FinalPrice),
 					"ID":             float64(order.ID),
+					"Items":          interface{}(nil),
+					"Payments":       interface{}(nil),
+					"Signatures":     interface{}(nil),
+					"ShiftID":        float64(0),
 					"TableNumber":    float64(order.TableNumber),
 					"UpdatedAt":      "0001-01-01T00:00:00Z",
+					"UserID":         float64(0),
+					"Version":        float64(order.Version),
 				},
 			},
 		},
@@ -186,7 +309,7 @@ func TestOrderReadById(t *testing.T) {
 			err:  notFoundErr,
 			expected: expectations{
 				statusCode:  http.StatusNotFound,
-				respPayload: map[string]interface{}{"Error": notFoundErr.Error()},
+				respPayload: problem(http.StatusNotFound, notFoundErr.Error()),
 			},
 		},
 	}
@@ -206,6 +329,9 @@ func TestOrderReadById(t *testing.T) {
 
 			res := w.Result()
 			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.err == nil {
+				assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+			}
 			if tt.expected.respPayload != nil {
 				//parsing Body as json, puting into &tt.respPayload 从前往后
 				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
@@ -219,6 +345,7 @@ func TestOderUpdateById(t *testing.T) {
 	type expectations struct {
 		statusCode  int
 		respPayload any
+		etag        string
 	}
 	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14.}
 	notFoundErr := entity.RecordNotFoundError{
@@ -229,6 +356,7 @@ func TestOderUpdateById(t *testing.T) {
 	tests := []struct {
 		name        string
 		payload     entity.Order
+		ifMatch     string
 		respPayload any
 		existing    entity.Order
 		expected    expectations
@@ -240,18 +368,52 @@ func TestOderUpdateById(t *testing.T) {
 			payload: entity.Order{
 				FinalPrice: 16.,
 				Model:      gorm.Model{ID: 1},
+				Version:    3,
 			},
+			ifMatch: `"3"`,
 			expected: expectations{
 				statusCode:  http.StatusNoContent,
 				respPayload: nil,
+				etag:        `"3"`,
 			},
 		},
 		{
-			name: "failed to update order",
-			err:  notFoundErr,
+			name:    "failed to update order",
+			err:     notFoundErr,
+			ifMatch: `"0"`,
 			expected: expectations{
 				statusCode:  http.StatusNotFound,
-				respPayload: map[string]interface{}{"Error": notFoundErr.Error()},
+				respPayload: problem(http.StatusNotFound, notFoundErr.Error()),
+			},
+		},
+		{
+			name:     "order was changed",
+			existing: order,
+			payload:  entity.Order{Model: gorm.Model{ID: 1}, Version: 2},
+			ifMatch:  `"2"`,
+			err:      entity.ErrVersionMismatch,
+			expected: expectations{
+				statusCode:  http.StatusPreconditionFailed,
+				respPayload: problem(http.StatusPreconditionFailed, entity.ErrVersionMismatch.Error()),
+			},
+		},
+		{
+			name:     "weak etag",
+			existing: order,
+			payload:  entity.Order{Model: gorm.Model{ID: 1}},
+			ifMatch:  `W/"2"`,
+			expected: expectations{
+				statusCode:  http.StatusPreconditionFailed,
+				respPayload: problem(http.StatusPreconditionFailed, entity.ErrVersionMismatch.Error()),
+			},
+		},
+		{
+			name:     "without If-Match",
+			existing: order,
+			payload:  entity.Order{Model: gorm.Model{ID: 1}},
+			expected: expectations{
+				statusCode:  http.StatusPreconditionRequired,
+				respPayload: problem(http.StatusPreconditionRequired, "If-Match with the ETag of the record is required"),
 			},
 		},
 	}
@@ -261,6 +423,9 @@ func TestOderUpdateById(t *testing.T) {
 			b := bytes.NewBuffer(nil)
 			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
 			r := httptest.NewRequest(http.MethodPut, "/orders/{id}", b)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", strconv.FormatUint(uint64(tt.existing.ID), 10))
 			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
@@ -271,6 +436,7 @@ func TestOderUpdateById(t *testing.T) {
 
 			res := w.Result()
 			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			assert.Equal(t, tt.expected.etag, res.Header.Get("ETag"))
 			if tt.expected.respPayload != nil {
 				//parsing Body as json, puting into &tt.respPayload 从前往后
 				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
@@ -279,6 +445,92 @@ func TestOderUpdateById(t *testing.T) {
 		})
 	}
 }
+func TestOrderPatchById(t *testing.T) {
+	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14., UserID: 3, Version: 1}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		ifMatch     string
+		updated     *entity.Order
+		statusCode  int
+	}{
+		{
+			name:        "merge patch",
+			contentType: patch.MergePatchContentType,
+			body:        `{"FinalPrice": 16}`,
+			updated:     &entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 16., UserID: 3, Version: 1},
+			statusCode:  http.StatusNoContent,
+		},
+		{
+			name:        "merge patch with null",
+			contentType: patch.MergePatchContentType + "; charset=utf-8",
+			body:        `{"TableNumber": null}`,
+			updated:     &entity.Order{Model: gorm.Model{ID: 1}, FinalPrice: 14., UserID: 3, Version: 1},
+			statusCode:  http.StatusNoContent,
+		},
+		{
+			name:        "json patch",
+			contentType: patch.JSONPatchContentType,
+			body:        `[{"op": "test", "path": "/TableNumber", "value": 2}, {"op": "replace", "path": "/TableNumber", "value": 0}]`,
+			updated:     &entity.Order{Model: gorm.Model{ID: 1}, FinalPrice: 14., UserID: 3, Version: 1},
+			statusCode:  http.StatusNoContent,
+		},
+		{
+			name:        "json patch test fails",
+			contentType: patch.JSONPatchContentType,
+			body:        `[{"op": "test", "path": "/TableNumber", "value": 5}, {"op": "replace", "path": "/TableNumber", "value": 0}]`,
+			statusCode:  http.StatusConflict,
+		},
+		{
+			name:        "invalid result",
+			contentType: patch.MergePatchContentType,
+			body:        `{"FinalPrice": -1}`,
+			statusCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "order was changed",
+			contentType: patch.MergePatchContentType,
+			body:        `{"FinalPrice": 16}`,
+			ifMatch:     `"0"`,
+			statusCode:  http.StatusPreconditionFailed,
+		},
+		{
+			name:        "plain json",
+			contentType: "application/json",
+			body:        `{"FinalPrice": 16}`,
+			statusCode:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPatch, "/orders/1", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			r.Header.Set("If-Match", `"1"`)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			repo := new(entity.MockRepo)
+			repo.On("GetOrder", uint(1)).Return(order, nil)
+			if tt.updated != nil {
+				repo.On("UpdateOrder", *tt.updated).Return(nil)
+			}
+			OrdersController{Repo: repo}.PatchOrderById(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode, w.Body.String())
+			if tt.updated == nil {
+				repo.AssertNotCalled(t, "UpdateOrder", mock.Anything)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestDiscountUpdateById(t *testing.T) {
 	type expectations struct {
 		statusCode  int
@@ -286,9 +538,9 @@ func TestDiscountUpdateById(t *testing.T) {
 	}
 	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 2, FinalPrice: 14.}
 	dish := entity.Dish{Model: gorm.Model{ID: 2}, Name: "Fish filet", Price: 10.}
-	discountDetail := entity.DiscountDetail{OrderID: 1, DishID: 2, Discount: 2., Dish: dish, Order: order}
-	errOrderNotFound := errors.New("order not found")
-	errDishNotFound := errors.New("dish not found")
+	discountDetail := entity.DiscountDetail{OrderID: 1, DishID: 2, Discount: 2., Dish: dish, Order: order, Version: 1}
+	errOrderNotFound := entity.WrapRecordNotFoundError("Order", 1, gorm.ErrRecordNotFound)
+	errDishNotFound := entity.WrapRecordNotFoundError("Dish", 2, gorm.ErrRecordNotFound)
 	errDiscount := entity.RecordNotFoundError{}
 
 	tests := []struct {
@@ -309,6 +561,7 @@ func TestDiscountUpdateById(t *testing.T) {
 				Discount: 1.8,
 				DishID:   dish.ID,
 				OrderID:  order.ID,
+				Version:  4,
 			},
 			existingOrder:    order,
 			existingDish:     dish,
@@ -325,8 +578,8 @@ func TestDiscountUpdateById(t *testing.T) {
 			existingOrder: order,
 			//existingDiscount: discountDetail,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": errOrderNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, errOrderNotFound.Error()),
 			},
 		},
 		{
@@ -337,8 +590,8 @@ func TestDiscountUpdateById(t *testing.T) {
 			//existingDiscount: discountDetail,
 			payload: discountDetail,
 			expected: expectations{
-				statusCode:  http.StatusUnprocessableEntity,
-				respPayload: map[string]interface{}{"Error": errDishNotFound.Error()},
+				statusCode:  http.StatusNotFound,
+				respPayload: problem(http.StatusNotFound, errDishNotFound.Error()),
 			},
 		},
 		{
@@ -346,7 +599,7 @@ func TestDiscountUpdateById(t *testing.T) {
 			discountErr: errDiscount,
 			expected: expectations{
 				statusCode:  http.StatusNotFound,
-				respPayload: map[string]interface{}{"Error": errDiscount.Error()},
+				respPayload: problem(http.StatusNotFound, errDiscount.Error()),
 			},
 		},
 	}
@@ -356,6 +609,7 @@ func TestDiscountUpdateById(t *testing.T) {
 			b := bytes.NewBuffer(nil)
 			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
 			r := httptest.NewRequest(http.MethodPost, "/orders/{orderId}/dishes/{dishId}", b)
+			r.Header.Set("If-Match", strconv.Quote(strconv.FormatUint(uint64(tt.payload.Version), 10)))
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("orderId", strconv.FormatUint(uint64(tt.existingOrder.ID), 10))
 			rctx.URLParams.Add("dishId", strconv.FormatUint(uint64(tt.existingDish.ID), 10))
@@ -397,23 +651,44 @@ func TestOrderDeleteById(t *testing.T) {
 		payload     entity.Order
 		respPayload any
 		existing    entity.Order
+		ifMatch     string
 		err         error
 		expected    expectations
 	}{
 		{
 			name:     "successful deleted order",
 			existing: order,
+			ifMatch:  `"1"`,
 			expected: expectations{
-				statusCode:  http.StatusNoContent, 
+				statusCode:  http.StatusNoContent,
 				respPayload: nil,
 			},
 		},
 		{
-			name: "failed to delete order",
-			err:  notFoundErr,
+			name:    "failed to delete order",
+			err:     notFoundErr,
+			ifMatch: `"1"`,
 			expected: expectations{
 				statusCode:  http.StatusNotFound,
-				respPayload: map[string]interface{}{"Error": notFoundErr.Error()},
+				respPayload: problem(http.StatusNotFound, notFoundErr.Error()),
+			},
+		},
+		{
+			name:     "order was changed",
+			existing: order,
+			err:      entity.ErrVersionMismatch,
+			ifMatch:  `"1"`,
+			expected: expectations{
+				statusCode:  http.StatusPreconditionFailed,
+				respPayload: problem(http.StatusPreconditionFailed, entity.ErrVersionMismatch.Error()),
+			},
+		},
+		{
+			name:     "without If-Match",
+			existing: order,
+			expected: expectations{
+				statusCode:  http.StatusPreconditionRequired,
+				respPayload: problem(http.StatusPreconditionRequired, "If-Match with the ETag of the record is required"),
 			},
 		},
 	}
@@ -422,12 +697,15 @@ func TestOrderDeleteById(t *testing.T) {
 			w := httptest.NewRecorder()
 			b := bytes.NewBuffer(nil)
 			r := httptest.NewRequest(http.MethodDelete, "/orders/{id}", b)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", strconv.FormatUint(uint64(tt.existing.ID), 10))
 			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
 
 			repo := new(entity.MockRepo)
-			repo.On("DeleteOrder", tt.existing.ID).Return(tt.err)
+			repo.On("DeleteOrder", tt.existing.ID, uint(1)).Return(tt.err)
 			OrdersController{Repo: repo}.DeleteOrderById(w, r)
 
 			res := w.Result()
@@ -440,3 +718,268 @@ func TestOrderDeleteById(t *testing.T) {
 		})
 	}
 }
+
+func TestOrdersExport(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)
+	rows := []entity.OrderExportRow{
+		{RecordType: entity.ExportItem, OrderID: 1, CreatedAt: from, TableNumber: 2, DishID: 2, DishName: "Fish filet", Quantity: 2, Price: 10, TaxRate: 19, Discount: 10, Amount: 18},
+		{RecordType: entity.ExportPayment, OrderID: 1, CreatedAt: from, TableNumber: 2, Amount: 18, PaymentMethod: entity.PaymentCash},
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		statusCode  int
+		contentType string
+	}{
+		{name: "csv export", format: "csv", statusCode: http.StatusOK, contentType: "text/csv; charset=utf-8"},
+		{name: "xlsx export", format: "xlsx", statusCode: http.StatusOK, contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{name: "unknown format", format: "pdf", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/orders/export?from=2024-03-01&to=2024-03-01&format="+tt.format, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("ExportOrders", from, to).Return(rows, nil)
+			OrdersController{Repo: repo}.ExportOrders(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+			var records [][]string
+			if tt.format == "csv" {
+				var err error
+				records, err = csv.NewReader(res.Body).ReadAll()
+				require.NoError(t, err)
+			} else {
+				f, err := excelize.OpenReader(res.Body)
+				require.NoError(t, err)
+				records, err = f.GetRows("Sheet1")
+				require.NoError(t, err)
+			}
+			require.Len(t, records, 3)
+			assert.Equal(t, "RecordType", records[0][0])
+			assert.Equal(t, []string{"item", "1", "Fish filet", "18"}, []string{records[1][0], records[1][1], records[1][6], strings.TrimSuffix(records[1][11], ".00")})
+			assert.Equal(t, "cash", records[2][12])
+		})
+	}
+}
+
+func TestDiscountUpdateRouted(t *testing.T) {
+	r := chi.NewRouter()
+	OrdersController{Repo: new(entity.MockRepo)}.RegisterRoutes(r)
+
+	rctx := chi.NewRouteContext()
+	require.True(t, r.Match(rctx, http.MethodPut, "/1/dishes/2"))
+	assert.Equal(t, "/{orderId}/dishes/{dishId}", rctx.RoutePattern())
+}
+
+func TestPaymentSigned(t *testing.T) {
+	order := entity.Order{
+		Model: gorm.Model{ID: 1},
+		Items: []entity.OrderItem{{DishID: 2, Quantity: 2, Price: 10, TaxRate: 19}},
+	}
+	signer, err := fiscal.NewSoftwareSigner()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		amount float32
+		items  []entity.OrderItem
+		paid   []entity.Payment
+		signed bool
+	}{
+		{name: "partial payment is not signed", amount: 5, paid: []entity.Payment{{OrderID: 1, Method: entity.PaymentCash, Amount: 5}}},
+		{name: "full payment is signed", amount: 15, paid: []entity.Payment{{OrderID: 1, Method: entity.PaymentCash, Amount: 5}, {OrderID: 1, Method: entity.PaymentCard, Amount: 15}}, signed: true},
+		{name: "order without items is not signed", amount: 5, items: []entity.OrderItem{}, paid: []entity.Payment{{OrderID: 1, Method: entity.PaymentCard, Amount: 5}}},
+		{name: "order with only voided items is not signed", amount: 5, items: []entity.OrderItem{{DishID: 2, Quantity: 2, Price: 10, TaxRate: 19, Status: entity.ItemVoided}}, paid: []entity.Payment{{OrderID: 1, Method: entity.PaymentCard, Amount: 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := entity.Payment{OrderID: 1, Method: entity.PaymentCard, Amount: tt.amount}
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(payment))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/orders/{id}/payments", b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			paid := order
+			if tt.items != nil {
+				paid.Items = tt.items
+			}
+			paid.Payments = tt.paid
+			repo := new(entity.MockRepo)
+			repo.On("AddPayment", payment).Return(nil)
+			repo.On("GetOrder", uint(1)).Return(paid, nil)
+			repo.On("AddSignature", mock.Anything).Return(nil)
+			OrdersController{Repo: repo, Signer: signer}.AddPayment(w, r)
+
+			assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+			if !tt.signed {
+				repo.AssertNotCalled(t, "AddSignature", mock.Anything)
+				return
+			}
+			repo.AssertCalled(t, "AddSignature", mock.Anything)
+			signature := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(entity.FiscalSignature)
+			assert.Equal(t, entity.FiscalPaid, signature.Kind)
+			assert.Equal(t, "Beleg^20.00_0.00_0.00_0.00_0.00^5.00:Bar_15.00:Unbar", signature.ProcessData)
+			assert.NoError(t, fiscal.Verify(signer.PublicKey(), signature))
+		})
+	}
+}
+
+func TestOrdersExportDSFinVK(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)
+	rows := []entity.OrderExportRow{
+		{RecordType: entity.ExportItem, OrderID: 1, CreatedAt: from, DishID: 2, DishName: "Fish filet", Quantity: 2, Price: 10, TaxRate: 19, Amount: 20},
+		{RecordType: entity.ExportItem, OrderID: 1, CreatedAt: from, DishID: 3, DishName: "Water", Quantity: 1, Price: 2, TaxRate: 19, Amount: 2},
+		{RecordType: entity.ExportPayment, OrderID: 1, CreatedAt: from, Amount: 22, PaymentMethod: entity.PaymentCash},
+	}
+	signatures := []entity.FiscalSignature{{OrderID: 1, Kind: entity.FiscalPaid, TransactionNumber: 7, SignatureCounter: 14, Signature: "c2ln"}}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/orders/export?from=2024-03-01&to=2024-03-01&format=dsfinvk", nil)
+	repo := new(entity.MockRepo)
+	repo.On("ExportOrders", from, to).Return(rows, nil)
+	repo.On("ExportSignatures", from, to).Return(signatures, nil)
+	OrdersController{Repo: repo}.ExportOrders(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/zip", res.Header.Get("Content-Type"))
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	tables := make(map[string][][]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		reader := csv.NewReader(rc)
+		reader.Comma = ';'
+		tables[f.Name], err = reader.ReadAll()
+		require.NoError(t, err)
+		rc.Close()
+	}
+	require.Len(t, tables["lines.csv"], 3)
+	assert.Equal(t, []string{"1", "2", "Water"}, []string{tables["lines.csv"][2][1], tables["lines.csv"][2][2], tables["lines.csv"][2][5]})
+	require.Len(t, tables["datapayment.csv"], 2)
+	assert.Equal(t, "Bar", tables["datapayment.csv"][1][2])
+	require.Len(t, tables["transactions_tse.csv"], 2)
+	assert.Equal(t, []string{"1", "7", "14", "c2ln"}, []string{tables["transactions_tse.csv"][1][0], tables["transactions_tse.csv"][1][2], tables["transactions_tse.csv"][1][6], tables["transactions_tse.csv"][1][7]})
+}
+
+func TestOrderItemAdjust(t *testing.T) {
+	voided := entity.OrderItem{Model: gorm.Model{ID: 5}, OrderID: 1, DishID: 2, Quantity: 1, Price: 8, Status: entity.ItemVoided, Reason: "entered_twice", AdjustedBy: 3}
+	tests := []struct {
+		name       string
+		status     string
+		adjustment entity.ItemAdjustment
+		err        error
+		statusCode int
+	}{
+		{name: "successful void", status: entity.ItemVoided, adjustment: entity.ItemAdjustment{Reason: "entered_twice", Quantity: 1}, statusCode: http.StatusOK},
+		{name: "successful comp", status: entity.ItemComped, adjustment: entity.ItemAdjustment{Reason: "waiting_time"}, statusCode: http.StatusOK},
+		{name: "item already voided", status: entity.ItemVoided, adjustment: entity.ItemAdjustment{Reason: "wrong_item"}, err: entity.ErrItemAdjusted, statusCode: http.StatusConflict},
+		{name: "missing reason", status: entity.ItemComped, statusCode: http.StatusUnprocessableEntity},
+		{name: "item not found", status: entity.ItemVoided, adjustment: entity.ItemAdjustment{Reason: "wrong_item"},
+			err: entity.WrapRecordNotFoundError("OrderItem", 5, gorm.ErrRecordNotFound), statusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.adjustment))
+			r := httptest.NewRequest(http.MethodPost, "/orders/{id}/items/{itemId}/"+tt.status, b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			rctx.URLParams.Add("itemId", "5")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: entity.RoleManager}))
+
+			expected := tt.adjustment
+			expected.Status = tt.status
+			expected.UserID = 3
+			repo := new(entity.MockRepo)
+			repo.On("AdjustOrderItem", uint(1), uint(5), expected).Return(voided, tt.err)
+			controller := OrdersController{Repo: repo}
+			if tt.status == entity.ItemVoided {
+				controller.VoidOrderItem(w, r)
+			} else {
+				controller.CompOrderItem(w, r)
+			}
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode == http.StatusUnprocessableEntity {
+				repo.AssertNotCalled(t, "AdjustOrderItem", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestOrdersReadPaged(t *testing.T) {
+	table := 4
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	orders := []entity.Order{{Model: gorm.Model{ID: 7}}, {Model: gorm.Model{ID: 9}}}
+	tests := []struct {
+		name       string
+		query      string
+		expected   entity.OrderQuery
+		statusCode int
+		link       string
+		cursor     string
+	}{
+		{
+			name:       "next page with cursor",
+			query:      "?limit=2&status=open&table=4&createdFrom=2024-03-01&createdTo=2024-03-01",
+			expected:   entity.OrderQuery{ListOptions: entity.ListOptions{Limit: 2}, Status: entity.OrderOpen, TableNumber: &table, CreatedFrom: from, CreatedTo: from.AddDate(0, 0, 1)},
+			statusCode: http.StatusOK,
+			link:       `</orders?createdFrom=2024-03-01&createdTo=2024-03-01&cursor=9&limit=2&status=open&table=4>; rel="next"`,
+			cursor:     "9",
+		},
+		{
+			name:       "next page with offset",
+			query:      "?limit=2&offset=4&sort=-createdAt",
+			expected:   entity.OrderQuery{ListOptions: entity.ListOptions{Limit: 2, Offset: 4, Sort: "-createdAt"}},
+			statusCode: http.StatusOK,
+			link:       `</orders?limit=2&offset=6&sort=-createdAt>; rel="next"`,
+		},
+		{
+			name:       "last page",
+			query:      "?cursor=5",
+			expected:   entity.OrderQuery{ListOptions: entity.ListOptions{Limit: defaultLimit, After: 5}},
+			statusCode: http.StatusOK,
+		},
+		{name: "unknown status", query: "?status=cooking", statusCode: http.StatusBadRequest},
+		{name: "cursor with sort", query: "?cursor=5&sort=tableNumber", statusCode: http.StatusBadRequest},
+		{name: "invalid limit", query: "?limit=-1", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/orders"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetOrders", tt.expected).Return(orders, nil)
+			OrdersController{Repo: repo}.ReadAllOrders(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, tt.link, res.Header.Get("Link"))
+			assert.Equal(t, tt.cursor, res.Header.Get("X-Next-Cursor"))
+			if tt.statusCode == http.StatusOK {
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+)
+
+func toOrder(o dto.OrderRequest) entity.Order {
+	return entity.Order{
+		TableNumber: o.TableNumber,
+		FinalPrice:  o.FinalPrice,
+		Items:       mapSlice(o.Items, toOrderItem),
+	}
+}
+
+func toOrderItem(i dto.OrderItemRequest) entity.OrderItem {
+	return entity.OrderItem{DishID: i.DishID, Quantity: i.Quantity, Price: i.Price}
+}
+
+func toItemAdjustment(a dto.ItemAdjustmentRequest) entity.ItemAdjustment {
+	return entity.ItemAdjustment{Reason: a.Reason, Waste: a.Waste, Quantity: a.Quantity}
+}
+
+func toPayment(p dto.PaymentRequest) entity.Payment {
+	return entity.Payment{Method: p.Method, Amount: p.Amount}
+}
+
+func NewOrderResponse(o entity.Order) dto.OrderResponse {
+	return dto.OrderResponse{
+		ID:          o.ID,
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+		TableNumber: o.TableNumber,
+		FinalPrice:  o.FinalPrice,
+		Total:       o.Total(),
+		Paid:        o.Paid(),
+		ShiftID:     o.ShiftID,
+		UserID:      o.UserID,
+		Version:     o.Version,
+		Items:       nonNil(mapSlice(o.Items, NewOrderItemResponse)),
+		Discounts:   nonNil(mapSlice(o.DiscountDetail, NewDiscountResponse)),
+		Payments:    nonNil(mapSlice(o.Payments, NewPaymentResponse)),
+		Signatures:  mapSlice(o.Signatures, NewSignatureResponse),
+	}
+}
+
+func NewOrderItemResponse(i entity.OrderItem) dto.OrderItemResponse {
+	return dto.OrderItemResponse{
+		ID:         i.ID,
+		OrderID:    i.OrderID,
+		DishID:     i.DishID,
+		DishName:   i.Dish.Name,
+		Quantity:   i.Quantity,
+		Price:      i.Price,
+		TaxRate:    i.TaxRate,
+		Status:     i.Status,
+		Reason:     i.Reason,
+		Waste:      i.Waste,
+		AdjustedBy: i.AdjustedBy,
+	}
+}
+
+func NewPaymentResponse(p entity.Payment) dto.PaymentResponse {
+	return dto.PaymentResponse{
+		ID:        p.ID,
+		CreatedAt: p.CreatedAt,
+		OrderID:   p.OrderID,
+		UserID:    p.UserID,
+		Method:    p.Method,
+		Amount:    p.Amount,
+	}
+}
+
+func NewSignatureResponse(s entity.FiscalSignature) dto.SignatureResponse {
+	return dto.SignatureResponse{
+		Kind:              s.Kind,
+		SerialNumber:      s.SerialNumber,
+		TransactionNumber: s.TransactionNumber,
+		SignatureCounter:  s.SignatureCounter,
+		Algorithm:         s.Algorithm,
+		ProcessType:       s.ProcessType,
+		ProcessData:       s.ProcessData,
+		Signature:         s.Signature,
+		StartTime:         s.StartTime,
+		EndTime:           s.EndTime,
+	}
+}
+
+// nonNil makes empty lists of a response [] instead of null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
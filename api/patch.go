@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/patch"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// patchContentTypes are the patch documents PATCH routes accept.
+var patchContentTypes = []string{patch.MergePatchContentType, patch.JSONPatchContentType}
+
+// decodePatch applies the patch in the request body to current, the resource as the
+// client reads it in the version of r, and reads the result into v like the body of a
+// PUT, which replaces the resource. Members removed by the patch, e.g. set to null by a
+// merge patch, are zero. It reports whether v can be used, otherwise the response is sent
+// already.
+func decodePatch(w http.ResponseWriter, r *http.Request, current any, v any) bool {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		SendProblem(w, r, fmt.Errorf("%w: %v", entity.ErrJson, err))
+		fmt.Println("Can not read the patch", err)
+		return false
+	}
+	if versionOf(r) == V2 {
+		current = responseV2(current)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println(entity.ErrJson, err)
+		return false
+	}
+	patched, err := patch.Apply(contentType, doc, body)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not apply the patch", err)
+		return false
+	}
+	if err := readJson(r, bytes.NewReader(patched), v); err != nil {
+		SendProblem(w, r, fmt.Errorf("%w: %v", entity.ErrJson, err))
+		fmt.Println(entity.ErrJson, err)
+		return false
+	}
+	if body, ok := v.(validator); ok {
+		return valid(w, r, body.Validate())
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"net/http"
+)
+
+func init() {
+	auth.SendError = SendProblem
+}
+
+// SendProblem answers a request with the problem details of err.
+func SendProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := dto.NewProblem(err)
+	if versionOf(r) == V2 {
+		p.Errors = mapSlice(p.Errors, func(f entity.FieldError) entity.FieldError {
+			f.Field = camelField(f.Field)
+			return f
+		})
+	}
+	if p.Status == http.StatusInternalServerError {
+		fmt.Println("Inner error", r.Method, r.URL.Path, err)
+	}
+	w.Header().Set("Content-Type", dto.ProblemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// problem is the decoded body of an error response with the status.
+func problem(status int, detail string, fields ...entity.FieldError) map[string]interface{} {
+	p := map[string]interface{}{"type": "about:blank", "title": http.StatusText(status), "status": float64(status), "detail": detail}
+	for _, kind := range []error{entity.ErrBadRequest, entity.ErrUnauthorized, entity.ErrForbidden, entity.ErrTooManyRequests,
+		entity.ErrNotFound, entity.ErrConflict, entity.ErrInvalidData, entity.ErrPreconditionFailed,
+		entity.ErrPreconditionRequired, entity.ErrUnavailable} {
+		if k := dto.NewProblem(kind); k.Status == status {
+			p["type"] = k.Type
+			break
+		}
+	}
+	if len(fields) > 0 {
+		var errs []interface{}
+		for _, f := range fields {
+			errs = append(errs, map[string]interface{}{"field": f.Field, "rule": f.Rule, "message": f.Message})
+		}
+		p["errors"] = errs
+	}
+	return p
+}
+
+func TestSendProblem(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected dto.Problem
+	}{
+		{
+			name:     "record not found",
+			err:      entity.WrapRecordNotFoundError("Dish", 3, gorm.ErrRecordNotFound),
+			expected: dto.Problem{Type: "/problems/not-found", Title: "Not Found", Status: http.StatusNotFound, Detail: "Dish with id 3 not found"},
+		},
+		{
+			name:     "conflict",
+			err:      fmt.Errorf("%w: Fries", entity.ErrDishSoldOut),
+			expected: dto.Problem{Type: "/problems/conflict", Title: "Conflict", Status: http.StatusConflict, Detail: "dish is sold out: Fries"},
+		},
+		{
+			name: "validation",
+			err:  entity.ValidationError{Fields: []entity.FieldError{{Field: "Name", Rule: entity.RuleRequired, Message: "is required"}}},
+			expected: dto.Problem{Type: "/problems/validation", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity,
+				Detail: "unsupported data: Name is required", Errors: []entity.FieldError{{Field: "Name", Rule: entity.RuleRequired, Message: "is required"}}},
+		},
+		{
+			name:     "invalid query",
+			err:      fmt.Errorf("%w: limit", entity.ErrBadRequest),
+			expected: dto.Problem{Type: "/problems/bad-request", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "bad request: limit"},
+		},
+		{
+			name:     "forbidden",
+			err:      fmt.Errorf("%w: %s", auth.ErrPermission, auth.PermDishEdit),
+			expected: dto.Problem{Type: "/problems/forbidden", Title: "Forbidden", Status: http.StatusForbidden, Detail: "permission required: dish.edit"},
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("database is locked"),
+			expected: dto.Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Detail: "Unknown error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/dishes/3", nil)
+			SendProblem(w, r, tt.err)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.Status, res.StatusCode)
+			assert.Equal(t, dto.ProblemContentType, res.Header.Get("Content-Type"))
+			var p dto.Problem
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&p))
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+}
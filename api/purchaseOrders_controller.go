@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PurchaseOrdersController struct {
+	Repo entity.Repo
+}
+
+func (p PurchaseOrdersController) RegisterRoutes(r chi.Router) {
+	r.With(managers).Post("/", p.CreatePurchaseOrder)
+	r.With(kitchen).Get("/", p.ReadAllPurchaseOrders)
+	r.With(managers).Get("/suggestions", p.ReadReorderSuggestions)
+	r.With(kitchen).Get("/{id}", p.ReadPurchaseOrderById)
+	r.With(kitchen).Post("/{id}/receive", p.ReceivePurchaseOrder)
+}
+
+func (p PurchaseOrdersController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Create a purchase order", Request: entity.PurchaseOrder{}, Response: entity.PurchaseOrder{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List purchase orders", Response: []entity.PurchaseOrder{}},
+		{Method: http.MethodGet, Pattern: "/suggestions", Summary: "Suggest the ingredients to reorder", Response: []entity.ReorderSuggestion{}},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read a purchase order", Response: entity.PurchaseOrder{}},
+		{Method: http.MethodPost, Pattern: "/{id}/receive", Summary: "Receive the delivered lines of a purchase order", Request: []entity.PurchaseOrderLine{}, Response: entity.PurchaseOrder{}},
+	}
+}
+
+func (p PurchaseOrdersController) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var order entity.PurchaseOrder
+	if !decode(w, r, &order) {
+		return
+	}
+	err := repoFor(p.Repo, r).CreatePurchaseOrder(&order)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not create purchase order", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, order)
+	fmt.Println("Added purchase order")
+}
+
+func (p PurchaseOrdersController) ReadAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := p.Repo.GetPurchaseOrders()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find purchase orders", err)
+		return
+	}
+	SendJson(w, http.StatusOK, orders)
+	fmt.Println("Found purchase orders")
+}
+
+func (p PurchaseOrdersController) ReadReorderSuggestions(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := p.Repo.GetReorderSuggestions()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not compute reorder suggestions", err)
+		return
+	}
+	SendJson(w, http.StatusOK, suggestions)
+	fmt.Println("Found reorder suggestions")
+}
+
+func (p PurchaseOrdersController) ReadPurchaseOrderById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	order, err := p.Repo.GetPurchaseOrder(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find purchase order", err)
+		return
+	}
+	SendJson(w, http.StatusOK, order)
+	fmt.Println("Found purchase order")
+}
+
+func (p PurchaseOrdersController) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var received []entity.PurchaseOrderLine
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &received) || !valid(w, r, entity.ValidateEach(received)) {
+		return
+	}
+	order, err := repoFor(p.Repo, r).ReceivePurchaseOrder(uint(id), received)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not receive purchase order", err)
+		return
+	}
+	SendJson(w, http.StatusOK, order)
+	fmt.Println("Received purchase order")
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestReorderSuggestionsRead(t *testing.T) {
+	type expectations struct {
+		statusCode  int
+		respPayload any
+	}
+	suggestion := entity.ReorderSuggestion{IngredientID: 1, Name: "Potato", Unit: "kg", Stock: 2, ParLevel: 10, Quantity: 8, SupplierID: 3, UnitCost: 1.5, EstimatedCost: 12}
+
+	tests := []struct {
+		name        string
+		existing    []entity.ReorderSuggestion
+		expected    expectations
+		respPayload any
+		err         error
+	}{
+		{
+			name:     "successful get suggestions",
+			existing: []entity.ReorderSuggestion{suggestion},
+			expected: expectations{
+				statusCode: http.StatusOK,
+				respPayload: []interface{}{map[string]interface{}{
+					"IngredientID":  float64(suggestion.IngredientID),
+					"Name":          suggestion.Name,
+					"Unit":          suggestion.Unit,
+					"Stock":         float64(suggestion.Stock),
+					"ParLevel":      float64(suggestion.ParLevel),
+					"Quantity":      float64(suggestion.Quantity),
+					"SupplierID":    float64(suggestion.SupplierID),
+					"UnitCost":      float64(suggestion.UnitCost),
+					"EstimatedCost": float64(suggestion.EstimatedCost),
+				}},
+			},
+		},
+		{
+			name: "failed to compute suggestions",
+			err:  errors.New("mock repo says no"),
+			expected: expectations{
+				statusCode:  http.StatusInternalServerError,
+				respPayload: problem(http.StatusInternalServerError, "Unknown error"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/purchaseOrders/suggestions", nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetReorderSuggestions").Return(tt.existing, tt.err)
+			PurchaseOrdersController{Repo: repo}.ReadReorderSuggestions(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.respPayload != nil {
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
+				assert.EqualValues(t, tt.expected.respPayload, tt.respPayload)
+			}
+		})
+	}
+}
+
+func TestPurchaseOrderReceive(t *testing.T) {
+	type expectations struct {
+		statusCode int
+		errMsg     string
+	}
+	received := []entity.PurchaseOrderLine{{IngredientID: 1, Quantity: 8, UnitCost: 1.5}}
+	order := entity.PurchaseOrder{Model: gorm.Model{ID: 4}, SupplierID: 3, Status: entity.PurchaseOrderReceived}
+	notFoundErr := entity.RecordNotFoundError{
+		Kind:  "PurchaseOrder",
+		ID:    strconv.FormatInt(int64(order.ID), 10),
+		Inner: errors.New("mock repo says no"),
+	}
+
+	tests := []struct {
+		name     string
+		payload  []entity.PurchaseOrderLine
+		existing entity.PurchaseOrder
+		expected expectations
+		err      error
+	}{
+		{
+			name:     "successful receive",
+			payload:  received,
+			existing: order,
+			expected: expectations{statusCode: http.StatusOK},
+		},
+		{
+			name:     "purchase order doesn't exist",
+			payload:  received,
+			existing: order,
+			err:      notFoundErr,
+			expected: expectations{statusCode: http.StatusNotFound, errMsg: notFoundErr.Error()},
+		},
+		{
+			name:     "purchase order already received",
+			payload:  received,
+			existing: order,
+			err:      entity.ErrPurchaseOrderReceived,
+			expected: expectations{statusCode: http.StatusConflict, errMsg: entity.ErrPurchaseOrderReceived.Error()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPost, "/purchaseOrders/{id}/receive", b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", strconv.FormatUint(uint64(tt.existing.ID), 10))
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			repo := new(entity.MockRepo)
+			repo.On("ReceivePurchaseOrder", tt.existing.ID, tt.payload).Return(tt.existing, tt.err)
+			PurchaseOrdersController{Repo: repo}.ReceivePurchaseOrder(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			var respPayload map[string]interface{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+			if tt.expected.errMsg != "" {
+				assert.Equal(t, tt.expected.errMsg, respPayload["detail"])
+			} else {
+				assert.Equal(t, entity.PurchaseOrderReceived, respPayload["Status"])
+			}
+		})
+	}
+}
@@ -1,9 +1,5 @@
 package api
 
-type Err struct {
-	Error string
-}
-
 type priceAfterDiscount struct {
 	OrderID       uint
 	DischID       uint
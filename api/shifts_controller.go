@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ShiftsController struct {
+	Repo entity.Repo
+}
+
+type closeShift struct {
+	CountedCash float32
+}
+
+func (c closeShift) Validate() error {
+	var v entity.Validator
+	v.Min("CountedCash", float64(c.CountedCash), 0)
+	return v.Err()
+}
+
+func (s ShiftsController) RegisterRoutes(r chi.Router) {
+	r.Use(managers)
+	r.Post("/", s.OpenShift)
+	r.Get("/", s.ReadAllShifts)
+	r.Get("/{id}", s.ReadShiftById)
+	r.Post("/{id}/close", s.CloseShift)
+	r.Get("/{id}/zReport", s.ReadZReport)
+}
+
+func (s ShiftsController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Open a shift", Request: entity.Shift{}, Response: entity.Shift{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List shifts", Response: []entity.Shift{}},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read a shift", Response: entity.Shift{}},
+		{Method: http.MethodPost, Pattern: "/{id}/close", Summary: "Close a shift with the counted cash", Request: closeShift{}, Response: entity.ZReport{}},
+		{Method: http.MethodGet, Pattern: "/{id}/zReport", Summary: "Read the Z report of a closed shift", Response: entity.ZReport{}},
+	}
+}
+
+func (s ShiftsController) OpenShift(w http.ResponseWriter, r *http.Request) {
+	var shift entity.Shift
+	if !decode(w, r, &shift) {
+		return
+	}
+	err := repoFor(s.Repo, r).OpenShift(&shift)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not open shift", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, shift)
+	fmt.Println("Opened shift")
+}
+
+func (s ShiftsController) ReadAllShifts(w http.ResponseWriter, r *http.Request) {
+	shifts, err := s.Repo.GetShifts()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find shifts", err)
+		return
+	}
+	SendJson(w, http.StatusOK, shifts)
+	fmt.Println("Found shifts")
+}
+
+func (s ShiftsController) ReadShiftById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	shift, err := s.Repo.GetShift(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find shift", err)
+		return
+	}
+	SendJson(w, http.StatusOK, shift)
+	fmt.Println("Found shift")
+}
+
+func (s ShiftsController) CloseShift(w http.ResponseWriter, r *http.Request) {
+	var body closeShift
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &body) {
+		return
+	}
+	report, err := repoFor(s.Repo, r).CloseShift(uint(id), body.CountedCash)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not close shift", err)
+		return
+	}
+	SendJson(w, http.StatusOK, report)
+	fmt.Println("Closed shift")
+}
+
+func (s ShiftsController) ReadZReport(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	report, err := s.Repo.GetZReport(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find z report", err)
+		return
+	}
+	SendJson(w, http.StatusOK, report)
+	fmt.Println("Found z report")
+}
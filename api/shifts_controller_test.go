@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestShiftClose(t *testing.T) {
+	type expectations struct {
+		statusCode int
+		errMsg     string
+	}
+	report := entity.ZReport{
+		Model:          gorm.Model{ID: 7},
+		ShiftID:        3,
+		Orders:         12,
+		Revenue:        480,
+		OpeningCash:    100,
+		CashExpected:   350,
+		CashCounted:    345,
+		CashDifference: -5,
+		Payments:       []entity.ZReportPayment{{Method: entity.PaymentCash, Count: 8, Amount: 250}},
+	}
+	notFoundErr := entity.RecordNotFoundError{
+		Kind:  "Shift",
+		ID:    strconv.FormatInt(int64(report.ShiftID), 10),
+		Inner: errors.New("mock repo says no"),
+	}
+
+	tests := []struct {
+		name     string
+		payload  closeShift
+		expected expectations
+		err      error
+	}{
+		{
+			name:     "successful close",
+			payload:  closeShift{CountedCash: 345},
+			expected: expectations{statusCode: http.StatusOK},
+		},
+		{
+			name:     "shift doesn't exist",
+			payload:  closeShift{CountedCash: 345},
+			err:      notFoundErr,
+			expected: expectations{statusCode: http.StatusNotFound, errMsg: notFoundErr.Error()},
+		},
+		{
+			name:     "shift already closed",
+			payload:  closeShift{CountedCash: 345},
+			err:      entity.ErrShiftClosed,
+			expected: expectations{statusCode: http.StatusConflict, errMsg: entity.ErrShiftClosed.Error()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b := bytes.NewBuffer(nil)
+			require.NoError(t, json.NewEncoder(b).Encode(tt.payload))
+			r := httptest.NewRequest(http.MethodPost, "/shifts/{id}/close", b)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", strconv.FormatUint(uint64(report.ShiftID), 10))
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			repo := new(entity.MockRepo)
+			repo.On("CloseShift", report.ShiftID, tt.payload.CountedCash).Return(report, tt.err)
+			ShiftsController{Repo: repo}.CloseShift(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.errMsg != "" {
+				var respPayload map[string]interface{}
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+				assert.Equal(t, tt.expected.errMsg, respPayload["detail"])
+			} else {
+				var respPayload entity.ZReport
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+				assert.Equal(t, report.CashDifference, respPayload.CashDifference)
+				assert.Equal(t, report.Payments, respPayload.Payments)
+			}
+		})
+	}
+}
+
+func TestOrderUpdateInClosedShift(t *testing.T) {
+	order := entity.Order{Model: gorm.Model{ID: 1}, TableNumber: 4, Version: 1}
+	w := httptest.NewRecorder()
+	b := bytes.NewBuffer(nil)
+	require.NoError(t, json.NewEncoder(b).Encode(order))
+	r := httptest.NewRequest(http.MethodPut, "/orders/{id}", b)
+	r.Header.Set("If-Match", `"1"`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.FormatUint(uint64(order.ID), 10))
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	repo := new(entity.MockRepo)
+	repo.On("UpdateOrder", order).Return(entity.ErrShiftClosed)
+	OrdersController{Repo: repo}.UpdateOderById(w, r)
+
+	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+}
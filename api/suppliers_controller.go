@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SuppliersController struct {
+	Repo entity.Repo
+}
+
+func (s SuppliersController) RegisterRoutes(r chi.Router) {
+	r.Use(managers)
+	r.Post("/", s.CreateSupplier)
+	r.Get("/", s.ReadAllSuppliers)
+	r.Get("/{id}", s.ReadSupplierById)
+	r.Put("/{id}", s.UpdateSupplierById)
+	r.Delete("/{id}", s.DeleteSupplierById)
+}
+
+func (s SuppliersController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Create a supplier", Request: entity.Supplier{}, Response: entity.Supplier{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List suppliers", Response: []entity.Supplier{}},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read a supplier", Response: entity.Supplier{}},
+		{Method: http.MethodPut, Pattern: "/{id}", Summary: "Update a supplier", Request: entity.Supplier{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Pattern: "/{id}", Summary: "Delete a supplier", Status: http.StatusNoContent},
+	}
+}
+
+func (s SuppliersController) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var supplier entity.Supplier
+	if !decodeJson(w, r, &supplier) || !valid(w, r, supplier.ValidateNew()) {
+		return
+	}
+	err := repoFor(s.Repo, r).CreateSupplier(&supplier)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not create supplier", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, supplier)
+	fmt.Println("Added supplier")
+}
+
+func (s SuppliersController) ReadAllSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := s.Repo.GetSuppliers()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find suppliers", err)
+		return
+	}
+	SendJson(w, http.StatusOK, suppliers)
+	fmt.Println("Found suppliers")
+}
+
+func (s SuppliersController) ReadSupplierById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	supplier, err := s.Repo.GetSupplier(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find supplier", err)
+		return
+	}
+	SendJson(w, http.StatusOK, supplier)
+	fmt.Println("Found supplier")
+}
+
+func (s SuppliersController) UpdateSupplierById(w http.ResponseWriter, r *http.Request) {
+	var supplier entity.Supplier
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &supplier) {
+		return
+	}
+	supplier.ID = uint(id)
+	err := repoFor(s.Repo, r).UpdateSupplier(&supplier)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update supplier", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Updated supplier")
+}
+
+func (s SuppliersController) DeleteSupplierById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	err := repoFor(s.Repo, r).DeleteSupplier(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not delete supplier", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Deleted supplier")
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TimesheetsController struct {
+	Repo entity.Repo
+}
+
+func (t TimesheetsController) RegisterRoutes(r chi.Router) {
+	r.With(staff).Post("/clockIn", t.ClockIn)
+	r.With(staff).Post("/clockOut", t.ClockOut)
+	r.With(staff).Get("/me", t.ReadOwnTimesheet)
+	r.With(managers).Get("/", t.ReadTimesheets)
+}
+
+func (t TimesheetsController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/clockIn", Summary: "Clock in the logged in user", Response: entity.TimeEntry{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Pattern: "/clockOut", Summary: "Clock out the logged in user", Response: entity.TimeEntry{}},
+		{Method: http.MethodGet, Pattern: "/me", Summary: "Read the timesheet of the logged in user", Response: []entity.Timesheet{}, Query: DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/", Summary: "Read the timesheets of all users", Response: []entity.Timesheet{}, Query: append([]openapi.Param{
+			{Name: "userId", Type: "integer", Description: "only the timesheet of this user"},
+		}, DateRangeParams...)},
+	}
+}
+
+// ClockIn starts the working time of the logged in user.
+func (t TimesheetsController) ClockIn(w http.ResponseWriter, r *http.Request) {
+	entry, err := repoFor(t.Repo, r).ClockIn(userId(r))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not clock in", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, entry)
+	fmt.Println("Clocked in user", entry.UserID)
+}
+
+func (t TimesheetsController) ClockOut(w http.ResponseWriter, r *http.Request) {
+	entry, err := repoFor(t.Repo, r).ClockOut(userId(r))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not clock out", err)
+		return
+	}
+	SendJson(w, http.StatusOK, entry)
+	fmt.Println("Clocked out user", entry.UserID)
+}
+
+func (t TimesheetsController) ReadOwnTimesheet(w http.ResponseWriter, r *http.Request) {
+	t.sendTimesheets(w, r, userId(r))
+}
+
+// ReadTimesheets reports the working hours per employee between from and to, or of
+// one employee with userId.
+func (t TimesheetsController) ReadTimesheets(w http.ResponseWriter, r *http.Request) {
+	var id uint64
+	if v := r.URL.Query().Get("userId"); v != "" {
+		var err error
+		id, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			SendProblem(w, r, fmt.Errorf("%w: invalid userId %q", entity.ErrBadRequest, v))
+			return
+		}
+	}
+	t.sendTimesheets(w, r, uint(id))
+}
+
+func (t TimesheetsController) sendTimesheets(w http.ResponseWriter, r *http.Request, id uint) {
+	from, to, err := ParseDateRange(r)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Invalid date range", err)
+		return
+	}
+	sheets, err := t.Repo.GetTimesheets(from, to, id)
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not read timesheets", err)
+		return
+	}
+	SendJson(w, http.StatusOK, sheets)
+	fmt.Println("Found timesheets from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestClockIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+	}{
+		{name: "successful clock in", statusCode: http.StatusCreated},
+		{name: "already clocked in", err: entity.ErrClockedIn, statusCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/timesheets/clockIn", nil)
+			r = r.WithContext(auth.WithClaims(r.Context(), auth.Claims{UserID: 3, Role: entity.RoleWaiter}))
+
+			repo := new(entity.MockRepo)
+			repo.On("ClockIn", uint(3)).Return(entity.TimeEntry{UserID: 3, ClockIn: time.Now()}, tt.err)
+			TimesheetsController{Repo: repo}.ClockIn(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTimesheetsRead(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 3, 8, 0, 0, 0, 0, time.Local)
+	out := from.Add(6 * time.Hour)
+	users := []entity.User{{Model: gorm.Model{ID: 3}, Username: "anna", Role: entity.RoleWaiter}, {Model: gorm.Model{ID: 4}, Username: "ben"}}
+	// The night shift started before the range, only its hours after from count.
+	entries := []entity.TimeEntry{
+		{UserID: 3, ClockIn: from.Add(-2 * time.Hour), ClockOut: &out},
+		{UserID: 3, ClockIn: to.Add(-time.Hour)},
+	}
+	sheets := entity.NewTimesheets(users, entries, from, to, to.Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		query      string
+		userId     uint
+		statusCode int
+	}{
+		{name: "all employees", query: "?from=2024-03-01&to=2024-03-07", statusCode: http.StatusOK},
+		{name: "one employee", query: "?from=2024-03-01&to=2024-03-07&userId=3", userId: 3, statusCode: http.StatusOK},
+		{name: "invalid employee", query: "?userId=anna", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/timesheets"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetTimesheets", from, to, tt.userId).Return(sheets, nil)
+			TimesheetsController{Repo: repo}.ReadTimesheets(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			var resp []entity.Timesheet
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			require.Len(t, resp, 2)
+			assert.Equal(t, "anna", resp[0].Username)
+			assert.InDelta(t, 7, resp[0].Hours, 0.001)
+			assert.Len(t, resp[0].Entries, 2)
+			assert.Zero(t, resp[1].Hours)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var errDeleteSelf = entity.NewError(entity.ErrConflict, "can not delete the logged in user")
+
+type UsersController struct {
+	Repo entity.Repo
+}
+
+// RegisterRoutes adds the user management, which only admins may use.
+func (u UsersController) RegisterRoutes(r chi.Router) {
+	r.Use(auth.RequireRole(entity.RoleAdmin))
+	r.Post("/", u.CreateUser)
+	r.Get("/", u.ReadAllUsers)
+	r.Get("/{id}", u.ReadUserById)
+	r.Put("/{id}", u.UpdateUserById)
+	r.Delete("/{id}", u.DeleteUserById)
+}
+
+func (u UsersController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Pattern: "/", Summary: "Create a user", Request: entity.User{}, Response: entity.User{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Pattern: "/", Summary: "List users", Response: []entity.User{}},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Read a user", Response: entity.User{}},
+		{Method: http.MethodPut, Pattern: "/{id}", Summary: "Update the role, password and PIN of a user", Request: entity.User{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Pattern: "/{id}", Summary: "Delete a user", Status: http.StatusNoContent},
+	}
+}
+
+// hashSecrets replaces a given password and PIN by their hashes, so they are never stored.
+func hashSecrets(user *entity.User) error {
+	if user.Password != "" {
+		hash, err := auth.HashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = ""
+		user.PasswordHash = hash
+	}
+	if user.Pin != "" {
+		hash, err := auth.HashPassword(user.Pin)
+		if err != nil {
+			return err
+		}
+		user.Pin = ""
+		user.PinHash = hash
+	}
+	return nil
+}
+
+func (u UsersController) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var user entity.User
+	if !decodeJson(w, r, &user) || !valid(w, r, user.ValidateNew()) {
+		return
+	}
+	err := hashSecrets(&user)
+	if err == nil {
+		err = repoFor(u.Repo, r).CreateUser(&user)
+	}
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not create user", err)
+		return
+	}
+	SendJson(w, http.StatusCreated, user)
+	fmt.Println("Added user")
+}
+
+func (u UsersController) ReadAllUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := u.Repo.GetUsers()
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find users", err)
+		return
+	}
+	SendJson(w, http.StatusOK, users)
+	fmt.Println("Found users")
+}
+
+func (u UsersController) ReadUserById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	user, err := u.Repo.GetUser(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not find user", err)
+		return
+	}
+	SendJson(w, http.StatusOK, user)
+	fmt.Println("Found user")
+}
+
+// UpdateUserById changes the role and, if they are given, the password and PIN of a user.
+func (u UsersController) UpdateUserById(w http.ResponseWriter, r *http.Request) {
+	var user entity.User
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if !decode(w, r, &user) {
+		return
+	}
+	user.ID = uint(id)
+	err := hashSecrets(&user)
+	if err == nil {
+		err = repoFor(u.Repo, r).UpdateUser(&user)
+	}
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not update user", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Updated user")
+}
+
+func (u UsersController) DeleteUserById(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if claims, ok := auth.FromContext(r.Context()); ok && claims.UserID == uint(id) {
+		SendProblem(w, r, errDeleteSelf)
+		return
+	}
+	err := repoFor(u.Repo, r).DeleteUser(uint(id))
+	if err != nil {
+		SendProblem(w, r, err)
+		fmt.Println("Can not delete user", err)
+		return
+	}
+	SendJson(w, http.StatusNoContent, nil)
+	fmt.Println("Deleted user")
+}
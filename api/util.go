@@ -6,11 +6,12 @@ import (
 	"fmt"
 	"gorestserviceagain/entity"
 	"net/http"
+	"time"
 )
 
 func SendJson(w http.ResponseWriter, status int, body any) {
-	w.WriteHeader(status)
 	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(status)
 	if body != nil {
 		err := json.NewEncoder(w).Encode(body)
 		if err != nil {
@@ -19,10 +20,6 @@ func SendJson(w http.ResponseWriter, status int, body any) {
 	}
 }
 
-func SendErr(w http.ResponseWriter, status int, err string) {
-	SendJson(w, status, Err{Error: err})
-}
-
 func comparePrice(DiscountDetail entity.DiscountDetail, dish entity.Dish) error {
 	var msg = "Added discount is too high"
 	discount := DiscountDetail.Discount
@@ -35,3 +32,39 @@ func comparePrice(DiscountDetail entity.DiscountDetail, dish entity.Dish) error
 	}
 	return nil
 }
+
+const dateLayout = "2006-01-02"
+
+// ParseDateRange reads the from and to query parameters, either as dates or RFC 3339 timestamps.
+// A date in to includes the whole day. Without parameters the last 30 days are used.
+func ParseDateRange(r *http.Request) (from time.Time, to time.Time, err error) {
+	now := time.Now()
+	to = now
+	from = now.AddDate(0, 0, -30)
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err = parseTime(v)
+		if err != nil {
+			return from, to, fmt.Errorf("%w: from: %w", entity.ErrBadRequest, err)
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, err = parseTime(v)
+		if err != nil {
+			return from, to, fmt.Errorf("%w: to: %w", entity.ErrBadRequest, err)
+		}
+		if len(v) == len(dateLayout) {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("%w: to is before from", entity.ErrBadRequest)
+	}
+	return from, to, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if len(v) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, v, time.Local)
+	}
+	return time.Parse(time.RFC3339, v)
+}
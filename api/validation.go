@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/entity"
+	"io"
+	"net/http"
+)
+
+// validator is a request body which checks its own fields.
+type validator interface {
+	Validate() error
+}
+
+// decodeJson reads the request body into v, in v2 through the request type of v. Malformed
+// JSON is a bad request. It reports whether v was read, otherwise the response is sent already.
+func decodeJson(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := readJson(r, r.Body, v); err != nil {
+		fmt.Println(entity.ErrJson, err)
+		SendProblem(w, r, fmt.Errorf("%w: %v", entity.ErrJson, err))
+		return false
+	}
+	return true
+}
+
+// readJson reads body into v in the version of r.
+func readJson(r *http.Request, body io.Reader, v any) error {
+	dec := json.NewDecoder(body)
+	if versionOf(r) == V2 {
+		if decoded, err := decodeV2(dec, v); decoded {
+			return err
+		}
+	}
+	return dec.Decode(v)
+}
+
+// decode reads the request body into v and validates it, if v is a validator.
+// It reports whether v can be used, otherwise the response is sent already.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if !decodeJson(w, r, v) {
+		return false
+	}
+	if body, ok := v.(validator); ok {
+		return valid(w, r, body.Validate())
+	}
+	return true
+}
+
+// valid answers invalid data with the list of invalid fields. It reports whether err is nil.
+func valid(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err == nil {
+		return true
+	}
+	SendProblem(w, r, err)
+	fmt.Println("Invalid request", err)
+	return false
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Version is the representation of the request and response bodies. V1 sends the entities
+// as they are, V2 the request and response types of package dto with camelCase names.
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+type versionKey struct{}
+
+// WithVersion is a middleware which makes the handlers below it read and write version v.
+func WithVersion(v Version) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v)))
+		})
+	}
+}
+
+// versionOf returns the version of a request, V1 if none is set.
+func versionOf(r *http.Request) Version {
+	if v, ok := r.Context().Value(versionKey{}).(Version); ok {
+		return v
+	}
+	return V1
+}
+
+// Deprecate marks the responses of v1 routes which have a v2 successor. Deprecation has the
+// date since when, Link the same route in v2 and Sunset the date the route is turned off, if
+// there is one.
+func Deprecate(since time.Time, sunset time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+			if !sunset.IsZero() {
+				h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successorPath(r.URL.Path)))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// successorPath returns the v2 path of a v1 path, with or without the /v1 prefix.
+func successorPath(path string) string {
+	return "/v2" + strings.TrimPrefix(path, "/v1")
+}
+
+// Respond sends body as JSON in the version of the request.
+func Respond(w http.ResponseWriter, r *http.Request, status int, body any) {
+	if versionOf(r) == V2 {
+		body = responseV2(body)
+	}
+	SendJson(w, status, body)
+}
+
+// requestV2 is the v2 request type of an entity read from a request body.
+type requestV2 struct {
+	// target is the pointer to the entity the request is decoded into.
+	target reflect.Type
+	typ    reflect.Type
+	decode func(dec *json.Decoder, v any) error
+}
+
+// requestsV2 has the v2 request types by the type of the pointer to the entity.
+var requestsV2 = requestTypes(
+	requestAs(toOrder),
+	requestAs(func(req []dto.OrderItemRequest) []entity.OrderItem {
+		return mapSlice(req, toOrderItem)
+	}),
+	requestAs(toItemAdjustment),
+	requestAs(toPayment),
+	requestAs(toDiscountDetail),
+	requestAs(toDish),
+	requestAs(func(req []dto.RecipeItemRequest) []entity.RecipeItem {
+		return mapSlice(req, toRecipeItem)
+	}),
+)
+
+// requestAs returns the request type R of the entity E, which toEntity maps it to.
+func requestAs[R any, E any](toEntity func(R) E) requestV2 {
+	return requestV2{
+		target: reflect.TypeFor[*E](),
+		typ:    reflect.TypeFor[R](),
+		decode: func(dec *json.Decoder, v any) error {
+			return decodeAs(dec, v.(*E), toEntity)
+		},
+	}
+}
+
+func requestTypes(requests ...requestV2) map[reflect.Type]requestV2 {
+	types := make(map[reflect.Type]requestV2, len(requests))
+	for _, req := range requests {
+		types[req.target] = req
+	}
+	return types
+}
+
+// decodeV2 reads a v2 request body into the entity v points to. It reports false for
+// entities without a v2 request type, which are read as they are.
+func decodeV2(dec *json.Decoder, v any) (bool, error) {
+	req, ok := requestsV2[reflect.TypeOf(v)]
+	if !ok {
+		return false, nil
+	}
+	return true, req.decode(dec, v)
+}
+
+// responseV2 returns the v2 response type of an entity, or body itself if it has none.
+func responseV2(body any) any {
+	switch t := body.(type) {
+	case entity.Order:
+		return NewOrderResponse(t)
+	case []entity.Order:
+		return mapSlice(t, NewOrderResponse)
+	case entity.OrderItem:
+		return NewOrderItemResponse(t)
+	case []entity.OrderItem:
+		return mapSlice(t, NewOrderItemResponse)
+	case entity.Payment:
+		return NewPaymentResponse(t)
+	case entity.DiscountDetail:
+		return NewDiscountResponse(t)
+	case priceAfterDiscount:
+		return NewPriceAfterDiscountResponse(t)
+	case entity.Dish:
+		return NewDishResponse(t)
+	case []entity.Dish:
+		return mapSlice(t, NewDishResponse)
+	case []entity.RecipeItem:
+		return mapSlice(t, NewRecipeItemResponse)
+	case []entity.ImportRow:
+		return mapSlice(t, NewImportRowResponse)
+	case entity.MarginReport:
+		return NewMarginReportResponse(t)
+	}
+	return body
+}
+
+// decodeAs reads the request type R and maps it to the entity target points to.
+func decodeAs[R any, E any](dec *json.Decoder, target *E, toEntity func(R) E) error {
+	var req R
+	if err := dec.Decode(&req); err != nil {
+		return err
+	}
+	*target = toEntity(req)
+	return nil
+}
+
+func mapSlice[T any, U any](s []T, f func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	mapped := make([]U, len(s))
+	for i, v := range s {
+		mapped[i] = f(v)
+	}
+	return mapped
+}
+
+// camelField turns the entity field path of a validation error into the v2 field names,
+// for example Items[0].DishID into items[0].dishId.
+func camelField(field string) string {
+	parts := strings.Split(field, ".")
+	for i, p := range parts {
+		name, index, _ := strings.Cut(p, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		parts[i] = camelName(name) + index
+	}
+	return strings.Join(parts, ".")
+}
+
+// camelName lowers the leading upper case letters of a Go field name, keeping the last
+// one of an initialism with a following word: ID is id, DishID dishId and SKU sku.
+func camelName(name string) string {
+	name = strings.ReplaceAll(name, "ID", "Id")
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
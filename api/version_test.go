@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecate(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		path      string
+		sunset    time.Time
+		successor string
+	}{
+		{name: "v1 route", path: "/v1/orders/3", sunset: sunset, successor: "</v2/orders/3>; rel=\"successor-version\""},
+		{name: "route without version", path: "/orders/3", successor: "</v2/orders/3>; rel=\"successor-version\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := func(r chi.Router) {
+				r.With(Deprecate(since, tt.sunset)).Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+					SendJson(w, http.StatusOK, nil)
+				})
+			}
+			router := chi.NewRouter()
+			routes(router)
+			router.Route("/v1", routes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			res := w.Result()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "@1790812800", res.Header.Get("Deprecation"))
+			assert.Equal(t, tt.successor, res.Header.Get("Link"))
+			if tt.sunset.IsZero() {
+				assert.Empty(t, res.Header.Get("Sunset"))
+			} else {
+				assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", res.Header.Get("Sunset"))
+			}
+		})
+	}
+}
+
+func TestCamelField(t *testing.T) {
+	for field, expected := range map[string]string{
+		"ID":                        "id",
+		"SKU":                       "sku",
+		"TaxRate":                   "taxRate",
+		"Items[1].DishID":           "items[1].dishId",
+		"Translations[0].Language":  "translations[0].language",
+		"PurchaseOrderLines[2].SKU": "purchaseOrderLines[2].sku",
+	} {
+		assert.Equal(t, expected, camelField(field), field)
+	}
+}
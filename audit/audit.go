@@ -0,0 +1,118 @@
+// Package audit records every change made through the repo in an append-only log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"reflect"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Repo decorates an entity.Repo and writes an audit entry for each create, update and
+// delete. The repo methods have no context, so the actor and request id come from the
+// context set with WithContext.
+type Repo struct {
+	entity.Repo
+	ctx context.Context
+}
+
+func New(repo entity.Repo) Repo {
+	return Repo{Repo: repo, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the repo which attributes its changes to the user and
+// request of ctx.
+func (a Repo) WithContext(ctx context.Context) entity.Repo {
+	a.ctx = ctx
+	return a
+}
+
+// ignored fields change with every update and would only clutter the diff.
+var ignored = map[string]bool{"UpdatedAt": true}
+
+// record writes the entry with tx, the transaction of the change, which fails with it.
+func (a Repo) record(tx entity.Repo, action string, kind string, id any, before any, after any) error {
+	claims, _ := auth.FromContext(a.ctx)
+	entry := entity.AuditEntry{
+		ActorID:    claims.UserID,
+		ApproverID: auth.ApproverFromContext(a.ctx),
+		RequestID:  middleware.GetReqID(a.ctx),
+		Action:     action,
+		Entity:     kind,
+		EntityID:   fmt.Sprint(id),
+	}
+	var err error
+	entry.Before, entry.After, entry.Diff, err = Diff(before, after)
+	if err != nil {
+		return fmt.Errorf("can not write audit log of %s %s %v: %w", action, kind, id, err)
+	}
+	if err := tx.AddAuditEntry(&entry); err != nil {
+		return fmt.Errorf("can not write audit log of %s %s %v: %w", action, kind, id, err)
+	}
+	return nil
+}
+
+// Diff returns before and after as JSON, and the changed top level fields as a JSON
+// object of {"Field": {"Before": ..., "After": ...}}. A nil before or after is a create
+// or delete, every field of the other one is part of the diff then.
+func Diff(before any, after any) (beforeJson string, afterJson string, diff string, err error) {
+	b, err := fields(before)
+	if err != nil {
+		return
+	}
+	f, err := fields(after)
+	if err != nil {
+		return
+	}
+	changes := make(map[string]map[string]any)
+	for k, v := range b {
+		if !ignored[k] && !reflect.DeepEqual(v, f[k]) {
+			changes[k] = map[string]any{"Before": v, "After": f[k]}
+		}
+	}
+	for k, v := range f {
+		if _, ok := b[k]; !ok && !ignored[k] {
+			changes[k] = map[string]any{"Before": nil, "After": v}
+		}
+	}
+	beforeJson, err = marshal(before)
+	if err != nil {
+		return
+	}
+	afterJson, err = marshal(after)
+	if err != nil {
+		return
+	}
+	d, err := json.Marshal(changes)
+	return beforeJson, afterJson, string(d), err
+}
+
+func fields(v any) (map[string]any, error) {
+	m := make(map[string]any)
+	if v == nil {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		// Not an object, e.g. the rows of an import.
+		var value any
+		json.Unmarshal(data, &value)
+		return map[string]any{"Value": value}, nil
+	}
+	return m, nil
+}
+
+func marshal(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
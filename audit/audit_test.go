@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDiff(t *testing.T) {
+	before := entity.Dish{Model: gorm.Model{ID: 1}, Name: "Pizza", Price: 8}
+	after := entity.Dish{Model: gorm.Model{ID: 1}, Name: "Pizza", Price: 9.5}
+
+	b, a, diff, err := Diff(before, after)
+	require.NoError(t, err)
+	assert.Contains(t, b, `"Price":8`)
+	assert.Contains(t, a, `"Price":9.5`)
+	assert.JSONEq(t, `{"Price":{"Before":8,"After":9.5}}`, diff)
+
+	b, a, diff, err = Diff(nil, entity.Supplier{Name: "Metro"})
+	require.NoError(t, err)
+	assert.Empty(t, b)
+	assert.NotEmpty(t, a)
+	assert.Contains(t, diff, `"Name":{"After":"Metro","Before":null}`)
+
+	_, a, diff, err = Diff(before, nil)
+	require.NoError(t, err)
+	assert.Empty(t, a)
+	assert.Contains(t, diff, `"Name":{"After":null,"Before":"Pizza"}`)
+}
+
+func TestRepo(t *testing.T) {
+	before := entity.Dish{Model: gorm.Model{ID: 1}, Name: "Pizza", Price: 8}
+	after := entity.Dish{Model: gorm.Model{ID: 1}, Name: "Pizza", Price: 9.5}
+	ctx := auth.WithClaims(context.Background(), auth.Claims{UserID: 3})
+	ctx = auth.WithApprover(ctx, 2)
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "host/req-000001")
+
+	mockRepo := new(entity.MockRepo)
+	mockRepo.On("GetDish", uint(1)).Return(before, nil).Once()
+	mockRepo.On("UpdateDish", after).Return(nil).Once()
+	mockRepo.On("GetDish", uint(1)).Return(after, nil).Once()
+	mockRepo.On("AddAuditEntry", mock.MatchedBy(func(e entity.AuditEntry) bool {
+		return e.ActorID == 3 && e.ApproverID == 2 && e.RequestID == "host/req-000001" &&
+			e.Action == entity.AuditUpdate && e.Entity == "dish" && e.EntityID == "1" &&
+			e.Diff == `{"Price":{"After":9.5,"Before":8}}`
+	})).Return(nil).Once()
+
+	repo := New(mockRepo).WithContext(ctx)
+	require.NoError(t, repo.UpdateDish(&after))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRepoFailed(t *testing.T) {
+	dish := entity.Dish{Name: "Pizza"}
+	mockRepo := new(entity.MockRepo)
+	mockRepo.On("CreateDish", dish).Return(errors.New("failed"))
+
+	err := New(mockRepo).CreateDish(&dish)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "AddAuditEntry", mock.Anything)
+}
+
+func TestRepoAuditFailed(t *testing.T) {
+	dish := entity.Dish{Name: "Pizza"}
+	mockRepo := new(entity.MockRepo)
+	mockRepo.On("CreateDish", dish).Return(nil)
+	mockRepo.On("AddAuditEntry", mock.Anything).Return(errors.New("disk full"))
+
+	err := New(mockRepo).CreateDish(&dish)
+	assert.ErrorContains(t, err, "disk full")
+}
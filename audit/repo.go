@@ -0,0 +1,303 @@
+package audit
+
+import "gorestserviceagain/entity"
+
+// The methods below wrap all changes of entity.Repo. Reads pass through unchanged.
+// Each change is made in a transaction with its audit entry, which reads the record
+// before and after the change, so a change without its entry is never committed.
+// Items, discounts, payments and signatures are recorded as updates of their order,
+// so the history of an order is found under its id.
+
+// Atomic runs fn in a transaction of the repo below. The changes fn makes through tx are
+// audited as well.
+func (a Repo) Atomic(fn func(tx entity.Repo) error) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		return fn(Repo{Repo: tx, ctx: a.ctx})
+	})
+}
+
+func (a Repo) orderChange(id uint, change func(tx entity.Repo) error) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetOrder(id)
+		if err := change(tx); err != nil {
+			return err
+		}
+		after, _ := tx.GetOrder(id)
+		return a.record(tx, entity.AuditUpdate, "order", id, before, after)
+	})
+}
+
+func (a Repo) CreateOrder(order *entity.Order) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreateOrder(order); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "order", order.ID, nil, *order)
+	})
+}
+
+func (a Repo) UpdateOrder(order *entity.Order) error {
+	return a.orderChange(order.ID, func(tx entity.Repo) error { return tx.UpdateOrder(order) })
+}
+
+func (a Repo) UpdateDiscount(discount *entity.DiscountDetail) error {
+	return a.orderChange(discount.OrderID, func(tx entity.Repo) error { return tx.UpdateDiscount(discount) })
+}
+
+func (a Repo) CreateDiscount(discount *entity.DiscountDetail) error {
+	return a.orderChange(discount.OrderID, func(tx entity.Repo) error { return tx.CreateDiscount(discount) })
+}
+
+func (a Repo) DeleteOrder(id uint, version uint) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetOrder(id)
+		if err := tx.DeleteOrder(id, version); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditDelete, "order", id, before, nil)
+	})
+}
+
+func (a Repo) AddOrderItems(orderId uint, items []entity.OrderItem) error {
+	return a.orderChange(orderId, func(tx entity.Repo) error { return tx.AddOrderItems(orderId, items) })
+}
+
+func (a Repo) AdjustOrderItem(orderId uint, itemId uint, adjustment entity.ItemAdjustment) (item entity.OrderItem, err error) {
+	err = a.orderChange(orderId, func(tx entity.Repo) error {
+		item, err = tx.AdjustOrderItem(orderId, itemId, adjustment)
+		return err
+	})
+	return item, err
+}
+
+func (a Repo) AddPayment(payment *entity.Payment) error {
+	return a.orderChange(payment.OrderID, func(tx entity.Repo) error { return tx.AddPayment(payment) })
+}
+
+// AddSignature also signs voided orders, which GetOrder does not find anymore.
+func (a Repo) AddSignature(signature *entity.FiscalSignature) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.AddSignature(signature); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "fiscalSignature", signature.ID, nil, *signature)
+	})
+}
+
+func (a Repo) CreateDish(dish *entity.Dish) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreateDish(dish); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "dish", dish.ID, nil, *dish)
+	})
+}
+
+func (a Repo) UpdateDish(dish *entity.Dish) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetDish(dish.ID)
+		if err := tx.UpdateDish(dish); err != nil {
+			return err
+		}
+		after, _ := tx.GetDish(dish.ID)
+		return a.record(tx, entity.AuditUpdate, "dish", dish.ID, before, after)
+	})
+}
+
+func (a Repo) DeleteDish(id uint, version uint) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetDish(id)
+		if err := tx.DeleteDish(id, version); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditDelete, "dish", id, before, nil)
+	})
+}
+
+// ImportDishes does not record dry runs, which change nothing.
+func (a Repo) ImportDishes(dishes []entity.Dish, dryRun bool) (rows []entity.ImportRow, err error) {
+	if dryRun {
+		return a.Repo.ImportDishes(dishes, dryRun)
+	}
+	err = a.Repo.Atomic(func(tx entity.Repo) error {
+		rows, err = tx.ImportDishes(dishes, dryRun)
+		if err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditImport, "dish", "", nil, rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (a Repo) CreateIngredient(ingredient *entity.Ingredient) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreateIngredient(ingredient); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "ingredient", ingredient.ID, nil, *ingredient)
+	})
+}
+
+func (a Repo) UpdateIngredient(ingredient *entity.Ingredient) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetIngredient(ingredient.ID)
+		if err := tx.UpdateIngredient(ingredient); err != nil {
+			return err
+		}
+		after, _ := tx.GetIngredient(ingredient.ID)
+		return a.record(tx, entity.AuditUpdate, "ingredient", ingredient.ID, before, after)
+	})
+}
+
+func (a Repo) DeleteIngredient(id uint) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetIngredient(id)
+		if err := tx.DeleteIngredient(id); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditDelete, "ingredient", id, before, nil)
+	})
+}
+
+func (a Repo) SetRecipe(dishId uint, recipe []entity.RecipeItem) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetRecipe(dishId)
+		if err := tx.SetRecipe(dishId, recipe); err != nil {
+			return err
+		}
+		after, _ := tx.GetRecipe(dishId)
+		return a.record(tx, entity.AuditUpdate, "recipe", dishId, before, after)
+	})
+}
+
+func (a Repo) CreateSupplier(supplier *entity.Supplier) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreateSupplier(supplier); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "supplier", supplier.ID, nil, *supplier)
+	})
+}
+
+func (a Repo) UpdateSupplier(supplier *entity.Supplier) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetSupplier(supplier.ID)
+		if err := tx.UpdateSupplier(supplier); err != nil {
+			return err
+		}
+		after, _ := tx.GetSupplier(supplier.ID)
+		return a.record(tx, entity.AuditUpdate, "supplier", supplier.ID, before, after)
+	})
+}
+
+func (a Repo) DeleteSupplier(id uint) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetSupplier(id)
+		if err := tx.DeleteSupplier(id); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditDelete, "supplier", id, before, nil)
+	})
+}
+
+func (a Repo) CreatePurchaseOrder(order *entity.PurchaseOrder) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreatePurchaseOrder(order); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "purchaseOrder", order.ID, nil, *order)
+	})
+}
+
+func (a Repo) ReceivePurchaseOrder(id uint, received []entity.PurchaseOrderLine) (after entity.PurchaseOrder, err error) {
+	err = a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetPurchaseOrder(id)
+		after, err = tx.ReceivePurchaseOrder(id, received)
+		if err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditUpdate, "purchaseOrder", id, before, after)
+	})
+	return after, err
+}
+
+func (a Repo) OpenShift(shift *entity.Shift) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.OpenShift(shift); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "shift", shift.ID, nil, *shift)
+	})
+}
+
+func (a Repo) CloseShift(id uint, countedCash float32) (report entity.ZReport, err error) {
+	err = a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetShift(id)
+		report, err = tx.CloseShift(id, countedCash)
+		if err != nil {
+			return err
+		}
+		after, _ := tx.GetShift(id)
+		return a.record(tx, entity.AuditUpdate, "shift", id, before, after)
+	})
+	return report, err
+}
+
+func (a Repo) CreateUser(user *entity.User) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		if err := tx.CreateUser(user); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "user", user.ID, nil, *user)
+	})
+}
+
+func (a Repo) UpdateUser(user *entity.User) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetUser(user.ID)
+		if err := tx.UpdateUser(user); err != nil {
+			return err
+		}
+		after, _ := tx.GetUser(user.ID)
+		return a.record(tx, entity.AuditUpdate, "user", user.ID, before, after)
+	})
+}
+
+func (a Repo) DeleteUser(id uint) error {
+	return a.Repo.Atomic(func(tx entity.Repo) error {
+		before, _ := tx.GetUser(id)
+		if err := tx.DeleteUser(id); err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditDelete, "user", id, before, nil)
+	})
+}
+
+func (a Repo) ClockIn(userId uint) (e entity.TimeEntry, err error) {
+	err = a.Repo.Atomic(func(tx entity.Repo) error {
+		e, err = tx.ClockIn(userId)
+		if err != nil {
+			return err
+		}
+		return a.record(tx, entity.AuditCreate, "timeEntry", e.ID, nil, e)
+	})
+	return e, err
+}
+
+func (a Repo) ClockOut(userId uint) (e entity.TimeEntry, err error) {
+	err = a.Repo.Atomic(func(tx entity.Repo) error {
+		e, err = tx.ClockOut(userId)
+		if err != nil {
+			return err
+		}
+		before := e
+		before.ClockOut = nil
+		return a.record(tx, entity.AuditUpdate, "timeEntry", e.ID, before, e)
+	})
+	return e, err
+}
+
+var _ entity.Repo = Repo{}
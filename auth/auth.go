@@ -0,0 +1,165 @@
+// Package auth issues login tokens and checks them, and the role of their user,
+// in chi middleware.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnauthorized = entity.NewError(entity.ErrUnauthorized, "missing or invalid login token")
+var ErrForbidden = entity.NewError(entity.ErrForbidden, "not allowed for this role")
+var ErrInvalidCredentials = entity.NewError(entity.ErrUnauthorized, "invalid username or password")
+
+// Claims are the contents of a login token.
+type Claims struct {
+	UserID   uint
+	Username string
+	Role     string
+	jwt.RegisteredClaims
+}
+
+// Tokens issues and parses HMAC signed JWTs.
+type Tokens struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+func (t Tokens) Issue(user entity.User) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(t.TTL)
+	claims := Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprint(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.Secret)
+	return token, expiresAt, err
+}
+
+func (t Tokens) Parse(token string) (Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return t.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w:%w", ErrUnauthorized, err)
+	}
+	return claims, nil
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func CheckPassword(user entity.User, password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// CheckPin checks the quick login PIN of staff. Admins have to log in with their password.
+func CheckPin(user entity.User, pin string) error {
+	if user.PinHash == "" || user.Role == entity.RoleAdmin {
+		return ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PinHash), []byte(pin)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+type contextKey struct{}
+
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims of the authenticated user of a request.
+func FromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(Claims)
+	return claims, ok
+}
+
+// UserGetter loads the user a login token was issued for.
+type UserGetter interface {
+	GetUser(id uint) (entity.User, error)
+}
+
+// Authenticate rejects requests without a valid "Authorization: Bearer" token and
+// makes the claims available through FromContext. The user is loaded on every request,
+// so tokens of deleted users are rejected and the claims have the current role.
+func Authenticate(tokens Tokens, users UserGetter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				SendError(w, r, ErrUnauthorized)
+				return
+			}
+			claims, err := tokens.Parse(token)
+			if err != nil {
+				fmt.Println("Rejected login token", err)
+				SendError(w, r, ErrUnauthorized)
+				return
+			}
+			user, err := users.GetUser(claims.UserID)
+			if errors.Is(err, entity.ErrNotFound) {
+				fmt.Println("Rejected login token of deleted user", claims.UserID)
+				SendError(w, r, ErrUnauthorized)
+				return
+			}
+			if err != nil {
+				fmt.Println("Can not load user of login token", err)
+				SendError(w, r, err)
+				return
+			}
+			claims.Username = user.Username
+			claims.Role = user.Role
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// RequireRole only lets users with one of the roles through. Admins may do everything.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContext(r.Context())
+			if !ok {
+				SendError(w, r, ErrUnauthorized)
+				return
+			}
+			if claims.Role != entity.RoleAdmin && !slices.Contains(roles, claims.Role) {
+				SendError(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SendError answers the requests the middleware rejects. The api package sets it to its
+// problem details mapper, which can not be used here as api depends on auth.
+var SendError = func(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(StatusOf(err))
+	json.NewEncoder(w).Encode(map[string]string{"Error": err.Error()})
+}
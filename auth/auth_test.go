@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTokens(t *testing.T) {
+	tokens := Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	user := entity.User{Model: gorm.Model{ID: 3}, Username: "anna", Role: entity.RoleWaiter}
+
+	token, expiresAt, err := tokens.Issue(user)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	claims, err := tokens.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), claims.UserID)
+	assert.Equal(t, entity.RoleWaiter, claims.Role)
+
+	_, err = Tokens{Secret: []byte("other")}.Parse(token)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	expired, _, err := Tokens{Secret: []byte("secret"), TTL: -time.Minute}.Issue(user)
+	require.NoError(t, err)
+	_, err = tokens.Parse(expired)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("geheim")
+	require.NoError(t, err)
+	user := entity.User{PasswordHash: hash}
+	assert.NoError(t, CheckPassword(user, "geheim"))
+	assert.ErrorIs(t, CheckPassword(user, "falsch"), ErrInvalidCredentials)
+}
+
+func TestMiddleware(t *testing.T) {
+	tokens := Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	stored := users{}
+	for i, role := range []string{entity.RoleWaiter, entity.RoleManager, entity.RoleAdmin} {
+		stored[role] = entity.User{Model: gorm.Model{ID: uint(i + 1)}, Username: role, Role: role}
+	}
+	handler := Authenticate(tokens, stored)(RequireRole(entity.RoleManager)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	token := func(id uint, role string) string {
+		token, _, err := tokens.Issue(entity.User{Model: gorm.Model{ID: id}, Username: role, Role: role})
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		statusCode    int
+	}{
+		{name: "no token", statusCode: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer nonsense", statusCode: http.StatusUnauthorized},
+		{name: "wrong role", authorization: token(1, entity.RoleWaiter), statusCode: http.StatusForbidden},
+		{name: "allowed role", authorization: token(2, entity.RoleManager), statusCode: http.StatusNoContent},
+		{name: "admin", authorization: token(3, entity.RoleAdmin), statusCode: http.StatusNoContent},
+		{name: "demoted user", authorization: token(1, entity.RoleManager), statusCode: http.StatusForbidden},
+		{name: "deleted user", authorization: token(9, entity.RoleAdmin), statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestLockout(t *testing.T) {
+	l := NewLockout(3, time.Minute)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for range 2 {
+		l.Fail("anna")
+	}
+	assert.False(t, l.Locked("anna"))
+	l.Fail("anna")
+	assert.True(t, l.Locked("anna"))
+	assert.False(t, l.Locked("ben"))
+
+	now = now.Add(2 * time.Minute)
+	assert.False(t, l.Locked("anna"))
+	l.Fail("anna")
+	l.Reset("anna")
+	assert.False(t, l.Locked("anna"))
+}
+
+func TestLockoutForgetsExpiredFailures(t *testing.T) {
+	l := NewLockout(3, time.Minute)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := range 100 {
+		l.Fail(fmt.Sprintf("user%d", i))
+	}
+	assert.Len(t, l.failures, 100)
+
+	now = now.Add(2 * time.Minute)
+	l.Fail("anna")
+	assert.Len(t, l.failures, 1)
+
+	// Failures further apart than the duration do not add up.
+	now = now.Add(2 * time.Minute)
+	l.Fail("anna")
+	l.Fail("anna")
+	assert.False(t, l.Locked("anna"))
+}
+
+type users map[string]entity.User
+
+func (u users) GetUserByUsername(username string) (entity.User, error) {
+	if user, ok := u[username]; ok {
+		return user, nil
+	}
+	return entity.User{}, entity.WrapRecordNotFoundError("User", username, nil)
+}
+
+func (u users) GetUser(id uint) (entity.User, error) {
+	for _, user := range u {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return entity.User{}, entity.WrapRecordNotFoundError("User", id, nil)
+}
+
+func TestApprovals(t *testing.T) {
+	pin, err := HashPassword("1234")
+	require.NoError(t, err)
+	approvals := Approvals{Users: users{
+		"maria": {Model: gorm.Model{ID: 7}, Username: "maria", PinHash: pin, Role: entity.RoleManager},
+		"tom":   {Model: gorm.Model{ID: 8}, Username: "tom", PinHash: pin, Role: entity.RoleWaiter},
+	}, Lockout: NewLockout(5, time.Minute)}
+	var approver uint
+	handler := approvals.Require(PermOrderVoid)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		approver = ApproverFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		claims     Claims
+		approver   string
+		pin        string
+		statusCode int
+		approvedBy uint
+	}{
+		{name: "manager voids", claims: Claims{UserID: 7, Role: entity.RoleManager}, statusCode: http.StatusNoContent},
+		{name: "waiter needs approval", claims: Claims{UserID: 3, Role: entity.RoleWaiter}, statusCode: http.StatusForbidden},
+		{name: "manager approves", claims: Claims{UserID: 3, Role: entity.RoleWaiter}, approver: "maria", pin: "1234", statusCode: http.StatusNoContent, approvedBy: 7},
+		{name: "wrong PIN", claims: Claims{UserID: 3, Role: entity.RoleWaiter}, approver: "maria", pin: "0000", statusCode: http.StatusForbidden},
+		{name: "waiter can not approve", claims: Claims{UserID: 3, Role: entity.RoleWaiter}, approver: "tom", pin: "1234", statusCode: http.StatusForbidden},
+		{name: "no self approval", claims: Claims{UserID: 8, Role: entity.RoleWaiter}, approver: "tom", pin: "1234", statusCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approver = 0
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/orders/1", nil)
+			r = r.WithContext(WithClaims(r.Context(), tt.claims))
+			if tt.approver != "" {
+				r.Header.Set(ApproverHeader, tt.approver)
+				r.Header.Set(ApproverPinHeader, tt.pin)
+			}
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Result().StatusCode)
+			assert.Equal(t, tt.approvedBy, approver)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"gorestserviceagain/entity"
+	"sync"
+	"time"
+)
+
+var ErrLockedOut = entity.NewError(entity.ErrTooManyRequests, "too many failed logins, try again later")
+
+// Lockout blocks a username for a while after too many failed logins, so that
+// 4 digit PINs can not simply be tried out. Failures are forgotten Duration after
+// the last one.
+type Lockout struct {
+	MaxAttempts int
+	Duration    time.Duration
+
+	mu       sync.Mutex
+	failures map[string]failure
+	pruned   time.Time
+	now      func() time.Time
+}
+
+type failure struct {
+	count int
+	until time.Time
+}
+
+func NewLockout(maxAttempts int, duration time.Duration) *Lockout {
+	return &Lockout{MaxAttempts: maxAttempts, Duration: duration, failures: make(map[string]failure), now: time.Now}
+}
+
+func (l *Lockout) Locked(username string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.failures[username]
+	if !ok {
+		return false
+	}
+	if l.now().After(f.until) {
+		delete(l.failures, username)
+		return false
+	}
+	return f.count >= l.MaxAttempts
+}
+
+func (l *Lockout) Fail(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.prune(now)
+	f := l.failures[username]
+	if now.After(f.until) {
+		f = failure{}
+	}
+	f.count++
+	f.until = now.Add(l.Duration)
+	l.failures[username] = f
+}
+
+// prune forgets the expired failures at most once per Duration, so that logins with
+// ever new usernames can not grow the map without bound.
+func (l *Lockout) prune(now time.Time) {
+	if now.Before(l.pruned.Add(l.Duration)) {
+		return
+	}
+	for username, f := range l.failures {
+		if now.After(f.until) {
+			delete(l.failures, username)
+		}
+	}
+	l.pruned = now
+}
+
+func (l *Lockout) Reset(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, username)
+}
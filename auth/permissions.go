@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"net/http"
+	"slices"
+)
+
+const (
+	PermDiscountUpTo20 = "discount.apply.upto20"
+	PermDiscountAny    = "discount.apply.any"
+	PermOrderVoid      = "order.void"
+	PermItemComp       = "item.comp"
+	PermDishEdit       = "dish.edit"
+	PermPriceOverride  = "price.override"
+)
+
+// RolePermissions lists what each role may do without approval. Admins may do everything.
+var RolePermissions = map[string][]string{
+	entity.RoleManager: {PermDiscountUpTo20, PermDiscountAny, PermOrderVoid, PermItemComp, PermDishEdit, PermPriceOverride},
+	entity.RoleWaiter:  {PermDiscountUpTo20},
+}
+
+// The approval of a second user is sent with these headers on the privileged request.
+const (
+	ApproverHeader    = dto.ApproverHeader
+	ApproverPinHeader = dto.ApproverPinHeader
+)
+
+var ErrPermission = entity.NewError(entity.ErrForbidden, "permission required")
+var ErrApproval = entity.NewError(entity.ErrForbidden, "invalid approval")
+
+func Can(role string, permission string) bool {
+	return role == entity.RoleAdmin || slices.Contains(RolePermissions[role], permission)
+}
+
+type UserFinder interface {
+	GetUserByUsername(username string) (entity.User, error)
+}
+
+// Approvals checks the permissions of the logged in user. A user without the permission
+// can still do the action if a second user who has it approves it with their PIN.
+type Approvals struct {
+	Users   UserFinder
+	Lockout *Lockout
+}
+
+// Authorize returns the id of the approving user, or 0 if the logged in user has the
+// permission.
+func (a Approvals) Authorize(r *http.Request, permission string) (approver uint, err error) {
+	claims, ok := FromContext(r.Context())
+	if !ok {
+		return 0, ErrUnauthorized
+	}
+	if Can(claims.Role, permission) {
+		return 0, nil
+	}
+	username := r.Header.Get(ApproverHeader)
+	if username == "" {
+		return 0, fmt.Errorf("%w: %s, a manager can approve with the %s and %s headers",
+			ErrPermission, permission, ApproverHeader, ApproverPinHeader)
+	}
+	if a.Lockout != nil && a.Lockout.Locked(username) {
+		return 0, ErrLockedOut
+	}
+	user, err := a.Users.GetUserByUsername(username)
+	if err == nil {
+		err = CheckPin(user, r.Header.Get(ApproverPinHeader))
+	}
+	if err != nil {
+		if a.Lockout != nil {
+			a.Lockout.Fail(username)
+		}
+		return 0, fmt.Errorf("%w: wrong approver or PIN", ErrApproval)
+	}
+	if a.Lockout != nil {
+		a.Lockout.Reset(username)
+	}
+	if user.ID == claims.UserID || !Can(user.Role, permission) {
+		return 0, fmt.Errorf("%w: %s may not approve %s", ErrApproval, user.Username, permission)
+	}
+	fmt.Printf("%s approved %s for %s\n", user.Username, permission, claims.Username)
+	return user.ID, nil
+}
+
+// Require is the middleware version of Authorize. The approving user is available
+// through ApproverFromContext.
+func (a Approvals) Require(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			approver, err := a.Authorize(r, permission)
+			if err != nil {
+				SendError(w, r, err)
+				return
+			}
+			if approver != 0 {
+				r = r.WithContext(WithApprover(r.Context(), approver))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StatusOf maps the errors of Authorize to a HTTP status.
+func StatusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrLockedOut):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusForbidden
+}
+
+type approverKey struct{}
+
+func WithApprover(ctx context.Context, approver uint) context.Context {
+	return context.WithValue(ctx, approverKey{}, approver)
+}
+
+// ApproverFromContext returns the user who approved the action of a request, or 0.
+func ApproverFromContext(ctx context.Context) uint {
+	approver, _ := ctx.Value(approverKey{}).(uint)
+	return approver
+}
@@ -0,0 +1,229 @@
+// Package client calls the v2 API of the service from other Go services. The methods
+// send and return the request and response types of package dto, problems answered
+// by the API are returned as *Error, which matches the error kinds of dto. It only
+// depends on dto, so it does not link the service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/dto"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API at BaseURL, with the login token Token.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+	// Retries is how often idempotent requests are repeated after network errors and
+	// responses saying the service is unavailable. They wait Backoff, doubled each time,
+	// or as long as the Retry-After header asks. Changes with If-Match are not repeated,
+	// see do.
+	Retries int
+	Backoff time.Duration
+}
+
+// New returns a client for the API at baseURL, which retries idempotent requests 3 times.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    http.DefaultClient,
+		Retries: 3,
+		Backoff: 200 * time.Millisecond,
+	}
+}
+
+type loginToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Login logs in with username and password and uses the token for the following requests.
+// It returns when the token expires.
+func (c *Client) Login(ctx context.Context, username string, password string) (time.Time, error) {
+	return c.login(ctx, "/auth/login", map[string]string{"Username": username, "Password": password})
+}
+
+// PinLogin logs in with username and PIN, see Login.
+func (c *Client) PinLogin(ctx context.Context, username string, pin string) (time.Time, error) {
+	return c.login(ctx, "/auth/pin", map[string]string{"Username": username, "Pin": pin})
+}
+
+func (c *Client) login(ctx context.Context, path string, body any) (time.Time, error) {
+	var token loginToken
+	if err := c.do(ctx, http.MethodPost, path, nil, nil, body, &token); err != nil {
+		return time.Time{}, err
+	}
+	c.Token = token.Token
+	return token.ExpiresAt, nil
+}
+
+type approverKey struct{}
+
+type approver struct {
+	username string
+	pin      string
+}
+
+// WithApprover makes the requests with ctx approved by a second user with their PIN, for
+// actions the logged in user may not do alone, like voids or high discounts.
+func WithApprover(ctx context.Context, username string, pin string) context.Context {
+	return context.WithValue(ctx, approverKey{}, approver{username: username, pin: pin})
+}
+
+// get, post, put and del call the v2 route path and decode the response into out, if
+// it is not nil. put and del change the version of the resource the client read, see ifMatch.
+func get[T any](ctx context.Context, c *Client, path string, query url.Values) (T, error) {
+	var out T
+	err := c.do(ctx, http.MethodGet, "/v2"+path, query, nil, nil, &out)
+	return out, err
+}
+
+func post[T any](ctx context.Context, c *Client, path string, body any) (T, error) {
+	var out T
+	err := c.do(ctx, http.MethodPost, "/v2"+path, nil, nil, body, &out)
+	return out, err
+}
+
+func put(ctx context.Context, c *Client, path string, version uint, body any) error {
+	return c.do(ctx, http.MethodPut, "/v2"+path, nil, ifMatch(version), body, nil)
+}
+
+func del(ctx context.Context, c *Client, path string, version uint) error {
+	return c.do(ctx, http.MethodDelete, "/v2"+path, nil, ifMatch(version), nil, nil)
+}
+
+// ifMatch makes a change fail with dto.ErrPreconditionFailed if the resource is no
+// longer at version, the version field of the response it was read with.
+func ifMatch(version uint) http.Header {
+	return http.Header{"If-Match": {strconv.Quote(strconv.FormatUint(uint64(version), 10))}}
+}
+
+// Patch is a JSON Merge Patch of a resource, by the JSON names of its v2 request type.
+// Nil sets a field to its zero value, e.g. Patch{"price": 0, "sku": nil}.
+type Patch map[string]any
+
+func patchWith(ctx context.Context, c *Client, path string, version uint, p Patch) error {
+	return c.do(ctx, http.MethodPatch, "/v2"+path, nil, ifMatch(version), p, nil)
+}
+
+// do sends a request with header and body as JSON and decodes the response into out.
+// Problems are returned as *Error.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, header http.Header, body any, out any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	// A change with If-Match whose response got lost may have been made, its repetition
+	// would fail with 412 as the version changed. So the caller has to read it again.
+	retries := 0
+	if idempotent(method) && header.Get("If-Match") == "" {
+		retries = c.Retries
+	}
+	wait := c.Backoff
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, method, u, header, contentType(body), data)
+		if attempt >= retries || err == nil && !retryable(res.StatusCode) {
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+			return decode(res, out)
+		}
+		if err == nil {
+			wait = retryAfter(res, wait)
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, u string, header http.Header, contentType string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if a, ok := ctx.Value(approverKey{}).(approver); ok {
+		req.Header.Set(dto.ApproverHeader, a.username)
+		req.Header.Set(dto.ApproverPinHeader, a.pin)
+	}
+	return c.HTTP.Do(req)
+}
+
+// decode reads a successful response into out and a problem into an *Error.
+func decode(res *http.Response, out any) error {
+	if res.StatusCode >= http.StatusBadRequest {
+		return newError(res)
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	// Accepted requests may have no body.
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("can not read response: %w", err)
+	}
+	return nil
+}
+
+func contentType(body any) string {
+	if _, ok := body.(Patch); ok {
+		return dto.MergePatchContentType
+	}
+	return "application/json"
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the seconds to wait of the Retry-After header, or wait without one.
+func retryAfter(res *http.Response, wait time.Duration) time.Duration {
+	if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		return time.Duration(s) * time.Second
+	}
+	return wait
+}
@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"go/build"
+	"gorestserviceagain/api"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var tokens = auth.Tokens{Secret: []byte("secret"), TTL: time.Hour}
+
+// newServer serves the v2 routes of the controllers with repo, and the logins. The
+// handler runs before the routes, it can answer requests itself by returning false.
+func newServer(t *testing.T, repo entity.Repo, before func(w http.ResponseWriter, r *http.Request) bool) *Client {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if before == nil || before(w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	})
+	r.Route("/auth", api.AuthController{Repo: repo, Tokens: tokens}.RegisterRoutes)
+	r.Route("/v2", func(r chi.Router) {
+		r.Use(auth.Authenticate(tokens, repo), api.WithVersion(api.V2))
+		r.Route("/orders", api.OrdersController{Repo: repo}.RegisterRoutes)
+		r.Route("/dishes", api.DishesController{Repo: repo}.RegisterRoutes)
+		api.DiscountDetailController{Repo: repo}.RegisterRoutes(r)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+	return c
+}
+
+// login logs the client in as anna, who has the role in repo.
+func login(t *testing.T, c *Client, repo *entity.MockRepo, role string) {
+	user := entity.User{Model: gorm.Model{ID: 4}, Username: "anna", Role: role}
+	repo.On("GetUser", user.ID).Return(user, nil)
+	token, _, err := tokens.Issue(user)
+	require.NoError(t, err)
+	c.Token = token
+}
+
+func TestLogin(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	repo := new(entity.MockRepo)
+	anna := entity.User{Model: gorm.Model{ID: 4}, Username: "anna", PasswordHash: hash, Role: entity.RoleWaiter}
+	repo.On("GetUserByUsername", "anna").Return(anna, nil)
+	repo.On("GetUser", anna.ID).Return(anna, nil)
+	repo.On("GetOrder", uint(1)).Return(entity.Order{Model: gorm.Model{ID: 1}}, nil)
+	c := newServer(t, repo, nil)
+
+	_, err = c.GetOrder(context.Background(), 1)
+	assert.ErrorIs(t, err, entity.ErrUnauthorized)
+
+	_, err = c.Login(context.Background(), "anna", "wrong")
+	assert.ErrorIs(t, err, entity.ErrUnauthorized)
+	expiresAt, err := c.Login(context.Background(), "anna", "secret123")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	order, err := c.GetOrder(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), order.ID)
+}
+
+func TestOrders(t *testing.T) {
+	ctx := context.Background()
+	table := 2
+	repo := new(entity.MockRepo)
+	repo.On("CreateOrder", entity.Order{TableNumber: 2, UserID: 4, Items: []entity.OrderItem{{DishID: 3, Quantity: 2}}}).Return(nil)
+	repo.On("GetOrders", entity.OrderQuery{ListOptions: entity.ListOptions{Limit: 10, Sort: "-id"}, Status: entity.OrderOpen, TableNumber: &table}).
+		Return([]entity.Order{{Model: gorm.Model{ID: 5}, TableNumber: 2}}, nil)
+	repo.On("GetOrder", uint(5)).Return(entity.Order{
+		Model:       gorm.Model{ID: 5},
+		TableNumber: 2,
+		Version:     2,
+		Items:       []entity.OrderItem{{Model: gorm.Model{ID: 7}, OrderID: 5, DishID: 3, Dish: entity.Dish{Name: "Schnitzel"}, Quantity: 2, Price: 12.5}},
+	}, nil)
+	repo.On("GetOrder", uint(6)).Return(entity.Order{}, entity.WrapRecordNotFoundError("order", 6, gorm.ErrRecordNotFound))
+	repo.On("UpdateOrder", entity.Order{Model: gorm.Model{ID: 5}, TableNumber: 3, Version: 2}).Return(nil)
+	repo.On("UpdateOrder", entity.Order{Model: gorm.Model{ID: 5}, TableNumber: 4, Version: 1}).Return(entity.ErrVersionMismatch)
+	c := newServer(t, repo, nil)
+	login(t, c, repo, entity.RoleWaiter)
+
+	created, err := c.CreateOrder(ctx, dto.OrderRequest{TableNumber: 2, Items: []dto.OrderItemRequest{{DishID: 3, Quantity: 2}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.TableNumber)
+	assert.Equal(t, uint(4), created.UserID)
+
+	orders, err := c.ListOrders(ctx, OrderQuery{Status: entity.OrderOpen, Table: &table, ListOptions: ListOptions{Limit: 10, Sort: "-id"}})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, uint(5), orders[0].ID)
+
+	order, err := c.GetOrder(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []dto.OrderItemResponse{{ID: 7, OrderID: 5, DishID: 3, DishName: "Schnitzel", Quantity: 2, Price: 12.5}}, order.Items)
+	assert.Equal(t, float32(25), order.Total)
+
+	_, err = c.GetOrder(ctx, 6)
+	assert.ErrorIs(t, err, entity.ErrNotFound)
+	assert.True(t, IsStatus(err, http.StatusNotFound))
+
+	require.NoError(t, c.UpdateOrder(ctx, 5, order.Version, dto.OrderRequest{TableNumber: 3}))
+	err = c.UpdateOrder(ctx, 5, 1, dto.OrderRequest{TableNumber: 4})
+	assert.ErrorIs(t, err, entity.ErrPreconditionFailed)
+	assert.True(t, IsStatus(err, http.StatusPreconditionFailed))
+	repo.AssertExpectations(t)
+}
+
+func TestDishes(t *testing.T) {
+	ctx := context.Background()
+	dish := entity.Dish{Name: "Fries", Category: "Sides", Price: 4.5, TaxRate: 19, Translations: []entity.DishTranslation{{Language: "de", Name: "Pommes"}}}
+	repo := new(entity.MockRepo)
+	repo.On("CreateDish", dish).Return(nil)
+	repo.On("SearchDishes", "pommes", 5).Return([]entity.Dish{dish}, nil)
+	repo.On("GetDish", uint(3)).Return(entity.Dish{Model: gorm.Model{ID: 3}, SKU: "S-1", Name: "Fries", Price: 4.5, Version: 4}, nil)
+	repo.On("UpdateDish", entity.Dish{Model: gorm.Model{ID: 3}, Name: "Fries", Version: 4, Translations: []entity.DishTranslation{}}).Return(nil)
+	c := newServer(t, repo, nil)
+	login(t, c, repo, entity.RoleManager)
+
+	created, err := c.CreateDish(ctx, dto.DishRequest{Name: "Fries", Category: "Sides", Price: 4.5, TaxRate: 19, Translations: []dto.Translation{{Language: "de", Name: "Pommes"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []dto.Translation{{Language: "de", Name: "Pommes"}}, created.Translations)
+
+	found, err := c.SearchDishes(ctx, "pommes", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "Fries", found[0].Name)
+
+	require.NoError(t, c.PatchDish(ctx, 3, 4, Patch{"price": 0, "sku": nil}))
+	assert.ErrorIs(t, c.PatchDish(ctx, 3, 3, Patch{"price": 1}), entity.ErrPreconditionFailed)
+
+	_, err = c.CreateDish(ctx, dto.DishRequest{Name: "Fries", Price: -1})
+	assert.ErrorIs(t, err, entity.ErrInvalidData)
+	var invalid entity.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []entity.FieldError{{Field: "price", Rule: entity.RuleMin, Message: "must be at least 0"}}, invalid.Fields)
+}
+
+func TestDiscounts(t *testing.T) {
+	ctx := context.Background()
+	repo := new(entity.MockRepo)
+	repo.On("GetOrder", uint(5)).Return(entity.Order{Model: gorm.Model{ID: 5}}, nil)
+	repo.On("GetDish", uint(3)).Return(entity.Dish{Model: gorm.Model{ID: 3}, Price: 10}, nil)
+	repo.On("CreateDiscount", mock.Anything).Return(nil)
+	repo.On("GetPriceAfterDiscount", uint(5), uint(3)).Return(entity.DiscountDetail{OrderID: 5, DishID: 3, Dish: entity.Dish{Price: 10}, Discount: 10}, nil)
+	c := newServer(t, repo, nil)
+	login(t, c, repo, entity.RoleWaiter)
+
+	discount, err := c.CreateDiscount(ctx, dto.DiscountRequest{OrderID: 5, DishID: 3, Discount: 10})
+	require.NoError(t, err)
+	assert.Equal(t, dto.DiscountResponse{OrderID: 5, DishID: 3, Discount: 10, UserID: 4}, discount)
+
+	// Waiters need an approval for discounts above 20%.
+	_, err = c.CreateDiscount(ctx, dto.DiscountRequest{OrderID: 5, DishID: 3, Discount: 50})
+	assert.ErrorIs(t, err, entity.ErrForbidden)
+
+	price, err := c.GetPriceAfterDiscount(ctx, 5, 3)
+	require.NoError(t, err)
+	assert.Equal(t, dto.PriceAfterDiscountResponse{OrderID: 5, DishID: 3, OriginalPrice: 10, DiscountPrice: 9}, price)
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	unavailable := func(times int32) func(w http.ResponseWriter, r *http.Request) bool {
+		return func(w http.ResponseWriter, r *http.Request) bool {
+			if calls.Add(1) <= times {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return false
+			}
+			return true
+		}
+	}
+	tests := []struct {
+		name   string
+		times  int32
+		call   func(c *Client) error
+		calls  int32
+		status int
+	}{
+		{
+			name:  "get is retried",
+			times: 2,
+			call: func(c *Client) error {
+				_, err := c.GetDish(context.Background(), 3)
+				return err
+			},
+			calls: 3,
+		},
+		{
+			name:  "post is not retried",
+			times: 2,
+			call: func(c *Client) error {
+				_, err := c.CreateDish(context.Background(), dto.DishRequest{Name: "Fries"})
+				return err
+			},
+			calls:  1,
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:  "changes with If-Match are not retried",
+			times: 2,
+			call: func(c *Client) error {
+				return c.DeleteDish(context.Background(), 3, 1)
+			},
+			calls:  1,
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:  "retries give up",
+			times: 10,
+			call: func(c *Client) error {
+				_, err := c.GetDish(context.Background(), 3)
+				return err
+			},
+			calls:  4,
+			status: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			repo := new(entity.MockRepo)
+			repo.On("GetDish", uint(3)).Return(entity.Dish{Model: gorm.Model{ID: 3}}, nil)
+			c := newServer(t, repo, unavailable(tt.times))
+			login(t, c, repo, entity.RoleManager)
+
+			err := tt.call(c)
+			assert.Equal(t, tt.calls, calls.Load())
+			if tt.status == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, IsStatus(err, tt.status), err)
+			}
+		})
+	}
+}
+
+func TestRetriesCanceled(t *testing.T) {
+	c := newServer(t, new(entity.MockRepo), func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetDish(ctx, 3)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+// TestImports keeps the client free of the dependencies of the service: it and dto
+// import nothing but the standard library and dto.
+func TestImports(t *testing.T) {
+	for _, dir := range []string{".", "../dto"} {
+		pkg, err := build.ImportDir(dir, 0)
+		require.NoError(t, err)
+		for _, path := range pkg.Imports {
+			std := !strings.Contains(strings.Split(path, "/")[0], ".") && !strings.HasPrefix(path, "gorestserviceagain/")
+			assert.True(t, std || path == "gorestserviceagain/dto", "%s imports %s", pkg.Name, path)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"gorestserviceagain/dto"
+)
+
+// CreateDiscount adds a discount in percent on a dish of an order. Discounts above 20%
+// need a manager, or their approval with WithApprover.
+func (c *Client) CreateDiscount(ctx context.Context, discount dto.DiscountRequest) (dto.DiscountResponse, error) {
+	return post[dto.DiscountResponse](ctx, c, "/discountPrice", discount)
+}
+
+// UpdateDiscount changes the discount on a dish of an order, see CreateDiscount. version
+// is the one of the discount in the order.
+func (c *Client) UpdateDiscount(ctx context.Context, orderId uint, dishId uint, version uint, discount float32) error {
+	return put(ctx, c, fmt.Sprintf("/orders/%d/dishes/%d", orderId, dishId), version, dto.DiscountRequest{Discount: discount})
+}
+
+func (c *Client) GetPriceAfterDiscount(ctx context.Context, orderId uint, dishId uint) (dto.PriceAfterDiscountResponse, error) {
+	return get[dto.PriceAfterDiscountResponse](ctx, c, fmt.Sprintf("/%d/dishes/%d", orderId, dishId), nil)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"gorestserviceagain/dto"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DishQuery filters and pages ListDishes, the zero values are not sent.
+type DishQuery struct {
+	Name     string
+	Category string
+	PriceMin *float32
+	PriceMax *float32
+	ListOptions
+}
+
+func (q DishQuery) values() url.Values {
+	v := q.ListOptions.values()
+	if q.Name != "" {
+		v.Set("name", q.Name)
+	}
+	if q.Category != "" {
+		v.Set("category", q.Category)
+	}
+	if q.PriceMin != nil {
+		v.Set("priceMin", strconv.FormatFloat(float64(*q.PriceMin), 'f', -1, 32))
+	}
+	if q.PriceMax != nil {
+		v.Set("priceMax", strconv.FormatFloat(float64(*q.PriceMax), 'f', -1, 32))
+	}
+	return v
+}
+
+func (c *Client) CreateDish(ctx context.Context, dish dto.DishRequest) (dto.DishResponse, error) {
+	return post[dto.DishResponse](ctx, c, "/dishes/", dish)
+}
+
+func (c *Client) ListDishes(ctx context.Context, query DishQuery) ([]dto.DishResponse, error) {
+	return get[[]dto.DishResponse](ctx, c, "/dishes/", query.values())
+}
+
+// SearchDishes finds the dishes matching text in their names, descriptions and translations,
+// the best matches first. A limit of 0 uses the default of the API.
+func (c *Client) SearchDishes(ctx context.Context, text string, limit int) ([]dto.DishResponse, error) {
+	v := url.Values{"q": {text}}
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	return get[[]dto.DishResponse](ctx, c, "/dishes/search", v)
+}
+
+func (c *Client) GetDish(ctx context.Context, id uint) (dto.DishResponse, error) {
+	return get[dto.DishResponse](ctx, c, fmt.Sprintf("/dishes/%d", id), nil)
+}
+
+// UpdateDish replaces a dish and its translations, fields left out are zero. It fails if
+// the dish is no longer at version.
+func (c *Client) UpdateDish(ctx context.Context, id uint, version uint, dish dto.DishRequest) error {
+	return put(ctx, c, fmt.Sprintf("/dishes/%d", id), version, dish)
+}
+
+// PatchDish changes the fields of a dish in p, see Patch and UpdateDish.
+func (c *Client) PatchDish(ctx context.Context, id uint, version uint, p Patch) error {
+	return patchWith(ctx, c, fmt.Sprintf("/dishes/%d", id), version, p)
+}
+
+func (c *Client) DeleteDish(ctx context.Context, id uint, version uint) error {
+	return del(ctx, c, fmt.Sprintf("/dishes/%d", id), version)
+}
+
+func (c *Client) GetRecipe(ctx context.Context, id uint) ([]dto.RecipeItemResponse, error) {
+	return get[[]dto.RecipeItemResponse](ctx, c, fmt.Sprintf("/dishes/%d/recipe", id), nil)
+}
+
+// SetRecipe replaces the recipe of a dish. The returned recipe only has the ids of the ingredients.
+func (c *Client) SetRecipe(ctx context.Context, id uint, recipe []dto.RecipeItemRequest) ([]dto.RecipeItemResponse, error) {
+	var out []dto.RecipeItemResponse
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/v2/dishes/%d/recipe", id), nil, nil, recipe, &out)
+	return out, err
+}
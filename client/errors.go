@@ -0,0 +1,52 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorestserviceagain/dto"
+	"io"
+	"net/http"
+)
+
+// Error is a problem answered by the API. errors.Is matches it with the error kind
+// of the problem, like dto.ErrNotFound, and errors.As finds the dto.ValidationError
+// of invalid fields.
+type Error struct {
+	dto.Problem
+}
+
+// newError reads the problem of a response. Responses which are not problems, e.g. of a
+// proxy, keep their status and have the body as detail.
+func newError(res *http.Response) *Error {
+	body, _ := io.ReadAll(res.Body)
+	var e Error
+	if err := json.Unmarshal(body, &e.Problem); err != nil || e.Status == 0 {
+		e.Problem = dto.Problem{Type: "about:blank", Title: http.StatusText(res.StatusCode), Status: res.StatusCode, Detail: string(body)}
+	}
+	return &e
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%d %s", e.Status, e.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Title, e.Detail)
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if kind := e.Kind(); kind != nil {
+		errs = append(errs, kind)
+	}
+	if len(e.Errors) > 0 {
+		errs = append(errs, dto.ValidationError{Fields: e.Errors})
+	}
+	return errs
+}
+
+// IsStatus reports whether err is a problem with the status.
+func IsStatus(err error, status int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Status == status
+}
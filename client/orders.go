@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"gorestserviceagain/dto"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// OrderQuery filters and pages ListOrders, the zero values are not sent.
+type OrderQuery struct {
+	Status      string
+	Table       *int
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	ListOptions
+}
+
+// ListOptions pages a list. Lists sorted by id are paged with the id of the last row as
+// Cursor, other lists with an Offset.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Cursor uint
+	Sort   string
+}
+
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		v.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Cursor > 0 {
+		v.Set("cursor", strconv.FormatUint(uint64(o.Cursor), 10))
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	return v
+}
+
+func (q OrderQuery) values() url.Values {
+	v := q.ListOptions.values()
+	if q.Status != "" {
+		v.Set("status", q.Status)
+	}
+	if q.Table != nil {
+		v.Set("table", strconv.Itoa(*q.Table))
+	}
+	if !q.CreatedFrom.IsZero() {
+		v.Set("createdFrom", q.CreatedFrom.Format(time.RFC3339))
+	}
+	if !q.CreatedTo.IsZero() {
+		v.Set("createdTo", q.CreatedTo.Format(time.RFC3339))
+	}
+	return v
+}
+
+func (c *Client) CreateOrder(ctx context.Context, order dto.OrderRequest) (dto.OrderResponse, error) {
+	return post[dto.OrderResponse](ctx, c, "/orders/", order)
+}
+
+func (c *Client) ListOrders(ctx context.Context, query OrderQuery) ([]dto.OrderResponse, error) {
+	return get[[]dto.OrderResponse](ctx, c, "/orders/", query.values())
+}
+
+func (c *Client) GetOrder(ctx context.Context, id uint) (dto.OrderResponse, error) {
+	return get[dto.OrderResponse](ctx, c, fmt.Sprintf("/orders/%d", id), nil)
+}
+
+// UpdateOrder replaces the table number and final price of an order, fields left out are
+// zero. The items are ignored, they are changed by AddOrderItems, VoidOrderItem and CompOrderItem.
+// It fails with dto.ErrPreconditionFailed if the order is no longer at version.
+func (c *Client) UpdateOrder(ctx context.Context, id uint, version uint, order dto.OrderRequest) error {
+	return put(ctx, c, fmt.Sprintf("/orders/%d", id), version, order)
+}
+
+// PatchOrder changes the table number or final price of an order, see Patch and UpdateOrder.
+func (c *Client) PatchOrder(ctx context.Context, id uint, version uint, p Patch) error {
+	return patchWith(ctx, c, fmt.Sprintf("/orders/%d", id), version, p)
+}
+
+// DeleteOrder voids an order, which needs the order.void permission, see UpdateOrder.
+func (c *Client) DeleteOrder(ctx context.Context, id uint, version uint) error {
+	return del(ctx, c, fmt.Sprintf("/orders/%d", id), version)
+}
+
+func (c *Client) AddOrderItems(ctx context.Context, id uint, items []dto.OrderItemRequest) ([]dto.OrderItemResponse, error) {
+	return post[[]dto.OrderItemResponse](ctx, c, fmt.Sprintf("/orders/%d/items", id), items)
+}
+
+func (c *Client) VoidOrderItem(ctx context.Context, id uint, itemId uint, adjustment dto.ItemAdjustmentRequest) (dto.OrderItemResponse, error) {
+	return post[dto.OrderItemResponse](ctx, c, fmt.Sprintf("/orders/%d/items/%d/void", id, itemId), adjustment)
+}
+
+func (c *Client) CompOrderItem(ctx context.Context, id uint, itemId uint, adjustment dto.ItemAdjustmentRequest) (dto.OrderItemResponse, error) {
+	return post[dto.OrderItemResponse](ctx, c, fmt.Sprintf("/orders/%d/items/%d/comp", id, itemId), adjustment)
+}
+
+func (c *Client) AddPayment(ctx context.Context, id uint, payment dto.PaymentRequest) (dto.PaymentResponse, error) {
+	return post[dto.PaymentResponse](ctx, c, fmt.Sprintf("/orders/%d/payments", id), payment)
+}
@@ -3,28 +3,87 @@ package main
 import (
 	"errors"
 	"fmt"
+	"gorestserviceagain/printing"
+	"gorestserviceagain/receipt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type config struct {
-	DSN  string
-	Port string
+	DSN        string
+	Port       string
+	Restaurant receipt.Restaurant
+	Printers   []printing.Printer
+	// FiscalSigner selects the TSE, "software" is the stand-in for development.
+	FiscalSigner string
+	// AuthSecret signs the login tokens, they stay valid for TokenTTL.
+	AuthSecret string
+	TokenTTL   time.Duration
+	// AdminUsername and AdminPassword create the first admin if there are no users yet.
+	AdminUsername string
+	AdminPassword string
+	// V1Sunset is the date the deprecated v1 routes are turned off, it is announced in their responses.
+	V1Sunset time.Time
 }
 
 var ErrDbDsnNotSet = errors.New("could not find DB in env vars")
+var ErrAuthSecretNotSet = errors.New("could not find AUTH_SECRET in env vars")
+var ErrAuthSecretWeak = fmt.Errorf("AUTH_SECRET must be a random secret of at least %d characters", minAuthSecretLength)
+
+// minAuthSecretLength is the length of a HS256 key, shorter secrets can be guessed.
+const minAuthSecretLength = 32
 
 func configFromEnv() (cfg config, err error) {
+	// The admin password is only taken from the environment, never from the .env file.
+	cfg.AdminPassword = os.Getenv("ADMIN_PASSWORD")
 	err = godotenv.Load()
 	if err != nil {
-		fmt.Errorf("Loading .env file: %w", err)
+		fmt.Printf("Loading .env file: %v\n", err)
+		err = nil
 	}
 	cfg.DSN = os.Getenv("DSN")
 	cfg.Port = os.Getenv("PORT")
+	cfg.Restaurant = receipt.Restaurant{
+		Name:     os.Getenv("RESTAURANT_NAME"),
+		Address:  os.Getenv("RESTAURANT_ADDRESS"),
+		Phone:    os.Getenv("RESTAURANT_PHONE"),
+		VATID:    os.Getenv("RESTAURANT_VAT_ID"),
+		Footer:   os.Getenv("RESTAURANT_FOOTER"),
+		Currency: os.Getenv("RESTAURANT_CURRENCY"),
+	}
+	cfg.FiscalSigner = os.Getenv("FISCAL_SIGNER")
+	cfg.AuthSecret = os.Getenv("AUTH_SECRET")
+	cfg.TokenTTL = 12 * time.Hour
+	if v := os.Getenv("AUTH_TOKEN_TTL"); v != "" {
+		cfg.TokenTTL, err = time.ParseDuration(v)
+		if err != nil {
+			return
+		}
+	}
+	cfg.AdminUsername = os.Getenv("ADMIN_USERNAME")
+	if v := os.Getenv("V1_SUNSET"); v != "" {
+		cfg.V1Sunset, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return
+		}
+	}
+	cfg.Printers, err = printing.ParsePrinters(os.Getenv("PRINTERS"))
+	if err != nil {
+		return
+	}
 	if cfg.DSN == "" {
 		err = ErrDbDsnNotSet
 		return
 	}
+	if cfg.AuthSecret == "" {
+		err = ErrAuthSecretNotSet
+		return
+	}
+	if len(cfg.AuthSecret) < minAuthSecretLength || cfg.AuthSecret == "change-me" {
+		err = ErrAuthSecretWeak
+		return
+	}
 	return
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigAuthSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		err    error
+	}{
+		{name: "missing", secret: "", err: ErrAuthSecretNotSet},
+		{name: "placeholder", secret: "change-me", err: ErrAuthSecretWeak},
+		{name: "short", secret: strings.Repeat("x", minAuthSecretLength-1), err: ErrAuthSecretWeak},
+		{name: "long enough", secret: strings.Repeat("x", minAuthSecretLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DSN", "db.sqlite")
+			t.Setenv("AUTH_SECRET", tt.secret)
+
+			cfg, err := configFromEnv()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.secret, cfg.AuthSecret)
+		})
+	}
+}
+
+func TestConfigAdminPasswordFromEnvironment(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(`ADMIN_PASSWORD = "admin"`), 0o600))
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("DSN", "db.sqlite")
+	t.Setenv("AUTH_SECRET", strings.Repeat("x", minAuthSecretLength))
+	t.Setenv("ADMIN_PASSWORD", "")
+	os.Unsetenv("ADMIN_PASSWORD")
+
+	cfg, err := configFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.AdminPassword, "the password of the .env file is not used")
+
+	t.Setenv("ADMIN_PASSWORD", "s3cret-from-env")
+	cfg, err = configFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret-from-env", cfg.AdminPassword)
+}
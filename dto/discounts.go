@@ -0,0 +1,24 @@
+package dto
+
+// DiscountRequest is the v2 body of a discount in percent on a dish of an order. On
+// updates the order and the dish are given by the route.
+type DiscountRequest struct {
+	OrderID  uint    `json:"orderId"`
+	DishID   uint    `json:"dishId"`
+	Discount float32 `json:"discount"`
+}
+
+type DiscountResponse struct {
+	OrderID  uint    `json:"orderId"`
+	DishID   uint    `json:"dishId"`
+	Discount float32 `json:"discount"`
+	UserID   uint    `json:"userId,omitempty"`
+	Version  uint    `json:"version"`
+}
+
+type PriceAfterDiscountResponse struct {
+	OrderID       uint    `json:"orderId"`
+	DishID        uint    `json:"dishId"`
+	OriginalPrice float32 `json:"originalPrice"`
+	DiscountPrice float32 `json:"discountPrice"`
+}
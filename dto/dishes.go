@@ -0,0 +1,103 @@
+package dto
+
+import "time"
+
+type DishRequest struct {
+	SKU          string        `json:"sku"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Category     string        `json:"category"`
+	Price        float32       `json:"price"`
+	TaxRate      float32       `json:"taxRate"`
+	SoldOut      bool          `json:"soldOut"`
+	Translations []Translation `json:"translations,omitempty"`
+}
+
+// Translation is the name and description of a dish in another language, in requests
+// and responses alike as it has no fields set by the server.
+type Translation struct {
+	Language    string `json:"language"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type DishResponse struct {
+	ID           uint          `json:"id"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+	SKU          string        `json:"sku"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Category     string        `json:"category"`
+	Price        float32       `json:"price"`
+	TaxRate      float32       `json:"taxRate"`
+	SoldOut      bool          `json:"soldOut"`
+	Version      uint          `json:"version"`
+	Translations []Translation `json:"translations"`
+}
+
+// RecipeItemRequest is the quantity of an ingredient in a dish, the dish is given by the route.
+type RecipeItemRequest struct {
+	IngredientID uint    `json:"ingredientId"`
+	Quantity     float32 `json:"quantity"`
+}
+
+// RecipeItemResponse has the name, unit and cost of the ingredient when it was loaded.
+type RecipeItemResponse struct {
+	DishID       uint    `json:"dishId"`
+	IngredientID uint    `json:"ingredientId"`
+	Ingredient   string  `json:"ingredient,omitempty"`
+	Unit         string  `json:"unit,omitempty"`
+	UnitCost     float32 `json:"unitCost"`
+	Quantity     float32 `json:"quantity"`
+}
+
+type ImportRowResponse struct {
+	Row    int      `json:"row"`
+	SKU    string   `json:"sku"`
+	Action string   `json:"action"`
+	DishID uint     `json:"dishId,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type MarginReportResponse struct {
+	From          time.Time                `json:"from"`
+	To            time.Time                `json:"to"`
+	Dishes        []DishMarginResponse     `json:"dishes"`
+	Categories    []CategoryMarginResponse `json:"categories"`
+	Sales         []SalesMarginResponse    `json:"sales"`
+	Revenue       float32                  `json:"revenue"`
+	Cost          float32                  `json:"cost"`
+	Margin        float32                  `json:"margin"`
+	MarginPercent float32                  `json:"marginPercent"`
+}
+
+type DishMarginResponse struct {
+	DishID        uint    `json:"dishId"`
+	Name          string  `json:"name"`
+	Category      string  `json:"category"`
+	Price         float32 `json:"price"`
+	Cost          float32 `json:"cost"`
+	Margin        float32 `json:"margin"`
+	MarginPercent float32 `json:"marginPercent"`
+}
+
+type CategoryMarginResponse struct {
+	Category      string  `json:"category"`
+	Dishes        int     `json:"dishes"`
+	Price         float32 `json:"price"`
+	Cost          float32 `json:"cost"`
+	Margin        float32 `json:"margin"`
+	MarginPercent float32 `json:"marginPercent"`
+}
+
+type SalesMarginResponse struct {
+	DishID        uint    `json:"dishId"`
+	Name          string  `json:"name"`
+	Category      string  `json:"category"`
+	Quantity      int     `json:"quantity"`
+	Revenue       float32 `json:"revenue"`
+	Cost          float32 `json:"cost"`
+	Margin        float32 `json:"margin"`
+	MarginPercent float32 `json:"marginPercent"`
+}
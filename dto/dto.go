@@ -0,0 +1,16 @@
+// Package dto has what the API and its clients exchange: the v2 request and response
+// bodies, the problem details of errors with their kinds, and the headers and content
+// types. It has no dependencies, so Go clients of the API do not link the service.
+package dto
+
+// The headers of the second user who approves an action the logged in user may not do alone.
+const (
+	ApproverHeader    = "X-Approver"
+	ApproverPinHeader = "X-Approver-Pin"
+)
+
+// The content types of the patch documents of PATCH requests.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
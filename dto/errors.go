@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of errors. Every error of the repos and handlers that a client can cause is of
+// one of these kinds, which errors.Is tells, e.g. errors.Is(err, ErrConflict). Other
+// errors are internal errors.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrInvalidData     = errors.New("unsupported data")
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUnavailable     = errors.New("unavailable")
+	// ErrPreconditionFailed is a change of a resource based on an outdated version of it,
+	// ErrPreconditionRequired one which does not tell which version it is based on.
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// Rules a field can break, for clients to react on without parsing the message.
+const (
+	RuleRequired = "required"
+	RulePositive = "positive"
+	RuleMin      = "min"
+	RuleRange    = "range"
+	RuleOneOf    = "oneOf"
+	RuleFormat   = "format"
+)
+
+// FieldError describes why one field of a request is invalid. Field is the path of the
+// field in the request body, e.g. "Items[1].Quantity".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request. It is an ErrInvalidData.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidData, strings.Join(messages, ", "))
+}
+
+func (e ValidationError) Is(err error) bool {
+	return err == ErrInvalidData
+}
@@ -0,0 +1,88 @@
+package dto
+
+import "time"
+
+// OrderRequest is the v2 body to create or update an order. The shift and the user are
+// taken from the login.
+type OrderRequest struct {
+	TableNumber int                `json:"tableNumber"`
+	FinalPrice  float32            `json:"finalPrice"`
+	Items       []OrderItemRequest `json:"items,omitempty"`
+}
+
+// OrderItemRequest is a dish ordered in v2. Without a price the dish price is charged,
+// a price of its own needs the price.override permission or an approval.
+type OrderItemRequest struct {
+	DishID   uint    `json:"dishId"`
+	Quantity int     `json:"quantity"`
+	Price    float32 `json:"price,omitempty"`
+}
+
+// ItemAdjustmentRequest is the v2 body to void or comp an order item, the status is
+// given by the route.
+type ItemAdjustmentRequest struct {
+	Reason   string `json:"reason"`
+	Waste    bool   `json:"waste,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+type PaymentRequest struct {
+	Method string  `json:"method"`
+	Amount float32 `json:"amount"`
+}
+
+type OrderResponse struct {
+	ID          uint                `json:"id"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+	TableNumber int                 `json:"tableNumber"`
+	FinalPrice  float32             `json:"finalPrice"`
+	Total       float32             `json:"total"`
+	Paid        float32             `json:"paid"`
+	ShiftID     uint                `json:"shiftId,omitempty"`
+	UserID      uint                `json:"userId,omitempty"`
+	Version     uint                `json:"version"`
+	Items       []OrderItemResponse `json:"items"`
+	Discounts   []DiscountResponse  `json:"discounts"`
+	Payments    []PaymentResponse   `json:"payments"`
+	Signatures  []SignatureResponse `json:"signatures,omitempty"`
+}
+
+// OrderItemResponse is an item of an order in v2. The dish name is only set when the
+// dish was loaded with the item.
+type OrderItemResponse struct {
+	ID         uint    `json:"id"`
+	OrderID    uint    `json:"orderId"`
+	DishID     uint    `json:"dishId"`
+	DishName   string  `json:"dishName,omitempty"`
+	Quantity   int     `json:"quantity"`
+	Price      float32 `json:"price"`
+	TaxRate    float32 `json:"taxRate"`
+	Status     string  `json:"status,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Waste      bool    `json:"waste,omitempty"`
+	AdjustedBy uint    `json:"adjustedBy,omitempty"`
+}
+
+type PaymentResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	OrderID   uint      `json:"orderId"`
+	UserID    uint      `json:"userId,omitempty"`
+	Method    string    `json:"method"`
+	Amount    float32   `json:"amount"`
+}
+
+// SignatureResponse is the fiscal signature of an order in v2.
+type SignatureResponse struct {
+	Kind              string    `json:"kind"`
+	SerialNumber      string    `json:"serialNumber"`
+	TransactionNumber uint64    `json:"transactionNumber"`
+	SignatureCounter  uint64    `json:"signatureCounter"`
+	Algorithm         string    `json:"algorithm"`
+	ProcessType       string    `json:"processType"`
+	ProcessData       string    `json:"processData"`
+	Signature         string    `json:"signature"`
+	StartTime         time.Time `json:"startTime"`
+	EndTime           time.Time `json:"endTime"`
+}
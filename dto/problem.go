@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"errors"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response, the problem details of RFC 7807.
+// Errors lists the invalid fields of a request.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// problemKinds maps the kinds of errors to their problem type and status.
+// The first matching kind wins, e.g. an invalid query is a bad request.
+var problemKinds = []struct {
+	kind   error
+	slug   string
+	status int
+}{
+	{ErrBadRequest, "bad-request", http.StatusBadRequest},
+	{ErrUnauthorized, "unauthorized", http.StatusUnauthorized},
+	{ErrForbidden, "forbidden", http.StatusForbidden},
+	{ErrTooManyRequests, "too-many-requests", http.StatusTooManyRequests},
+	{ErrNotFound, "not-found", http.StatusNotFound},
+	{ErrConflict, "conflict", http.StatusConflict},
+	{ErrInvalidData, "validation", http.StatusUnprocessableEntity},
+	{ErrPreconditionFailed, "precondition-failed", http.StatusPreconditionFailed},
+	{ErrPreconditionRequired, "precondition-required", http.StatusPreconditionRequired},
+	{ErrUnavailable, "unavailable", http.StatusServiceUnavailable},
+}
+
+// NewProblem maps an error to its problem details. Internal errors are not described,
+// they are only logged.
+func NewProblem(err error) Problem {
+	for _, k := range problemKinds {
+		if !errors.Is(err, k.kind) {
+			continue
+		}
+		p := Problem{Type: "/problems/" + k.slug, Title: http.StatusText(k.status), Status: k.status, Detail: err.Error()}
+		var invalid ValidationError
+		if errors.As(err, &invalid) {
+			p.Errors = invalid.Fields
+		}
+		return p
+	}
+	return Problem{Type: "about:blank", Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError, Detail: "Unknown error"}
+}
+
+// Kind returns the error kind of a problem, which NewProblem made it from. It is nil
+// for internal errors and problems of unknown types.
+func (p Problem) Kind() error {
+	for _, k := range problemKinds {
+		if p.Type == "/problems/"+k.slug {
+			return k.kind
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditImport = "import"
+)
+
+// AuditEntry records one change of an entity. Entries are only ever added, never
+// changed or deleted, so the model has no UpdatedAt and DeletedAt.
+type AuditEntry struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	ActorID    uint
+	ApproverID uint
+	RequestID  string
+	Action     string
+	Entity     string `gorm:"index:idx_audit_entity"`
+	EntityID   string `gorm:"index:idx_audit_entity"`
+	Before     string
+	After      string
+	Diff       string
+}
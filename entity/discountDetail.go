@@ -6,4 +6,13 @@ type DiscountDetail struct {
 	DishID   uint
 	Dish     Dish
 	Discount float32
+	UserID   uint
+	// Version counts the updates of the discount, see Order.Version.
+	Version uint `gorm:"not null;default:1"`
+}
+
+func (d DiscountDetail) Validate() error {
+	var v Validator
+	v.Range("Discount", float64(d.Discount), 0, 100)
+	return v.Err()
 }
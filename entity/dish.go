@@ -1,9 +1,68 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// TaxRates are the VAT rates a dish can have, in the order of the DSFinV-K process data:
+// normal, reduced, average rates for agriculture and forestry, and tax free.
+var TaxRates = []float32{19, 7, 10.7, 5.5, 0}
+
+// KnownTaxRate reports whether the rate is one of TaxRates.
+func KnownTaxRate(rate float32) bool {
+	return slices.Contains(TaxRates, rate)
+}
 
 type Dish struct {
 	gorm.Model
-	Name  string
-	Price float32
+	SKU         string `gorm:"index"`
+	Name        string
+	Description string
+	Category    string
+	Price       float32
+	TaxRate     float32
+	// SoldOut is set while an ingredient of the recipe is low on stock, updates keep it.
+	SoldOut bool
+	// Version counts the updates of the dish, see Order.Version.
+	Version      uint `gorm:"not null;default:1"`
+	Translations []DishTranslation
+}
+
+// Validate checks a dish sent by a client. Translations need their language.
+func (d Dish) Validate() error {
+	var v Validator
+	v.Required("Name", d.Name)
+	v.Min("Price", float64(d.Price), 0)
+	v.Check(KnownTaxRate(d.TaxRate), "TaxRate", RuleOneOf, fmt.Sprintf("must be one of %v", TaxRates))
+	for i, t := range d.Translations {
+		v.Required(fmt.Sprintf("Translations[%d].Language", i), t.Language)
+	}
+	return v.Err()
+}
+
+// DishTranslation is the name and description of a dish in another language, e.g. "en".
+type DishTranslation struct {
+	ID          uint
+	DishID      uint   `gorm:"uniqueIndex:idx_dish_language"`
+	Language    string `gorm:"uniqueIndex:idx_dish_language"`
+	Name        string
+	Description string
+}
+
+const (
+	ImportCreate  = "create"
+	ImportUpdate  = "update"
+	ImportInvalid = "invalid"
+)
+
+// ImportRow reports what a menu import did, or would do in a dry run, with one row of the file.
+type ImportRow struct {
+	Row    int
+	SKU    string
+	Action string
+	DishID uint
+	Errors []string
 }
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/dto"
+)
+
+// Kinds of errors. Every error of the repos and handlers that a client can cause is of
+// one of these kinds, which errors.Is tells, e.g. errors.Is(err, ErrConflict). Other
+// errors are internal errors. They are the kinds of dto, which clients of the API match.
+var (
+	ErrNotFound             = dto.ErrNotFound
+	ErrConflict             = dto.ErrConflict
+	ErrInvalidData          = dto.ErrInvalidData
+	ErrBadRequest           = dto.ErrBadRequest
+	ErrUnauthorized         = dto.ErrUnauthorized
+	ErrForbidden            = dto.ErrForbidden
+	ErrTooManyRequests      = dto.ErrTooManyRequests
+	ErrUnavailable          = dto.ErrUnavailable
+	ErrPreconditionFailed   = dto.ErrPreconditionFailed
+	ErrPreconditionRequired = dto.ErrPreconditionRequired
+)
+
+// kindError is an error of one of the kinds.
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Is(err error) bool {
+	return err == e.kind
+}
+
+// NewError returns an error of the kind, e.g. NewError(ErrConflict, "dish is sold out").
+func NewError(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+type RecordNotFoundError struct {
+	ID    string
+	Kind  string
+	Inner error
+}
+
+func (e RecordNotFoundError) Error() string {
+	return fmt.Sprintf("%v with id %v not found", e.Kind, e.ID)
+}
+
+func (e RecordNotFoundError) Unwarp() error {
+	return e.Inner
+}
+
+func (e RecordNotFoundError) Is(err error) bool {
+	return err == ErrRecordNotFound || err == ErrNotFound
+}
+
+func WrapRecordNotFoundError(kind string, id any, inner error) error {
+	return RecordNotFoundError{
+		ID:    fmt.Sprint(id),
+		Kind:  kind,
+		Inner: inner,
+	}
+}
+
+var ErrDBNotConnected = errors.New("could not connect to DB")
+var ErrRecordNotFound = NewError(ErrNotFound, "record not found")
+var ErrJson = NewError(ErrBadRequest, "can not convert object to JSON")
+var ErrEntityNotFound = NewError(ErrNotFound, "entity not found")
+var ErrDishSoldOut = NewError(ErrConflict, "dish is sold out")
+
+// ErrVersionMismatch is an update or delete of a record which was changed since the client
+// read it, so its version is not the one the client sent.
+var ErrVersionMismatch = NewError(ErrPreconditionFailed, "the record was changed since it was read")
+var ErrShiftClosed = NewError(ErrConflict, "shift is closed, its orders can not be changed anymore")
+var ErrShiftOpen = NewError(ErrConflict, "another shift is still open")
+var ErrNoShiftOpen = NewError(ErrConflict, "no shift is open, orders can only be taken in an open shift")
+var ErrPurchaseOrderReceived = NewError(ErrConflict, "purchase order has already been received")
+var ErrUsernameTaken = NewError(ErrConflict, "username is already taken")
+var ErrClockedIn = NewError(ErrConflict, "already clocked in")
+var ErrNotClockedIn = NewError(ErrConflict, "not clocked in")
+var ErrItemAdjusted = NewError(ErrConflict, "order item is already voided or comped")
+var ErrReferenceNotFound = NewError(ErrInvalidData, "a record it refers to does not exist")
+var ErrDuplicateKey = NewError(ErrConflict, "a record with the same key exists already")
+var ErrDiscountExists = NewError(ErrConflict, "the dish of the order already has a discount")
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+const (
+	ExportItem    = "item"
+	ExportPayment = "payment"
+)
+
+// OrderExportRow is one line of the order export. Item rows describe an order item with its
+// discount and the amount charged for it, payment rows one payment made for the order.
+type OrderExportRow struct {
+	RecordType    string
+	OrderID       uint
+	CreatedAt     time.Time
+	TableNumber   int
+	ShiftID       uint
+	DishID        uint
+	DishName      string
+	Quantity      int
+	Price         float32
+	TaxRate       float32
+	Discount      float32
+	Amount        float32
+	PaymentMethod string
+}
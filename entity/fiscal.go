@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FiscalPaid   = "paid"
+	FiscalVoided = "voided"
+)
+
+// FiscalTransaction is the data handed to the technical security device (TSE) for signing.
+type FiscalTransaction struct {
+	OrderID     uint
+	Kind        string
+	ProcessType string
+	ProcessData string
+	StartTime   time.Time
+}
+
+// FiscalSignature is the TSE signature of a paid or voided order, stored with the order.
+type FiscalSignature struct {
+	gorm.Model
+	OrderID           uint
+	Kind              string
+	SerialNumber      string
+	TransactionNumber uint64
+	SignatureCounter  uint64
+	Algorithm         string
+	ProcessType       string
+	ProcessData       string
+	Signature         string
+	StartTime         time.Time
+	EndTime           time.Time
+}
+
+// FiscalSigner signs transactions with a technical security device as required by the KassenSichV.
+type FiscalSigner interface {
+	Sign(tx FiscalTransaction) (FiscalSignature, error)
+}
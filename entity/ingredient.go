@@ -0,0 +1,98 @@
+package entity
+
+import "gorm.io/gorm"
+
+type Ingredient struct {
+	gorm.Model
+	Name              string
+	Unit              string
+	Stock             float32
+	LowStockThreshold float32
+	ParLevel          float32
+	UnitCost          float32
+	SupplierID        uint
+}
+
+// Validate checks the fields of an update, which only changes the fields it sets. See
+// ValidateNew for new ingredients.
+func (i Ingredient) Validate() error {
+	var v Validator
+	v.Min("Stock", float64(i.Stock), 0)
+	v.Min("LowStockThreshold", float64(i.LowStockThreshold), 0)
+	v.Min("ParLevel", float64(i.ParLevel), 0)
+	v.Min("UnitCost", float64(i.UnitCost), 0)
+	return v.Err()
+}
+
+// ValidateNew checks a new ingredient, which needs a name.
+func (i Ingredient) ValidateNew() error {
+	var v Validator
+	v.Required("Name", i.Name)
+	v.Nested("", i.Validate())
+	return v.Err()
+}
+
+func (i Ingredient) IsLowStock() bool {
+	return i.Stock <= i.LowStockThreshold
+}
+
+// RecipeItem is the quantity of one ingredient used to prepare one portion of a dish.
+type RecipeItem struct {
+	DishID       uint `gorm:"primaryKey"`
+	IngredientID uint `gorm:"primaryKey"`
+	Ingredient   Ingredient
+	Quantity     float32
+}
+
+func (r RecipeItem) Validate() error {
+	var v Validator
+	v.RequiredID("IngredientID", r.IngredientID)
+	v.Positive("Quantity", float64(r.Quantity))
+	return v.Err()
+}
+
+type InventoryItem struct {
+	IngredientID      uint
+	Name              string
+	Unit              string
+	Stock             float32
+	LowStockThreshold float32
+	LowStock          bool
+	DishIDs           []uint
+}
+
+// StockUsage sums up how much of every ingredient the given order items consume,
+// keyed by ingredient id.
+func StockUsage(items []OrderItem, recipe []RecipeItem) map[uint]float32 {
+	usage := make(map[uint]float32)
+	for _, item := range items {
+		for _, r := range recipe {
+			if r.DishID == item.DishID {
+				usage[r.IngredientID] += r.Quantity * float32(item.Quantity)
+			}
+		}
+	}
+	return usage
+}
+
+// NewInventory builds the inventory report from the ingredients and every recipe using them.
+func NewInventory(ingredients []Ingredient, recipe []RecipeItem) []InventoryItem {
+	inventory := make([]InventoryItem, 0, len(ingredients))
+	for _, i := range ingredients {
+		item := InventoryItem{
+			IngredientID:      i.ID,
+			Name:              i.Name,
+			Unit:              i.Unit,
+			Stock:             i.Stock,
+			LowStockThreshold: i.LowStockThreshold,
+			LowStock:          i.IsLowStock(),
+		}
+		for _, r := range recipe {
+			if r.IngredientID == i.ID {
+				item.DishIDs = append(item.DishIDs, r.DishID)
+			}
+		}
+		inventory = append(inventory, item)
+	}
+	return inventory
+}
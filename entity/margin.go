@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+type DishMargin struct {
+	DishID        uint
+	Name          string
+	Category      string
+	Price         float32
+	Cost          float32
+	Margin        float32
+	MarginPercent float32
+}
+
+type CategoryMargin struct {
+	Category      string
+	Dishes        int
+	Price         float32
+	Cost          float32
+	Margin        float32
+	MarginPercent float32
+}
+
+// DishSales is the number of portions sold of a dish and the revenue after discounts.
+type DishSales struct {
+	DishID   uint
+	Quantity int
+	Revenue  float32
+}
+
+type SalesMargin struct {
+	DishID        uint
+	Name          string
+	Category      string
+	Quantity      int
+	Revenue       float32
+	Cost          float32
+	Margin        float32
+	MarginPercent float32
+}
+
+type MarginReport struct {
+	From          time.Time
+	To            time.Time
+	Dishes        []DishMargin
+	Categories    []CategoryMargin
+	Sales         []SalesMargin
+	Revenue       float32
+	Cost          float32
+	Margin        float32
+	MarginPercent float32
+}
+
+func marginPercent(margin float32, price float32) float32 {
+	if price == 0 {
+		return 0
+	}
+	return margin / price * 100
+}
+
+// RecipeCost is the cost of one portion of every dish, based on the latest ingredient costs.
+// The recipe items must have their Ingredient loaded.
+func RecipeCost(recipe []RecipeItem) map[uint]float32 {
+	cost := make(map[uint]float32)
+	for _, r := range recipe {
+		cost[r.DishID] += r.Quantity * r.Ingredient.UnitCost
+	}
+	return cost
+}
+
+// NewMarginReport computes the theoretical margin of every dish and category from the recipe
+// costs, and the margin actually made with the given sales.
+func NewMarginReport(dishes []Dish, recipe []RecipeItem, sales []DishSales, from time.Time, to time.Time) MarginReport {
+	report := MarginReport{From: from, To: to}
+	cost := RecipeCost(recipe)
+	byId := make(map[uint]Dish, len(dishes))
+	categories := make(map[string]*CategoryMargin)
+	for _, d := range dishes {
+		byId[d.ID] = d
+		m := DishMargin{
+			DishID:   d.ID,
+			Name:     d.Name,
+			Category: d.Category,
+			Price:    d.Price,
+			Cost:     cost[d.ID],
+			Margin:   d.Price - cost[d.ID],
+		}
+		m.MarginPercent = marginPercent(m.Margin, m.Price)
+		report.Dishes = append(report.Dishes, m)
+
+		c, ok := categories[d.Category]
+		if !ok {
+			c = &CategoryMargin{Category: d.Category}
+			categories[d.Category] = c
+		}
+		c.Dishes++
+		c.Price += m.Price
+		c.Cost += m.Cost
+		c.Margin += m.Margin
+	}
+	for _, c := range categories {
+		c.MarginPercent = marginPercent(c.Margin, c.Price)
+		report.Categories = append(report.Categories, *c)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Category < report.Categories[j].Category
+	})
+
+	for _, s := range sales {
+		d := byId[s.DishID]
+		m := SalesMargin{
+			DishID:   s.DishID,
+			Name:     d.Name,
+			Category: d.Category,
+			Quantity: s.Quantity,
+			Revenue:  s.Revenue,
+			Cost:     cost[s.DishID] * float32(s.Quantity),
+		}
+		m.Margin = m.Revenue - m.Cost
+		m.MarginPercent = marginPercent(m.Margin, m.Revenue)
+		report.Sales = append(report.Sales, m)
+		report.Revenue += m.Revenue
+		report.Cost += m.Cost
+	}
+	report.Margin = report.Revenue - report.Cost
+	report.MarginPercent = marginPercent(report.Margin, report.Revenue)
+	return report
+}
@@ -1,6 +1,10 @@
 package entity
 
-import "github.com/stretchr/testify/mock"
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type MockRepo struct {
 	mock.Mock
@@ -17,8 +21,8 @@ func (m *MockRepo) CreateOrder(order *Order) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) GetOrders() (c []Order, err error) {
-	args := m.Called()
+func (m *MockRepo) GetOrders(query OrderQuery) (c []Order, err error) {
+	args := m.Called(query)
 	if result := args.Get(0); result != nil {
 		return result.([]Order), args.Error(1)
 	}
@@ -43,8 +47,8 @@ func (m *MockRepo) UpdateDiscount(discount *DiscountDetail) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) DeleteOrder(id uint) error {
-	args := m.Called(id)
+func (m *MockRepo) DeleteOrder(id uint, version uint) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
@@ -53,8 +57,8 @@ func (m *MockRepo) CreateDish(dish *Dish) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) GetDishes() ([]Dish, error) {
-	args := m.Called()
+func (m *MockRepo) GetDishes(query DishQuery) ([]Dish, error) {
+	args := m.Called(query)
 	if result := args.Get(0); result != nil {
 		return result.([]Dish), args.Error(1)
 	}
@@ -74,11 +78,19 @@ func (m *MockRepo) UpdateDish(dish *Dish) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) DeleteDish(id uint) error {
-	args := m.Called(id)
+func (m *MockRepo) DeleteDish(id uint, version uint) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *MockRepo) SearchDishes(text string, limit int) ([]Dish, error) {
+	args := m.Called(text, limit)
+	if result := args.Get(0); result != nil {
+		return result.([]Dish), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockRepo) CreateDiscount(discount *DiscountDetail) error {
 	args := m.Called(*discount)
 	return args.Error(0)
@@ -91,3 +103,360 @@ func (m *MockRepo) GetPriceAfterDiscount(orderId uint, dishId uint) (DiscountDet
 	}
 	return DiscountDetail{}, args.Error(1)
 }
+
+func (m *MockRepo) AddOrderItems(orderId uint, items []OrderItem) error {
+	args := m.Called(orderId, items)
+	return args.Error(0)
+}
+
+func (m *MockRepo) AdjustOrderItem(orderId uint, itemId uint, adjustment ItemAdjustment) (OrderItem, error) {
+	args := m.Called(orderId, itemId, adjustment)
+	if result := args.Get(0); result != nil {
+		return result.(OrderItem), args.Error(1)
+	}
+	return OrderItem{}, args.Error(1)
+}
+
+func (m *MockRepo) CreateIngredient(ingredient *Ingredient) error {
+	args := m.Called(*ingredient)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetIngredients() ([]Ingredient, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]Ingredient), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetIngredient(id uint) (Ingredient, error) {
+	args := m.Called(id)
+	if result := args.Get(0); result != nil {
+		return result.(Ingredient), args.Error(1)
+	}
+	return Ingredient{}, args.Error(1)
+}
+
+func (m *MockRepo) UpdateIngredient(ingredient *Ingredient) error {
+	args := m.Called(*ingredient)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteIngredient(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SetRecipe(dishId uint, recipe []RecipeItem) error {
+	args := m.Called(dishId, recipe)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetRecipe(dishId uint) ([]RecipeItem, error) {
+	args := m.Called(dishId)
+	if result := args.Get(0); result != nil {
+		return result.([]RecipeItem), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetInventory() ([]InventoryItem, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]InventoryItem), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) CreateSupplier(supplier *Supplier) error {
+	args := m.Called(*supplier)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetSuppliers() ([]Supplier, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]Supplier), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetSupplier(id uint) (Supplier, error) {
+	args := m.Called(id)
+	if result := args.Get(0); result != nil {
+		return result.(Supplier), args.Error(1)
+	}
+	return Supplier{}, args.Error(1)
+}
+
+func (m *MockRepo) UpdateSupplier(supplier *Supplier) error {
+	args := m.Called(*supplier)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteSupplier(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) CreatePurchaseOrder(order *PurchaseOrder) error {
+	args := m.Called(*order)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetPurchaseOrders() ([]PurchaseOrder, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]PurchaseOrder), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetPurchaseOrder(id uint) (PurchaseOrder, error) {
+	args := m.Called(id)
+	if result := args.Get(0); result != nil {
+		return result.(PurchaseOrder), args.Error(1)
+	}
+	return PurchaseOrder{}, args.Error(1)
+}
+
+func (m *MockRepo) ReceivePurchaseOrder(id uint, received []PurchaseOrderLine) (PurchaseOrder, error) {
+	args := m.Called(id, received)
+	if result := args.Get(0); result != nil {
+		return result.(PurchaseOrder), args.Error(1)
+	}
+	return PurchaseOrder{}, args.Error(1)
+}
+
+func (m *MockRepo) GetReorderSuggestions() ([]ReorderSuggestion, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]ReorderSuggestion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetIngredientCosts(ingredientId uint) ([]IngredientCost, error) {
+	args := m.Called(ingredientId)
+	if result := args.Get(0); result != nil {
+		return result.([]IngredientCost), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetMarginReport(from time.Time, to time.Time) (MarginReport, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.(MarginReport), args.Error(1)
+	}
+	return MarginReport{}, args.Error(1)
+}
+
+func (m *MockRepo) GetSalesSummary(from time.Time, to time.Time) (SalesSummary, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.(SalesSummary), args.Error(1)
+	}
+	return SalesSummary{}, args.Error(1)
+}
+
+func (m *MockRepo) GetTopDishes(from time.Time, to time.Time, limit int) ([]TopDish, error) {
+	args := m.Called(from, to, limit)
+	if result := args.Get(0); result != nil {
+		return result.([]TopDish), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetSalesByTable(from time.Time, to time.Time) ([]TableSales, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.([]TableSales), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetSalesByHour(from time.Time, to time.Time) ([]PeriodSales, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.([]PeriodSales), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetSalesByWeekday(from time.Time, to time.Time) ([]PeriodSales, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.([]PeriodSales), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetAdjustments(from time.Time, to time.Time) ([]AdjustmentSales, error) {
+	args := m.Called(from, to)
+	if result := args.Get(0); result != nil {
+		return result.([]AdjustmentSales), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) AddPayment(payment *Payment) error {
+	args := m.Called(*payment)
+	return args.Error(0)
+}
+
+func (m *MockRepo) OpenShift(shift *Shift) error {
+	args := m.Called(*shift)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetShifts() ([]Shift, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]Shift), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetShift(id uint) (Shift, error) {
+	args := m.Called(id)
+	if result := args.Get(0); result != nil {
+		return result.(Shift), args.Error(1)
+	}
+	return Shift{}, args.Error(1)
+}
+
+func (m *MockRepo) CloseShift(id uint, countedCash float32) (ZReport, error) {
+	args := m.Called(id, countedCash)
+	if result := args.Get(0); result != nil {
+		return result.(ZReport), args.Error(1)
+	}
+	return ZReport{}, args.Error(1)
+}
+
+func (m *MockRepo) GetZReport(shiftId uint) (ZReport, error) {
+	args := m.Called(shiftId)
+	if result := args.Get(0); result != nil {
+		return result.(ZReport), args.Error(1)
+	}
+	return ZReport{}, args.Error(1)
+}
+
+func (m *MockRepo) ExportOrders(from time.Time, to time.Time, fn func(OrderExportRow) error) error {
+	args := m.Called(from, to)
+	if rows := args.Get(0); rows != nil {
+		for _, row := range rows.([]OrderExportRow) {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepo) ImportDishes(dishes []Dish, dryRun bool) ([]ImportRow, error) {
+	args := m.Called(dishes, dryRun)
+	if result := args.Get(0); result != nil {
+		return result.([]ImportRow), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) AddSignature(signature *FiscalSignature) error {
+	args := m.Called(*signature)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ExportSignatures(from time.Time, to time.Time, fn func(FiscalSignature) error) error {
+	args := m.Called(from, to)
+	if signatures := args.Get(0); signatures != nil {
+		for _, s := range signatures.([]FiscalSignature) {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepo) CreateUser(user *User) error {
+	args := m.Called(*user)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetUsers() ([]User, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) GetUser(id uint) (User, error) {
+	args := m.Called(id)
+	if result := args.Get(0); result != nil {
+		return result.(User), args.Error(1)
+	}
+	return User{}, args.Error(1)
+}
+
+func (m *MockRepo) GetUserByUsername(username string) (User, error) {
+	args := m.Called(username)
+	if result := args.Get(0); result != nil {
+		return result.(User), args.Error(1)
+	}
+	return User{}, args.Error(1)
+}
+
+func (m *MockRepo) UpdateUser(user *User) error {
+	args := m.Called(*user)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteUser(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ClockIn(userId uint) (TimeEntry, error) {
+	args := m.Called(userId)
+	if result := args.Get(0); result != nil {
+		return result.(TimeEntry), args.Error(1)
+	}
+	return TimeEntry{}, args.Error(1)
+}
+
+func (m *MockRepo) ClockOut(userId uint) (TimeEntry, error) {
+	args := m.Called(userId)
+	if result := args.Get(0); result != nil {
+		return result.(TimeEntry), args.Error(1)
+	}
+	return TimeEntry{}, args.Error(1)
+}
+
+func (m *MockRepo) GetTimesheets(from time.Time, to time.Time, userId uint) ([]Timesheet, error) {
+	args := m.Called(from, to, userId)
+	if result := args.Get(0); result != nil {
+		return result.([]Timesheet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// Atomic runs fn with the mock itself, the calls of fn are expected as usual.
+func (m *MockRepo) Atomic(fn func(tx Repo) error) error {
+	return fn(m)
+}
+
+func (m *MockRepo) AddAuditEntry(entry *AuditEntry) error {
+	args := m.Called(*entry)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetAuditEntries(entity string, entityId string) ([]AuditEntry, error) {
+	args := m.Called(entity, entityId)
+	if result := args.Get(0); result != nil {
+		return result.([]AuditEntry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -4,7 +4,65 @@ import "gorm.io/gorm"
 
 type Order struct {
 	gorm.Model
-	TableNumber    int
-	FinalPrice     float32
+	TableNumber int
+	FinalPrice  float32
+	ShiftID     uint
+	UserID      uint
+	// Version counts the updates of the order, updates and deletes need the version the
+	// client read, see ErrVersionMismatch.
+	Version        uint `gorm:"not null;default:1"`
 	DiscountDetail []DiscountDetail
+	Items          []OrderItem
+	Payments       []Payment
+	Signatures     []FiscalSignature
+}
+
+// Validate checks an order sent by a client, including its items.
+func (o Order) Validate() error {
+	var v Validator
+	v.Min("TableNumber", float64(o.TableNumber), 0)
+	v.Min("FinalPrice", float64(o.FinalPrice), 0)
+	v.Nested("Items", ValidateEach(o.Items))
+	return v.Err()
+}
+
+// Total is the amount due for all charged items after discounts.
+func (o Order) Total() float32 {
+	discounts := make(map[uint]float32)
+	for _, d := range o.DiscountDetail {
+		discounts[d.DishID] = d.Discount
+	}
+	var total float32
+	for _, item := range o.Items {
+		if !item.Charged() {
+			continue
+		}
+		total += float32(item.Quantity) * item.Price * (100 - discounts[item.DishID]) / 100
+	}
+	return total
+}
+
+func (o Order) Paid() float32 {
+	var paid float32
+	for _, p := range o.Payments {
+		paid += p.Amount
+	}
+	return paid
+}
+
+// PaidInFull reports whether the payments cover the total, allowing for rounding. An order
+// with nothing to charge is never paid in full.
+func (o Order) PaidInFull() bool {
+	total := o.Total()
+	return total > 0 && o.Paid() >= total-0.005
+}
+
+// Signature returns the latest fiscal signature of the given kind, or nil if there is none.
+func (o Order) Signature(kind string) *FiscalSignature {
+	for i := len(o.Signatures) - 1; i >= 0; i-- {
+		if o.Signatures[i].Kind == kind {
+			return &o.Signatures[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,70 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Voided and comped items stay on the order with their reason, but are not charged.
+// A void corrects a mistake, a comp gives the item to the guest on the house.
+const (
+	ItemVoided = "void"
+	ItemComped = "comp"
+)
+
+// Reason codes, one of them is required for every void and comp.
+var (
+	VoidReasons = []string{"entered_twice", "wrong_item", "wrong_table", "changed_mind", "kitchen_error"}
+	CompReasons = []string{"quality", "waiting_time", "staff_meal", "regular_guest", "promotion"}
+)
+
+type OrderItem struct {
+	gorm.Model
+	OrderID    uint
+	DishID     uint
+	Dish       Dish
+	Quantity   int
+	Price      float32
+	TaxRate    float32
+	Status     string `gorm:"not null;default:''"`
+	Reason     string
+	Waste      bool
+	AdjustedBy uint
+}
+
+// Charged reports whether the item is part of the amount due.
+func (i OrderItem) Charged() bool {
+	return i.Status == ""
+}
+
+// Validate checks an item sent by a client. A price of 0 stands for the dish price.
+func (i OrderItem) Validate() error {
+	var v Validator
+	v.RequiredID("DishID", i.DishID)
+	v.Positive("Quantity", float64(i.Quantity))
+	v.Min("Price", float64(i.Price), 0)
+	return v.Err()
+}
+
+// ItemAdjustment voids or comps Quantity of an order item, or all of it if Quantity is 0.
+// Waste marks a void of an item which was already prepared, its ingredients are not
+// returned to the stock.
+type ItemAdjustment struct {
+	Status   string
+	Reason   string
+	Waste    bool
+	Quantity int
+	UserID   uint
+}
+
+func (a ItemAdjustment) Validate() error {
+	var v Validator
+	switch a.Status {
+	case ItemVoided:
+		v.OneOf("Reason", a.Reason, VoidReasons)
+	case ItemComped:
+		v.OneOf("Reason", a.Reason, CompReasons)
+		v.Check(!a.Waste, "Waste", RuleFormat, "is only possible for voids")
+	default:
+		v.OneOf("Status", a.Status, []string{ItemVoided, ItemComped})
+	}
+	v.Min("Quantity", float64(a.Quantity), 0)
+	return v.Err()
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "gorm.io/gorm"
+
+const (
+	PaymentCash = "cash"
+	PaymentCard = "card"
+)
+
+type Payment struct {
+	gorm.Model
+	OrderID uint
+	UserID  uint
+	Method  string
+	Amount  float32
+}
+
+func (p Payment) Validate() error {
+	var v Validator
+	v.OneOf("Method", p.Method, []string{PaymentCash, PaymentCard})
+	v.Positive("Amount", float64(p.Amount))
+	return v.Err()
+}
@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Order states for filtering lists. An order is paid once its payments cover the
+// total of its items, voided orders are the deleted ones.
+const (
+	OrderOpen   = "open"
+	OrderPaid   = "paid"
+	OrderVoided = "voided"
+)
+
+// ListOptions selects a page of a list. Limit 0 returns all rows. Pages are either
+// selected by Offset, or by After, the id of the last row of the previous page. After
+// only works sorted by id, but stays stable while new rows are added.
+// Sort is a comma separated list of fields, a leading "-" sorts a field descending.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	After  uint
+	Sort   string
+}
+
+// SortFields splits Sort into the field names and their directions.
+func (o ListOptions) SortFields() (fields []string, desc []bool) {
+	if o.Sort == "" {
+		return nil, nil
+	}
+	for _, f := range strings.Split(o.Sort, ",") {
+		f = strings.TrimSpace(f)
+		fields = append(fields, strings.TrimPrefix(f, "-"))
+		desc = append(desc, strings.HasPrefix(f, "-"))
+	}
+	return fields, desc
+}
+
+// ByID reports whether the list is sorted by id only, the order cursors need.
+func (o ListOptions) ByID() bool {
+	return o.Sort == "" || o.Sort == "id" || o.Sort == "-id"
+}
+
+func (o ListOptions) Validate() error {
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must not be negative", ErrBadRequest)
+	}
+	if o.After != 0 && (o.Offset != 0 || !o.ByID()) {
+		return fmt.Errorf("%w: a cursor can not be combined with an offset or a sort other than id", ErrBadRequest)
+	}
+	return nil
+}
+
+// OrderQuery filters the orders. Zero values do not filter.
+type OrderQuery struct {
+	ListOptions
+	Status      string
+	TableNumber *int
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+func (q OrderQuery) Validate() error {
+	switch q.Status {
+	case "", OrderOpen, OrderPaid, OrderVoided:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrBadRequest, q.Status)
+	}
+	return q.ListOptions.Validate()
+}
+
+// DishQuery filters the dishes. Name matches a part of the name, ignoring case.
+type DishQuery struct {
+	ListOptions
+	Name     string
+	Category string
+	PriceMin *float32
+	PriceMax *float32
+}
+
+func (q DishQuery) Validate() error {
+	if q.PriceMin != nil && q.PriceMax != nil && *q.PriceMin > *q.PriceMax {
+		return fmt.Errorf("%w: priceMin is above priceMax", ErrBadRequest)
+	}
+	return q.ListOptions.Validate()
+}
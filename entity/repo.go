@@ -1,55 +1,35 @@
 package entity
 
-import (
-	"errors"
-	"fmt"
-)
-
-type RecordNotFoundError struct {
-	ID    string
-	Kind  string
-	Inner error
-}
-
-func (e RecordNotFoundError) Error() string {
-	return fmt.Sprintf("%v with id %v not found", e.Kind, e.ID)
-}
-
-func (e RecordNotFoundError) Unwarp() error {
-	return e.Inner
-}
-
-func (e RecordNotFoundError) Is(err error) bool {
-	return errors.Is(err, ErrRecordNotFound)
-}
-
-func WrapRecordNotFoundError(kind string, id any, inner error) error {
-	return RecordNotFoundError{
-		ID:    fmt.Sprint(id),
-		Kind:  kind,
-		Inner: inner,
-	}
-}
-
-var ErrDBNotConnected = errors.New("could not connect to DB")
-var ErrInvalidData = errors.New("unsupported data")
-var ErrRecordNotFound = errors.New("record not found")
-var ErrJson = errors.New("can not convert object to JSON")
-var ErrEntityNotFound = errors.New("entity not found")
+import "time"
 
 type Repo interface {
 	OrdersRepo
 	DiscountDetailsRepo
 	DishRepo
+	InventoryRepo
+	PurchasingRepo
+	ReportsRepo
+	ShiftsRepo
+	UsersRepo
+	AuditRepo
+	// Atomic runs fn with a repo whose changes are committed together if fn returns nil,
+	// and rolled back otherwise. The transactions of the repo methods nest in it.
+	Atomic(fn func(tx Repo) error) error
 }
 
 type OrdersRepo interface {
 	CreateOrder(order *Order) error
-	GetOrders() ([]Order, error)
+	GetOrders(query OrderQuery) ([]Order, error)
 	GetOrder(id uint) (Order, error)
 	UpdateOrder(order *Order) error
 	UpdateDiscount(discount *DiscountDetail) error
-	DeleteOrder(id uint) error
+	DeleteOrder(id uint, version uint) error
+	AddOrderItems(orderId uint, items []OrderItem) error
+	AdjustOrderItem(orderId uint, itemId uint, adjustment ItemAdjustment) (OrderItem, error)
+	AddPayment(payment *Payment) error
+	ExportOrders(from time.Time, to time.Time, fn func(OrderExportRow) error) error
+	AddSignature(signature *FiscalSignature) error
+	ExportSignatures(from time.Time, to time.Time, fn func(FiscalSignature) error) error
 }
 type DiscountDetailsRepo interface {
 	CreateDiscount(discount *DiscountDetail) error
@@ -57,8 +37,70 @@ type DiscountDetailsRepo interface {
 }
 type DishRepo interface {
 	CreateDish(dish *Dish) error
-	GetDishes() ([]Dish, error)
+	GetDishes(query DishQuery) ([]Dish, error)
 	GetDish(id uint) (Dish, error)
 	UpdateDish(dish *Dish) error
-	DeleteDish(id uint) error
+	DeleteDish(id uint, version uint) error
+	ImportDishes(dishes []Dish, dryRun bool) ([]ImportRow, error)
+	SearchDishes(text string, limit int) ([]Dish, error)
+}
+
+type InventoryRepo interface {
+	CreateIngredient(ingredient *Ingredient) error
+	GetIngredients() ([]Ingredient, error)
+	GetIngredient(id uint) (Ingredient, error)
+	UpdateIngredient(ingredient *Ingredient) error
+	DeleteIngredient(id uint) error
+	SetRecipe(dishId uint, recipe []RecipeItem) error
+	GetRecipe(dishId uint) ([]RecipeItem, error)
+	GetInventory() ([]InventoryItem, error)
+	GetMarginReport(from time.Time, to time.Time) (MarginReport, error)
+}
+
+type PurchasingRepo interface {
+	CreateSupplier(supplier *Supplier) error
+	GetSuppliers() ([]Supplier, error)
+	GetSupplier(id uint) (Supplier, error)
+	UpdateSupplier(supplier *Supplier) error
+	DeleteSupplier(id uint) error
+	CreatePurchaseOrder(order *PurchaseOrder) error
+	GetPurchaseOrders() ([]PurchaseOrder, error)
+	GetPurchaseOrder(id uint) (PurchaseOrder, error)
+	ReceivePurchaseOrder(id uint, received []PurchaseOrderLine) (PurchaseOrder, error)
+	GetReorderSuggestions() ([]ReorderSuggestion, error)
+	GetIngredientCosts(ingredientId uint) ([]IngredientCost, error)
+}
+
+type ReportsRepo interface {
+	GetSalesSummary(from time.Time, to time.Time) (SalesSummary, error)
+	GetTopDishes(from time.Time, to time.Time, limit int) ([]TopDish, error)
+	GetSalesByTable(from time.Time, to time.Time) ([]TableSales, error)
+	GetSalesByHour(from time.Time, to time.Time) ([]PeriodSales, error)
+	GetSalesByWeekday(from time.Time, to time.Time) ([]PeriodSales, error)
+	GetAdjustments(from time.Time, to time.Time) ([]AdjustmentSales, error)
+}
+
+type ShiftsRepo interface {
+	OpenShift(shift *Shift) error
+	GetShifts() ([]Shift, error)
+	GetShift(id uint) (Shift, error)
+	CloseShift(id uint, countedCash float32) (ZReport, error)
+	GetZReport(shiftId uint) (ZReport, error)
+}
+
+type UsersRepo interface {
+	CreateUser(user *User) error
+	GetUsers() ([]User, error)
+	GetUser(id uint) (User, error)
+	GetUserByUsername(username string) (User, error)
+	UpdateUser(user *User) error
+	DeleteUser(id uint) error
+	ClockIn(userId uint) (TimeEntry, error)
+	ClockOut(userId uint) (TimeEntry, error)
+	GetTimesheets(from time.Time, to time.Time, userId uint) ([]Timesheet, error)
+}
+
+type AuditRepo interface {
+	AddAuditEntry(entry *AuditEntry) error
+	GetAuditEntries(entity string, entityId string) ([]AuditEntry, error)
 }
@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+type SalesSummary struct {
+	From         time.Time
+	To           time.Time
+	Orders       int
+	Items        int
+	Revenue      float32
+	Discounts    float32
+	Voids        float32
+	Comps        float32
+	AverageCheck float32
+}
+
+type TopDish struct {
+	DishID   uint
+	Name     string
+	Quantity int
+	Revenue  float32
+}
+
+type TableSales struct {
+	TableNumber int
+	Orders      int
+	Revenue     float32
+}
+
+// PeriodSales are the sales within one hour of the day (0-23) or one weekday (0 is Sunday).
+type PeriodSales struct {
+	Period  int
+	Orders  int
+	Revenue float32
+}
+
+// AdjustmentSales are the items voided or comped for one reason. Amount is their value
+// before discounts, Wasted the part of the voided items which had already been prepared.
+type AdjustmentSales struct {
+	Status string
+	Reason string
+	Items  int
+	Amount float32
+	Wasted int
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a search into lower case words of letters and digits.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchDocument is the text a dish is found by, its name and description in all languages.
+func (d Dish) SearchDocument() string {
+	parts := []string{d.Name, d.Description}
+	for _, t := range d.Translations {
+		parts = append(parts, t.Name, t.Description)
+	}
+	return strings.Join(SearchTerms(strings.Join(parts, " ")), " ")
+}
+
+// FuzzyScore rates how well a document matches the search terms, for searches without
+// a full-text index or when the index finds nothing. Every term has to match a word of
+// the document, either as its prefix or with a few typos. It returns 0 if one does not.
+func FuzzyScore(terms []string, document string) float64 {
+	words := strings.Fields(document)
+	var score float64
+	for _, term := range terms {
+		best := 0.0
+		t := []rune(term)
+		for _, word := range words {
+			w := []rune(word)
+			if strings.HasPrefix(word, term) {
+				best = 1
+				break
+			}
+			distance := editDistance(t, w)
+			if len(w) > len(t) {
+				distance = min(distance, editDistance(t, w[:len(t)]))
+			}
+			if distance <= maxTypos(len(t)) {
+				best = max(best, 1-float64(distance)/float64(len(t)+1))
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score
+}
+
+// maxTypos allows one typo from four letters on and two from eight letters on.
+func maxTypos(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
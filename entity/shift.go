@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Shift is one business day at the till. Orders created while a shift is open belong to it
+// and can not be changed anymore once it is closed.
+type Shift struct {
+	gorm.Model
+	BusinessDate time.Time
+	OpenedAt     time.Time
+	ClosedAt     *time.Time
+	OpeningCash  float32
+}
+
+func (s Shift) IsClosed() bool {
+	return s.ClosedAt != nil
+}
+
+func (s Shift) Validate() error {
+	var v Validator
+	v.Min("OpeningCash", float64(s.OpeningCash), 0)
+	return v.Err()
+}
+
+// ZReport is the frozen end of day report written when a shift is closed.
+type ZReport struct {
+	gorm.Model
+	ShiftID        uint `gorm:"uniqueIndex"`
+	BusinessDate   time.Time
+	OpenedAt       time.Time
+	ClosedAt       time.Time
+	Orders         int
+	Revenue        float32
+	Discounts      float32
+	VoidedOrders   int
+	Voids          float32
+	ItemVoids      float32
+	Comps          float32
+	OpeningCash    float32
+	CashExpected   float32
+	CashCounted    float32
+	CashDifference float32
+	Payments       []ZReportPayment
+	Taxes          []ZReportTax
+}
+
+type ZReportPayment struct {
+	ID        uint
+	ZReportID uint
+	Method    string
+	Count     int
+	Amount    float32
+}
+
+type ZReportTax struct {
+	ID        uint
+	ZReportID uint
+	Rate      float32
+	Net       float32
+	Tax       float32
+	Gross     float32
+}
+
+// NetOf splits a gross amount into net amount and tax for the tax rate in percent.
+func NetOf(gross float32, rate float32) (net float32, tax float32) {
+	net = gross / (1 + rate/100)
+	return net, gross - net
+}
+
+// NewZReport freezes the totals of a shift. Taxes only need Rate and Gross set.
+func NewZReport(shift Shift, closedAt time.Time, countedCash float32, sales SalesSummary, voids SalesSummary,
+	payments []ZReportPayment, taxes []ZReportTax) ZReport {
+	z := ZReport{
+		ShiftID:      shift.ID,
+		BusinessDate: shift.BusinessDate,
+		OpenedAt:     shift.OpenedAt,
+		ClosedAt:     closedAt,
+		Orders:       sales.Orders,
+		Revenue:      sales.Revenue,
+		Discounts:    sales.Discounts,
+		VoidedOrders: voids.Orders,
+		Voids:        voids.Revenue,
+		ItemVoids:    sales.Voids,
+		Comps:        sales.Comps,
+		OpeningCash:  shift.OpeningCash,
+		CashExpected: shift.OpeningCash,
+		CashCounted:  countedCash,
+		Payments:     payments,
+		Taxes:        taxes,
+	}
+	for _, p := range payments {
+		if p.Method == PaymentCash {
+			z.CashExpected += p.Amount
+		}
+	}
+	z.CashDifference = z.CashCounted - z.CashExpected
+	for i := range z.Taxes {
+		z.Taxes[i].Net, z.Taxes[i].Tax = NetOf(z.Taxes[i].Gross, z.Taxes[i].Rate)
+	}
+	return z
+}
@@ -0,0 +1,143 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Supplier struct {
+	gorm.Model
+	Name    string
+	Contact string
+	Email   string
+	Phone   string
+}
+
+// ValidateNew checks a new supplier. Updates only change the fields they set, so they
+// have nothing to check.
+func (s Supplier) ValidateNew() error {
+	var v Validator
+	v.Required("Name", s.Name)
+	return v.Err()
+}
+
+const (
+	PurchaseOrderOpen     = "open"
+	PurchaseOrderPartial  = "partial"
+	PurchaseOrderReceived = "received"
+)
+
+type PurchaseOrder struct {
+	gorm.Model
+	SupplierID uint
+	Supplier   Supplier
+	Status     string
+	Lines      []PurchaseOrderLine
+	ReceivedAt *time.Time
+}
+
+func (p PurchaseOrder) Validate() error {
+	var v Validator
+	v.RequiredID("SupplierID", p.SupplierID)
+	v.Nested("Lines", ValidateEach(p.Lines))
+	return v.Err()
+}
+
+type PurchaseOrderLine struct {
+	gorm.Model
+	PurchaseOrderID  uint
+	IngredientID     uint
+	Quantity         float32
+	ReceivedQuantity float32
+	UnitCost         float32
+}
+
+// Validate checks an ordered line as well as a received one, where Quantity is the
+// quantity delivered.
+func (l PurchaseOrderLine) Validate() error {
+	var v Validator
+	v.RequiredID("IngredientID", l.IngredientID)
+	v.Positive("Quantity", float64(l.Quantity))
+	v.Min("UnitCost", float64(l.UnitCost), 0)
+	return v.Err()
+}
+
+// IngredientCost records the price paid for an ingredient on every delivery,
+// so food cost can be followed over time.
+type IngredientCost struct {
+	gorm.Model
+	IngredientID    uint
+	PurchaseOrderID uint
+	Quantity        float32
+	UnitCost        float32
+	ReceivedAt      time.Time
+}
+
+type ReorderSuggestion struct {
+	IngredientID  uint
+	Name          string
+	Unit          string
+	Stock         float32
+	ParLevel      float32
+	Quantity      float32
+	SupplierID    uint
+	UnitCost      float32
+	EstimatedCost float32
+}
+
+// NewReorderSuggestions lists every ingredient whose stock is below its par level
+// together with the quantity needed to fill it up again.
+func NewReorderSuggestions(ingredients []Ingredient) []ReorderSuggestion {
+	suggestions := make([]ReorderSuggestion, 0)
+	for _, i := range ingredients {
+		if i.Stock >= i.ParLevel {
+			continue
+		}
+		quantity := i.ParLevel - i.Stock
+		suggestions = append(suggestions, ReorderSuggestion{
+			IngredientID:  i.ID,
+			Name:          i.Name,
+			Unit:          i.Unit,
+			Stock:         i.Stock,
+			ParLevel:      i.ParLevel,
+			Quantity:      quantity,
+			SupplierID:    i.SupplierID,
+			UnitCost:      i.UnitCost,
+			EstimatedCost: quantity * i.UnitCost,
+		})
+	}
+	return suggestions
+}
+
+// Receive books the received quantities onto the order lines and updates the status.
+func (p *PurchaseOrder) Receive(received []PurchaseOrderLine) error {
+	if p.Status == PurchaseOrderReceived {
+		return ErrPurchaseOrderReceived
+	}
+	for _, r := range received {
+		found := false
+		for i := range p.Lines {
+			if p.Lines[i].IngredientID != r.IngredientID {
+				continue
+			}
+			p.Lines[i].ReceivedQuantity += r.Quantity
+			if r.UnitCost != 0 {
+				p.Lines[i].UnitCost = r.UnitCost
+			}
+			found = true
+			break
+		}
+		if !found {
+			return fmt.Errorf("%w: ingredient %d is not on purchase order %d", ErrInvalidData, r.IngredientID, p.ID)
+		}
+	}
+	p.Status = PurchaseOrderReceived
+	for _, l := range p.Lines {
+		if l.ReceivedQuantity < l.Quantity {
+			p.Status = PurchaseOrderPartial
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimeEntry is the time between a clock-in and the following clock-out of a user.
+type TimeEntry struct {
+	gorm.Model
+	UserID   uint `gorm:"index"`
+	ClockIn  time.Time
+	ClockOut *time.Time
+}
+
+type Timesheet struct {
+	UserID   uint
+	Username string
+	Role     string
+	Entries  []TimeEntry
+	Hours    float64
+}
+
+// Hours counts the part of the entry between from and to. Entries without a clock-out
+// are counted up to now.
+func (e TimeEntry) Hours(from time.Time, to time.Time, now time.Time) float64 {
+	start, end := e.ClockIn, now
+	if e.ClockOut != nil {
+		end = *e.ClockOut
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// NewTimesheets sums up the entries per user. Users without entries are included
+// with zero hours.
+func NewTimesheets(users []User, entries []TimeEntry, from time.Time, to time.Time, now time.Time) []Timesheet {
+	sheets := make([]Timesheet, len(users))
+	index := make(map[uint]int)
+	for i, u := range users {
+		sheets[i] = Timesheet{UserID: u.ID, Username: u.Username, Role: u.Role}
+		index[u.ID] = i
+	}
+	for _, e := range entries {
+		i, ok := index[e.UserID]
+		if !ok {
+			continue
+		}
+		sheets[i].Entries = append(sheets[i].Entries, e)
+		sheets[i].Hours += e.Hours(from, to, now)
+	}
+	return sheets
+}
@@ -0,0 +1,70 @@
+package entity
+
+import "gorm.io/gorm"
+
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleWaiter  = "waiter"
+	RoleKitchen = "kitchen"
+)
+
+var Roles = []string{RoleAdmin, RoleManager, RoleWaiter, RoleKitchen}
+
+// User is a staff member who logs in to the API. Password and Pin are only set on
+// input, only their bcrypt hashes are stored.
+type User struct {
+	gorm.Model
+	Username     string `gorm:"uniqueIndex"`
+	Password     string `gorm:"-"`
+	PasswordHash string `json:"-"`
+	Pin          string `gorm:"-"`
+	PinHash      string `json:"-"`
+	Role         string
+}
+
+// Validate checks the fields a client may change. The role may be left out on updates,
+// see ValidateNew for new users.
+func (u User) Validate() error {
+	var v Validator
+	if u.Role != "" {
+		v.OneOf("Role", u.Role, Roles)
+	}
+	if u.Pin != "" {
+		v.Check(ValidPin(u.Pin), "Pin", RuleFormat, "must have 4 digits")
+		v.Check(u.Role != RoleAdmin, "Pin", RuleFormat, "can not be used by admins")
+	}
+	return v.Err()
+}
+
+// ValidateNew checks a new user, who needs a username, password and role.
+func (u User) ValidateNew() error {
+	var v Validator
+	v.Required("Username", u.Username)
+	v.Required("Password", u.Password)
+	v.Required("Role", u.Role)
+	v.Nested("", u.Validate())
+	return v.Err()
+}
+
+// ValidPin checks for the 4 digits staff type in on the shared tablets.
+func ValidPin(pin string) bool {
+	if len(pin) != 4 {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
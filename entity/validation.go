@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"fmt"
+	"gorestserviceagain/dto"
+	"slices"
+	"strings"
+)
+
+// Rules a field can break, see dto.
+const (
+	RuleRequired = dto.RuleRequired
+	RulePositive = dto.RulePositive
+	RuleMin      = dto.RuleMin
+	RuleRange    = dto.RuleRange
+	RuleOneOf    = dto.RuleOneOf
+	RuleFormat   = dto.RuleFormat
+)
+
+type FieldError = dto.FieldError
+
+type ValidationError = dto.ValidationError
+
+// Validator collects the field errors of a request, so all of them are reported at once.
+type Validator struct {
+	fields []FieldError
+}
+
+func (v *Validator) Check(ok bool, field string, rule string, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Rule: rule, Message: message})
+	}
+}
+
+func (v *Validator) Required(field string, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, RuleRequired, "is required")
+}
+
+func (v *Validator) RequiredID(field string, id uint) {
+	v.Check(id != 0, field, RuleRequired, "is required")
+}
+
+func (v *Validator) Positive(field string, value float64) {
+	v.Check(value > 0, field, RulePositive, "must be positive")
+}
+
+func (v *Validator) Min(field string, value float64, min float64) {
+	v.Check(value >= min, field, RuleMin, fmt.Sprintf("must be at least %v", min))
+}
+
+func (v *Validator) Range(field string, value float64, min float64, max float64) {
+	v.Check(value >= min && value <= max, field, RuleRange, fmt.Sprintf("must be between %v and %v", min, max))
+}
+
+func (v *Validator) OneOf(field string, value string, allowed []string) {
+	v.Check(slices.Contains(allowed, value), field, RuleOneOf, fmt.Sprintf("must be one of %v", allowed))
+}
+
+// Nested adds the field errors of a nested value with the prefix, e.g. "Items[1]".
+// An empty prefix adds them as they are.
+func (v *Validator) Nested(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	e, ok := err.(ValidationError)
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: prefix, Rule: RuleFormat, Message: err.Error()})
+		return
+	}
+	for _, f := range e.Fields {
+		if prefix == "" {
+			v.fields = append(v.fields, f)
+			continue
+		}
+		if !strings.HasPrefix(f.Field, "[") {
+			f.Field = "." + f.Field
+		}
+		f.Field = prefix + f.Field
+		v.fields = append(v.fields, f)
+	}
+}
+
+// Err returns a ValidationError with the collected field errors, or nil if there are none.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return ValidationError{Fields: v.fields}
+}
+
+// ValidateEach validates the elements of a list, the fields are prefixed with their
+// index, e.g. "[1].Quantity".
+func ValidateEach[T interface{ Validate() error }](items []T) error {
+	var v Validator
+	for i, item := range items {
+		v.Nested(fmt.Sprintf("[%d]", i), item.Validate())
+	}
+	return v.Err()
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/fiscal"
+	"io"
+)
+
+// FormatDSFinVK is a zip archive with the semicolon separated tables of the DSFinV-K,
+// the export format German tax auditors expect from cash registers.
+const FormatDSFinVK = "dsfinvk"
+
+var (
+	DSFinVKLinesHeader = []any{
+		"Z_NR", "BON_ID", "POS_ZEILE", "BON_DATUM", "ART_NR", "ARTIKELTEXT",
+		"MENGE", "STK_BR", "UST_SATZ", "RABATT", "POS_BRUTTO",
+	}
+	DSFinVKPaymentsHeader = []any{"Z_NR", "BON_ID", "ZAHLART_TYP", "ZAHLART_NAME", "ZAHLWAEH_BETRAG"}
+	DSFinVKTSEHeader      = []any{
+		"BON_ID", "TSE_ID", "TSE_TANR", "TSE_TA_START", "TSE_TA_ENDE", "TSE_TA_VORGANGSART",
+		"TSE_TA_SIGZ", "TSE_TA_SIG", "TSE_TA_VORGANGSDATEN",
+	}
+)
+
+// OrderRows streams export rows to fn, like the ExportOrders repo method.
+type OrderRows func(fn func(entity.OrderExportRow) error) error
+
+// SignatureRows streams fiscal signatures to fn, like the ExportSignatures repo method.
+type SignatureRows func(fn func(entity.FiscalSignature) error) error
+
+// WriteDSFinVK writes lines.csv, datapayment.csv and transactions_tse.csv into a zip
+// archive. Zip entries can only be written one after the other, so the orders are
+// streamed twice.
+func WriteDSFinVK(w io.Writer, orders OrderRows, signatures SignatureRows) error {
+	archive := zip.NewWriter(w)
+
+	lines, err := dsfinvkTable(archive, "lines.csv", DSFinVKLinesHeader)
+	if err != nil {
+		return err
+	}
+	var order uint
+	var pos int
+	err = orders(func(row entity.OrderExportRow) error {
+		if row.RecordType != entity.ExportItem {
+			return nil
+		}
+		if row.OrderID != order {
+			order, pos = row.OrderID, 0
+		}
+		pos++
+		return lines.Write([]any{
+			row.ShiftID, row.OrderID, pos, row.CreatedAt, row.DishID, row.DishName,
+			row.Quantity, row.Price, row.TaxRate, row.Discount, row.Amount,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := lines.Close(); err != nil {
+		return err
+	}
+
+	payments, err := dsfinvkTable(archive, "datapayment.csv", DSFinVKPaymentsHeader)
+	if err != nil {
+		return err
+	}
+	err = orders(func(row entity.OrderExportRow) error {
+		if row.RecordType != entity.ExportPayment {
+			return nil
+		}
+		return payments.Write([]any{
+			row.ShiftID, row.OrderID, fiscal.PaymentType(row.PaymentMethod), row.PaymentMethod, row.Amount,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := payments.Close(); err != nil {
+		return err
+	}
+
+	tse, err := dsfinvkTable(archive, "transactions_tse.csv", DSFinVKTSEHeader)
+	if err != nil {
+		return err
+	}
+	err = signatures(func(s entity.FiscalSignature) error {
+		return tse.Write([]any{
+			s.OrderID, s.SerialNumber, s.TransactionNumber, s.StartTime, s.EndTime, s.ProcessType,
+			s.SignatureCounter, s.Signature, s.ProcessData,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := tse.Close(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func dsfinvkTable(archive *zip.Writer, name string, header []any) (Writer, error) {
+	f, err := archive.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+	table := &csvWriter{w: w}
+	return table, table.Write(header)
+}
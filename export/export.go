@@ -0,0 +1,139 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"gorestserviceagain/entity"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnknownFormat = entity.NewError(entity.ErrBadRequest, "unknown export format")
+
+// Writer writes a table row by row, so exports never need to hold all rows in memory.
+type Writer interface {
+	Write(record []any) error
+	Close() error
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatDSFinVK:
+		return "application/zip"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV, "":
+		return &csvWriter{w: csv.NewWriter(w), escapeFormulas: true}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// csvWriter writes a CSV file. Files opened in a spreadsheet escape text which would be
+// taken as a formula, see escapeFormula.
+type csvWriter struct {
+	w              *csv.Writer
+	escapeFormulas bool
+}
+
+func (c *csvWriter) Write(record []any) error {
+	fields := make([]string, len(record))
+	for i, v := range record {
+		fields[i] = formatValue(v)
+		if s, ok := v.(string); ok && c.escapeFormulas {
+			fields[i] = escapeFormula(s)
+		}
+	}
+	return c.w.Write(fields)
+}
+
+// escapeFormula prefixes text starting like a formula with a quote, so a dish named
+// "=HYPERLINK(...)" is shown as text by spreadsheets instead of being run.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', 2, 32)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+const xlsxSheet = "Sheet1"
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxWriter) Write(record []any) error {
+	x.row++
+	cells := make([]any, len(record))
+	for i, v := range record {
+		switch v := v.(type) {
+		case float32:
+			cells[i] = float64(v)
+		case time.Time:
+			cells[i] = v.Format(time.RFC3339)
+		default:
+			cells[i] = v
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+// Close finishes the sheet and writes the workbook. Excelize keeps large sheets in a
+// temporary file while streaming, so memory stays flat here as well.
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
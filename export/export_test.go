@@ -0,0 +1,18 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(FormatCSV, &b)
+	require.NoError(t, err)
+	require.NoError(t, w.Write([]any{"=HYPERLINK(\"http://x\")", "+1", "-fries", "@sum", "\tTab", "Fries", float32(-2)}))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "\"'=HYPERLINK(\"\"http://x\"\")\",'+1,'-fries,'@sum,'\tTab,Fries,-2.00\n", b.String())
+}
@@ -0,0 +1,15 @@
+package export
+
+import "gorestserviceagain/entity"
+
+var OrderHeader = []any{
+	"RecordType", "OrderID", "CreatedAt", "TableNumber", "ShiftID", "DishID", "DishName",
+	"Quantity", "Price", "TaxRate", "Discount", "Amount", "PaymentMethod",
+}
+
+func OrderRecord(row entity.OrderExportRow) []any {
+	return []any{
+		row.RecordType, row.OrderID, row.CreatedAt, row.TableNumber, row.ShiftID, row.DishID, row.DishName,
+		row.Quantity, row.Price, row.TaxRate, row.Discount, row.Amount, row.PaymentMethod,
+	}
+}
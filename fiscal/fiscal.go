@@ -0,0 +1,103 @@
+// Package fiscal builds the transactions that are signed by a technical security
+// device (TSE) as required by the KassenSichV.
+package fiscal
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"strings"
+	"time"
+)
+
+// ProcessType is the DSFinV-K process type of all transactions of this cash register.
+const ProcessType = "Kassenbeleg-V1"
+
+// vatRates are the rates in the order of the gross amounts in the DSFinV-K process data.
+var vatRates = entity.TaxRates
+
+// NewTransaction builds the transaction for an order that is paid or voided.
+// The order needs its items, discounts and payments loaded.
+func NewTransaction(order entity.Order, kind string) entity.FiscalTransaction {
+	return entity.FiscalTransaction{
+		OrderID:     order.ID,
+		Kind:        kind,
+		ProcessType: ProcessType,
+		ProcessData: ProcessData(order, kind),
+		StartTime:   order.CreatedAt,
+	}
+}
+
+// ProcessData formats an order as "Beleg^<gross amounts per VAT rate>^<payments>",
+// e.g. "Beleg^23.80_3.50_0.00_0.00_0.00^30.00:Bar". Voids are signed as
+// "AVBelegstorno" with negated amounts.
+func ProcessData(order entity.Order, kind string) string {
+	receiptType := "Beleg"
+	amount := func(v float32) string {
+		return fmt.Sprintf("%.2f", v)
+	}
+	if kind == entity.FiscalVoided {
+		receiptType = "AVBelegstorno"
+		amount = func(v float32) string {
+			if v == 0 {
+				return "0.00"
+			}
+			return fmt.Sprintf("%.2f", -v)
+		}
+	}
+
+	discounts := make(map[uint]float32)
+	for _, d := range order.DiscountDetail {
+		discounts[d.DishID] = d.Discount
+	}
+	gross := make([]float32, len(vatRates))
+	for _, item := range order.Items {
+		if !item.Charged() {
+			continue
+		}
+		amount := float32(item.Quantity) * item.Price * (100 - discounts[item.DishID]) / 100
+		gross[rateIndex(item.TaxRate)] += amount
+	}
+	amounts := make([]string, len(gross))
+	for i, g := range gross {
+		amounts[i] = amount(g)
+	}
+
+	var payments []string
+	for _, p := range order.Payments {
+		payments = append(payments, amount(p.Amount)+":"+PaymentType(p.Method))
+	}
+	return receiptType + "^" + strings.Join(amounts, "_") + "^" + strings.Join(payments, "_")
+}
+
+// PaymentType maps a payment method to the DSFinV-K payment type.
+func PaymentType(method string) string {
+	if method == entity.PaymentCash {
+		return "Bar"
+	}
+	return "Unbar"
+}
+
+// rateIndex returns the process data position of a tax rate. Dishes can only have the
+// known rates, see entity.Dish.Validate, so the normal rate is only a fallback.
+func rateIndex(rate float32) int {
+	for i, r := range vatRates {
+		if r == rate {
+			return i
+		}
+	}
+	return 0
+}
+
+// Message is the data covered by a signature, so it can be verified later.
+func Message(s entity.FiscalSignature) []byte {
+	return []byte(strings.Join([]string{
+		s.SerialNumber,
+		fmt.Sprint(s.TransactionNumber),
+		fmt.Sprint(s.SignatureCounter),
+		s.Algorithm,
+		s.ProcessType,
+		s.ProcessData,
+		s.StartTime.UTC().Format(time.RFC3339),
+		s.EndTime.UTC().Format(time.RFC3339),
+	}, "|"))
+}
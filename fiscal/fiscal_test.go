@@ -0,0 +1,50 @@
+package fiscal
+
+import (
+	"gorestserviceagain/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func testOrder() entity.Order {
+	return entity.Order{
+		Model: gorm.Model{ID: 42, CreatedAt: time.Date(2024, 3, 1, 19, 30, 0, 0, time.UTC)},
+		Items: []entity.OrderItem{
+			{DishID: 1, Quantity: 2, Price: 14.5, TaxRate: 19},
+			{DishID: 2, Quantity: 1, Price: 3.5, TaxRate: 7},
+		},
+		DiscountDetail: []entity.DiscountDetail{{OrderID: 42, DishID: 1, Discount: 10}},
+		Payments:       []entity.Payment{{Method: entity.PaymentCash, Amount: 30}},
+	}
+}
+
+func TestProcessData(t *testing.T) {
+	assert.Equal(t, "Beleg^26.10_3.50_0.00_0.00_0.00^30.00:Bar", ProcessData(testOrder(), entity.FiscalPaid))
+	assert.Equal(t, "AVBelegstorno^-26.10_-3.50_0.00_0.00_0.00^-30.00:Bar", ProcessData(testOrder(), entity.FiscalVoided))
+}
+
+func TestSoftwareSigner(t *testing.T) {
+	signer, err := NewSoftwareSigner()
+	require.NoError(t, err)
+
+	first, err := signer.Sign(NewTransaction(testOrder(), entity.FiscalPaid))
+	require.NoError(t, err)
+	second, err := signer.Sign(NewTransaction(testOrder(), entity.FiscalVoided))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), first.TransactionNumber)
+	assert.Equal(t, uint64(2), second.TransactionNumber)
+	assert.Equal(t, first.SignatureCounter+2, second.SignatureCounter)
+	assert.Equal(t, signer.SerialNumber(), first.SerialNumber)
+	assert.Equal(t, ProcessType, first.ProcessType)
+	assert.Equal(t, testOrder().CreatedAt, first.StartTime)
+	assert.NoError(t, Verify(signer.PublicKey(), first))
+	assert.NoError(t, Verify(signer.PublicKey(), second))
+
+	first.ProcessData = "Beleg^0.00_0.00_0.00_0.00_0.00^"
+	assert.ErrorIs(t, Verify(signer.PublicKey(), first), ErrInvalidSignature)
+}
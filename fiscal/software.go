@@ -0,0 +1,99 @@
+package fiscal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"gorestserviceagain/entity"
+	"sync"
+	"time"
+)
+
+const SoftwareAlgorithm = "ecdsa-with-SHA256"
+
+var ErrInvalidSignature = errors.New("invalid fiscal signature")
+
+// SoftwareSigner is a stand-in for a certified TSE for development and tests. It signs
+// with a key that only lives in memory, so its signatures have no legal value.
+type SoftwareSigner struct {
+	mu                sync.Mutex
+	key               *ecdsa.PrivateKey
+	serialNumber      string
+	transactionNumber uint64
+	signatureCounter  uint64
+	now               func() time.Time
+}
+
+func NewSoftwareSigner() (*SoftwareSigner, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	// Like a real TSE, the serial number is the hash of the public key.
+	serial := sha256.Sum256(der)
+	return &SoftwareSigner{key: key, serialNumber: hex.EncodeToString(serial[:]), now: time.Now}, nil
+}
+
+func (s *SoftwareSigner) SerialNumber() string {
+	return s.serialNumber
+}
+
+func (s *SoftwareSigner) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign numbers the transaction and signs it. A TSE signs the start and the end of a
+// transaction, so the signature counter grows by two for every transaction.
+func (s *SoftwareSigner) Sign(tx entity.FiscalTransaction) (entity.FiscalSignature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := tx.StartTime
+	end := s.now()
+	if start.IsZero() {
+		start = end
+	}
+	s.transactionNumber++
+	s.signatureCounter += 2
+	signature := entity.FiscalSignature{
+		OrderID:           tx.OrderID,
+		Kind:              tx.Kind,
+		SerialNumber:      s.serialNumber,
+		TransactionNumber: s.transactionNumber,
+		SignatureCounter:  s.signatureCounter,
+		Algorithm:         SoftwareAlgorithm,
+		ProcessType:       tx.ProcessType,
+		ProcessData:       tx.ProcessData,
+		StartTime:         start,
+		EndTime:           end,
+	}
+	hash := sha256.Sum256(Message(signature))
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	if err != nil {
+		return entity.FiscalSignature{}, err
+	}
+	signature.Signature = base64.StdEncoding.EncodeToString(sig)
+	return signature, nil
+}
+
+// Verify checks a signature made by a SoftwareSigner with the given public key.
+func Verify(key *ecdsa.PublicKey, s entity.FiscalSignature) error {
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return errors.Join(ErrInvalidSignature, err)
+	}
+	hash := sha256.Sum256(Message(s))
+	if !ecdsa.VerifyASN1(key, hash[:], sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
-	"gorestserviceagain/api"
+	"gorestserviceagain/audit"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/fiscal"
 	"gorestserviceagain/postgresdb"
+	"gorestserviceagain/printing"
 	"log"
 	"net/http"
-
-	"github.com/go-chi/chi/v5"
+	"time"
 )
 
 func main() {
@@ -22,12 +25,52 @@ func main() {
 		log.Fatal(nil)
 	}
 	db.Migrate()
+	repo := audit.New(db)
+
+	spooler := printing.NewSpooler(cfg.Printers)
+	spooler.Start()
+	defer spooler.Close()
+
+	var signer entity.FiscalSigner
+	switch cfg.FiscalSigner {
+	case "":
+		fmt.Println("No fiscal signer configured, orders are not signed")
+	case "software":
+		s, err := fiscal.NewSoftwareSigner()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Signing orders with the software TSE", s.SerialNumber())
+		signer = s
+	default:
+		log.Fatalf("unknown fiscal signer %q", cfg.FiscalSigner)
+	}
 
-	r := chi.NewRouter()
+	tokens := auth.Tokens{Secret: []byte(cfg.AuthSecret), TTL: cfg.TokenTTL}
+	if err := createAdmin(repo, cfg); err != nil {
+		log.Fatal(err)
+	}
 
-	r.Route("/orders", api.OrdersController{Repo: db}.RegisterRoutes)
-	r.Route("/dishes", api.DishesController{Repo: db}.RegisterRoutes)
-	api.DiscountDetailController{Repo: db}.RegisterRoutes(r)
+	r, _ := newRouter(repo, cfg, tokens, auth.NewLockout(5, 5*time.Minute), spooler, signer)
 	fmt.Println("Staring serve on", cfg.Port)
 	http.ListenAndServe(":"+cfg.Port, r)
 }
+
+// createAdmin adds the admin from the config when the database has no users yet,
+// so there is someone who can log in and create the other users.
+func createAdmin(db entity.Repo, cfg config) error {
+	users, err := db.GetUsers()
+	if err != nil || len(users) > 0 {
+		return err
+	}
+	if cfg.AdminUsername == "" || cfg.AdminPassword == "" {
+		fmt.Println("No users yet, set ADMIN_USERNAME and ADMIN_PASSWORD in the environment to create an admin")
+		return nil
+	}
+	hash, err := auth.HashPassword(cfg.AdminPassword)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Creating admin", cfg.AdminUsername)
+	return db.CreateUser(&entity.User{Username: cfg.AdminUsername, PasswordHash: hash, Role: entity.RoleAdmin})
+}
@@ -0,0 +1,205 @@
+package menu
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+)
+
+var ErrUnknownFormat = entity.NewError(entity.ErrBadRequest, "unknown menu format")
+
+var csvHeader = []string{"SKU", "Name", "Category", "Price", "TaxRate", "SoldOut"}
+
+// Item is one dish of a menu file. Dishes are matched by their SKU, so a menu can be
+// copied between restaurants where the database ids differ. SoldOut is only exported,
+// it is set by the stock of the ingredients and not imported.
+type Item struct {
+	SKU      string  `yaml:"sku"`
+	Name     string  `yaml:"name"`
+	Category string  `yaml:"category"`
+	Price    float32 `yaml:"price"`
+	TaxRate  float32 `yaml:"taxRate"`
+	SoldOut  bool    `yaml:"soldOut"`
+}
+
+func FromDish(d entity.Dish) Item {
+	return Item{SKU: d.SKU, Name: d.Name, Category: d.Category, Price: d.Price, TaxRate: d.TaxRate, SoldOut: d.SoldOut}
+}
+
+func (i Item) Dish() entity.Dish {
+	return entity.Dish{SKU: i.SKU, Name: i.Name, Category: i.Category, Price: i.Price, TaxRate: i.TaxRate}
+}
+
+// Format maps a file extension or content type to a menu format, or returns "".
+func Format(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(s, "json"):
+		return FormatJSON
+	case strings.HasSuffix(s, "csv"):
+		return FormatCSV
+	case strings.HasSuffix(s, "yaml"), strings.HasSuffix(s, "yml"):
+		return FormatYAML
+	}
+	return ""
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatYAML:
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+func Decode(format string, r io.Reader) (items []Item, err error) {
+	switch format {
+	case FormatJSON:
+		err = json.NewDecoder(r).Decode(&items)
+	case FormatYAML:
+		err = yaml.NewDecoder(r).Decode(&items)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case FormatCSV:
+		items, err = decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entity.ErrBadRequest, err)
+	}
+	return items, nil
+}
+
+func decodeCSV(r io.Reader) ([]Item, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"SKU", "Name", "Price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	items := make([]Item, 0, len(records)-1)
+	for n, record := range records[1:] {
+		item := Item{
+			SKU:      field(record, "SKU"),
+			Name:     field(record, "Name"),
+			Category: field(record, "Category"),
+		}
+		price, err := parseFloat(field(record, "Price"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: Price: %w", n+1, err)
+		}
+		taxRate, err := parseFloat(field(record, "TaxRate"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: TaxRate: %w", n+1, err)
+		}
+		if v := field(record, "SoldOut"); v != "" {
+			item.SoldOut, err = strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: SoldOut: %w", n+1, err)
+			}
+		}
+		item.Price = price
+		item.TaxRate = taxRate
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseFloat(v string) (float32, error) {
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 32)
+	return float32(f), err
+}
+
+func Encode(format string, w io.Writer, items []Item) error {
+	switch format {
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(items)
+	case FormatYAML:
+		e := yaml.NewEncoder(w)
+		defer e.Close()
+		return e.Encode(items)
+	case FormatCSV:
+		c := csv.NewWriter(w)
+		c.Write(csvHeader)
+		for _, i := range items {
+			c.Write([]string{
+				i.SKU, i.Name, i.Category,
+				strconv.FormatFloat(float64(i.Price), 'f', -1, 32),
+				strconv.FormatFloat(float64(i.TaxRate), 'f', -1, 32),
+				strconv.FormatBool(i.SoldOut),
+			})
+		}
+		c.Flush()
+		return c.Error()
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// Validate checks every item and returns one import row per item. Rows are numbered from 1.
+func Validate(items []Item) (rows []entity.ImportRow, ok bool) {
+	ok = true
+	seen := make(map[string]int)
+	for n, i := range items {
+		row := entity.ImportRow{Row: n + 1, SKU: i.SKU}
+		if i.SKU == "" {
+			row.Errors = append(row.Errors, "SKU is required")
+		} else if first, dup := seen[i.SKU]; dup {
+			row.Errors = append(row.Errors, fmt.Sprintf("SKU is already used in row %d", first))
+		} else {
+			seen[i.SKU] = n + 1
+		}
+		if strings.TrimSpace(i.Name) == "" {
+			row.Errors = append(row.Errors, "Name is required")
+		}
+		if i.Price < 0 {
+			row.Errors = append(row.Errors, "Price must not be negative")
+		}
+		if !entity.KnownTaxRate(i.TaxRate) {
+			row.Errors = append(row.Errors, fmt.Sprintf("TaxRate must be one of %v", entity.TaxRates))
+		}
+		if len(row.Errors) > 0 {
+			row.Action = entity.ImportInvalid
+			ok = false
+		}
+		rows = append(rows, row)
+	}
+	return rows, ok
+}
@@ -0,0 +1,24 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	items, err := Decode("csv", strings.NewReader("SKU,Name,Category,Price,TaxRate,SoldOut\nF1,Fries,Sides,\"4,50\",7,true\n"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, Item{SKU: "F1", Name: "Fries", Category: "Sides", Price: 4.5, TaxRate: 7, SoldOut: true}, items[0])
+	assert.False(t, items[0].Dish().SoldOut, "sold out is set by the stock")
+}
+
+func TestValidateTaxRate(t *testing.T) {
+	rows, ok := Validate([]Item{{SKU: "F1", Name: "Fries", TaxRate: 7}, {SKU: "W1", Name: "Water", TaxRate: 16}})
+	assert.False(t, ok)
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, []string{"TaxRate must be one of [19 7 10.7 5.5 0]"}, rows[1].Errors)
+}
@@ -0,0 +1,264 @@
+// Package openapi describes the routes of the controllers as OpenAPI 3 document. The
+// controllers list their routes as operations, the schemas of the bodies are read from
+// the Go types by reflection.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const jsonContentType = "application/json"
+
+// Operation is a route of a controller. Request and Response are values of the body types,
+// nil for routes without a body.
+type Operation struct {
+	Method string
+	// Pattern is the chi pattern relative to where the controller is mounted.
+	Pattern     string
+	Summary     string
+	Description string
+	Query       []Param
+	Request     any
+	Response    any
+	// Status is the status of a successful response, 200 if it is not set.
+	Status int
+	// Consumes and Produces are the content types of bodies which are not JSON.
+	Consumes []string
+	Produces []string
+	// Public operations can be used without login.
+	Public     bool
+	Deprecated bool
+}
+
+// Param is a query parameter, of Type string if it is not set.
+type Param struct {
+	Name        string
+	Description string
+	Type        string
+	Format      string
+	Enum        []string
+}
+
+// Deprecate returns copies of ops which are marked as deprecated.
+func Deprecate(ops []Operation) []Operation {
+	deprecated := make([]Operation, len(ops))
+	for i, op := range ops {
+		op.Deprecated = true
+		deprecated[i] = op
+	}
+	return deprecated
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+	Security   []map[string][]string           `json:"security,omitempty"`
+	Tags       []Tag                           `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// PathItem is the operation of a method on a path.
+type PathItem struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	// Security is empty for public operations, which overrides the security of the document.
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Spec builds the document of the routes added to it.
+type Spec struct {
+	doc     Document
+	schemas *schemas
+	// problems is the content of the error responses of all operations.
+	problems map[string]MediaType
+}
+
+// New returns a spec with bearer authentication for all operations which are not public.
+// Errors are answered with a problem, a value of the body sent as content type problemType.
+func New(title string, version string, problemType string, problem any) *Spec {
+	schemas := newSchemas()
+	return &Spec{
+		doc: Document{
+			OpenAPI: "3.0.3",
+			Info:    Info{Title: title, Version: version},
+			Paths:   make(map[string]map[string]*PathItem),
+			Components: Components{
+				Schemas:         schemas.components,
+				SecuritySchemes: map[string]SecurityScheme{"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}},
+			},
+			Security: []map[string][]string{{"bearer": {}}},
+		},
+		schemas:  schemas,
+		problems: map[string]MediaType{problemType: {Schema: schemas.of(reflect.TypeOf(problem))}},
+	}
+}
+
+// Add describes the operations of a controller mounted at prefix, grouped under tag.
+func (s *Spec) Add(prefix string, tag string, ops []Operation) {
+	if tag != "" && !s.hasTag(tag) {
+		s.doc.Tags = append(s.doc.Tags, Tag{Name: tag})
+	}
+	for _, op := range ops {
+		path := joinPath(prefix, op.Pattern)
+		item := &PathItem{
+			Summary:     op.Summary,
+			Description: op.Description,
+			Parameters:  s.parameters(path, op.Query),
+			Responses:   map[string]*Response{"default": {Description: "Problem", Content: s.problems}},
+			Deprecated:  op.Deprecated,
+		}
+		if tag != "" {
+			item.Tags = []string{tag}
+		}
+		if op.Public {
+			item.Security = &[]map[string][]string{}
+		}
+		if content := s.content(op.Request, op.Consumes); content != nil {
+			item.RequestBody = &RequestBody{Required: true, Content: content}
+		}
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		item.Responses[fmt.Sprint(status)] = &Response{Description: http.StatusText(status), Content: s.content(op.Response, op.Produces)}
+
+		if s.doc.Paths[path] == nil {
+			s.doc.Paths[path] = make(map[string]*PathItem)
+		}
+		s.doc.Paths[path][strings.ToLower(op.Method)] = item
+	}
+}
+
+// Has reports whether the spec has an operation for the method on the chi pattern path.
+func (s *Spec) Has(method string, path string) bool {
+	_, ok := s.doc.Paths[openapiPath(path)][strings.ToLower(method)]
+	return ok
+}
+
+// Document returns the document built so far.
+func (s *Spec) Document() Document {
+	return s.doc
+}
+
+// ServeHTTP sends the document as JSON.
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", jsonContentType)
+	if err := json.NewEncoder(w).Encode(s.doc); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (s *Spec) hasTag(name string) bool {
+	for _, t := range s.doc.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// content returns the content of a body, which is body as JSON and the other content
+// types as binary. It is nil if there is no body.
+func (s *Spec) content(body any, types []string) map[string]MediaType {
+	if body == nil && len(types) == 0 {
+		return nil
+	}
+	content := make(map[string]MediaType)
+	if body != nil {
+		content[jsonContentType] = MediaType{Schema: s.schemas.of(reflect.TypeOf(body))}
+	}
+	for _, t := range types {
+		content[t] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	return content
+}
+
+// parameters returns the parameters of the path and the query. Path parameters named id
+// or ending in Id are integers.
+func (s *Spec) parameters(path string, query []Param) []Parameter {
+	var params []Parameter
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		schema := &Schema{Type: "string"}
+		if m[1] == "id" || strings.HasSuffix(m[1], "Id") {
+			schema = &Schema{Type: "integer", Minimum: ptr(0.)}
+		}
+		params = append(params, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, q := range query {
+		schema := &Schema{Type: q.Type, Format: q.Format, Enum: q.Enum}
+		if schema.Type == "" {
+			schema.Type = "string"
+		}
+		params = append(params, Parameter{Name: q.Name, In: "query", Description: q.Description, Schema: schema})
+	}
+	return params
+}
+
+// pathParam matches the chi path parameters, with an optional regular expression.
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openapiPath drops the regular expressions of the chi path parameters.
+func openapiPath(pattern string) string {
+	return pathParam.ReplaceAllString(pattern, "{$1}")
+}
+
+func joinPath(prefix string, pattern string) string {
+	return openapiPath(prefix + pattern)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,54 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type node struct {
+	gorm.Model
+	Name     string `json:"name"`
+	Secret   string `json:"-"`
+	Parent   *node  `json:"parent,omitempty"`
+	Children []node
+	Seen     *time.Time
+	internal string
+}
+
+func TestSchemas(t *testing.T) {
+	s := newSchemas()
+
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/node"}}, s.of(reflect.TypeFor[[]node]()))
+	assert.Equal(t, &Schema{Type: "object", Properties: map[string]*Schema{
+		"ID":        {Type: "integer", Minimum: ptr(0.)},
+		"CreatedAt": {Type: "string", Format: "date-time"},
+		"UpdatedAt": {Type: "string", Format: "date-time"},
+		"DeletedAt": {Type: "string", Format: "date-time", Nullable: true},
+		"name":      {Type: "string"},
+		"parent":    {Ref: "#/components/schemas/node"},
+		"Children":  {Type: "array", Items: &Schema{Ref: "#/components/schemas/node"}},
+		"Seen":      {Type: "string", Format: "date-time", Nullable: true},
+	}}, s.components["node"])
+}
+
+func TestSpecAdd(t *testing.T) {
+	spec := New("Test", "1", "application/problem+json", struct{ Detail string }{})
+	spec.Add("/nodes", "nodes", []Operation{
+		{Method: http.MethodGet, Pattern: "/{id:[0-9]+}", Response: node{}},
+		{Method: http.MethodPost, Pattern: "/login", Request: node{}, Status: http.StatusNoContent, Public: true},
+	})
+
+	assert.True(t, spec.Has(http.MethodGet, "/nodes/{id:[0-9]+}"))
+	assert.False(t, spec.Has(http.MethodDelete, "/nodes/{id}"))
+	get := spec.Document().Paths["/nodes/{id}"]["get"]
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Minimum: ptr(0.)}}}, get.Parameters)
+	assert.Nil(t, get.Security)
+	post := spec.Document().Paths["/nodes/login"]["post"]
+	assert.Nil(t, post.Responses["204"].Content)
+	assert.Equal(t, &[]map[string][]string{}, post.Security)
+}
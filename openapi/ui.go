@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+var uiPage = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+SwaggerUIBundle({url: {{.URL}}, dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`))
+
+// UI returns the Swagger UI page of the document served at url. The scripts of Swagger UI
+// are loaded from unpkg.
+func UI(title string, url string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := uiPage.Execute(w, struct{ Title, URL string }{title, url})
+		if err != nil {
+			fmt.Println("Can not render Swagger UI", err)
+		}
+	}
+}
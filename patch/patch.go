@@ -0,0 +1,266 @@
+// Package patch applies the patch documents of PATCH requests to the JSON of a resource,
+// JSON Merge Patch of RFC 7386 and JSON Patch of RFC 6902.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = dto.MergePatchContentType
+	JSONPatchContentType  = dto.JSONPatchContentType
+)
+
+var (
+	ErrInvalidPatch = entity.NewError(entity.ErrBadRequest, "invalid patch document")
+	// ErrNotApplicable is a patch which does not fit the resource, e.g. it removes a field
+	// which does not exist or one of its tests fails.
+	ErrNotApplicable = entity.NewError(entity.ErrConflict, "patch can not be applied")
+	ErrContentType   = entity.NewError(entity.ErrBadRequest, "patch content type must be "+MergePatchContentType+" or "+JSONPatchContentType)
+)
+
+// Apply applies the patch of the content type to the JSON document doc.
+func Apply(contentType string, doc []byte, patch []byte) ([]byte, error) {
+	switch contentType {
+	case MergePatchContentType:
+		return Merge(doc, patch)
+	case JSONPatchContentType:
+		return Operations(doc, patch)
+	}
+	return nil, ErrContentType
+}
+
+// Merge applies a merge patch to doc. Members of the patch replace those of doc, objects
+// are merged and null removes a member, which makes it the zero value once decoded.
+func Merge(doc []byte, patch []byte) ([]byte, error) {
+	var d, p any
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(d, p))
+}
+
+func merge(doc any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]any)
+	if !ok {
+		d = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+		} else {
+			d[k] = merge(d[k], v)
+		}
+	}
+	return d
+}
+
+// Operation is one step of a JSON Patch. Path and From are JSON Pointers of RFC 6901.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Operations applies the operations of a JSON Patch to doc, all of them or none.
+func Operations(doc []byte, patch []byte) ([]byte, error) {
+	var d any
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		var err error
+		if d, err = op.apply(d); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(d)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	path, err := pointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: %s needs a value", ErrInvalidPatch, op.Op)
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s is not %s", ErrNotApplicable, op.Path, op.Value)
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := pointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("%w: can not move %s into itself", ErrInvalidPatch, op.From)
+			}
+			doc, value, err = remove(doc, from)
+		} else if value, err = get(doc, from); err == nil {
+			// The copy must not change with the original.
+			data, _ := json.Marshal(value)
+			err = json.Unmarshal(data, &value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+// pointer splits a JSON Pointer into its unescaped reference tokens.
+func pointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// index returns the array index of token, which may be len(a) if end is allowed.
+func index(a []any, token string, end bool) (int, error) {
+	if end && token == "-" {
+		return len(a), nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > len(a) || i == len(a) && !end || token != strconv.Itoa(i) {
+		return 0, fmt.Errorf("%w: no index %s", ErrNotApplicable, token)
+	}
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			v, ok := d[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: no member %s", ErrNotApplicable, token)
+			}
+			doc = v
+		case []any:
+			i, err := index(d, token, false)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("%w: no member %s", ErrNotApplicable, token)
+		}
+	}
+	return doc, nil
+}
+
+// add returns doc with value added at path, the parent of path must exist.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+		return doc, nil
+	case []any:
+		i, err := index(p, last, true)
+		if err != nil {
+			return nil, err
+		}
+		return set(doc, path[:len(path)-1], append(p[:i], append([]any{value}, p[i:]...)...)), nil
+	}
+	return nil, fmt.Errorf("%w: no member %s", ErrNotApplicable, last)
+}
+
+// set returns doc with the value at path, which exists, replaced by value.
+func set(doc any, path []string, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+	parent, _ := get(doc, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+	case []any:
+		i, _ := index(p, last, false)
+		p[i] = value
+	}
+	return doc
+}
+
+// remove returns doc without the value at path, and the value.
+func remove(doc any, path []string) (any, any, error) {
+	value, err := get(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(path) == 0 {
+		return nil, value, nil
+	}
+	parent, _ := get(doc, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		delete(p, last)
+		return doc, value, nil
+	case []any:
+		i, _ := index(p, last, false)
+		return set(doc, path[:len(path)-1], append(p[:i:i], p[i+1:]...)), value, nil
+	}
+	return doc, value, nil
+}
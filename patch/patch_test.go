@@ -0,0 +1,81 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{name: "replace", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "null removes", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "zero value", doc: `{"price":4.5}`, patch: `{"price":0}`, want: `{"price":0}`},
+		{name: "arrays are replaced", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "nested", doc: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"d":null,"f":{"g":null}}}`, want: `{"a":{"b":"c","f":{}}}`},
+		{name: "not an object", doc: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "malformed", doc: `{}`, patch: `{"a":`, err: ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(MergePatchContentType, []byte(tt.doc), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestOperations(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{name: "add member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":null}]`, want: `{"a":1,"b":null}`},
+		{name: "add to array", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/1","value":3},{"op":"add","path":"/a/-","value":4}]`, want: `{"a":[1,3,2,4]}`},
+		{name: "nested arrays", doc: `[[1,2],[3]]`, patch: `[{"op":"remove","path":"/0/0"},{"op":"add","path":"/1/0","value":4}]`, want: `[[2],[4,3]]`},
+		{name: "remove", doc: `{"a":[1,2,3]}`, patch: `[{"op":"remove","path":"/a/1"}]`, want: `{"a":[1,3]}`},
+		{name: "replace", doc: `{"price":4.5,"name":"Fries"}`, patch: `[{"op":"replace","path":"/price","value":0}]`, want: `{"price":0,"name":"Fries"}`},
+		{name: "replace with null", doc: `{"sku":"M-1"}`, patch: `[{"op":"replace","path":"/sku","value":null}]`, want: `{"sku":null}`},
+		{name: "move", doc: `{"a":{"b":1},"c":{}}`, patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`, want: `{"a":{},"c":{"d":1}}`},
+		{name: "copy", doc: `{"a":[1]}`, patch: `[{"op":"copy","from":"/a/0","path":"/b"}]`, want: `{"a":[1],"b":1}`},
+		{name: "copy is deep", doc: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"remove","path":"/c/b"}]`, want: `{"a":{"b":1},"c":{}}`},
+		{name: "escaped", doc: `{"a/b":1,"m~n":2}`, patch: `[{"op":"remove","path":"/a~1b"},{"op":"test","path":"/m~0n","value":2}]`, want: `{"m~n":2}`},
+		{name: "test passes", doc: `{"a":{"b":[1]}}`, patch: `[{"op":"test","path":"/a","value":{"b":[1]}}]`, want: `{"a":{"b":[1]}}`},
+		{name: "test fails", doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":2}]`, err: ErrNotApplicable},
+		{name: "all or nothing", doc: `{"a":1}`, patch: `[{"op":"remove","path":"/a"},{"op":"remove","path":"/a"}]`, err: ErrNotApplicable},
+		{name: "replace missing", doc: `{}`, patch: `[{"op":"replace","path":"/a","value":1}]`, err: ErrNotApplicable},
+		{name: "index out of range", doc: `{"a":[1]}`, patch: `[{"op":"add","path":"/a/2","value":1}]`, err: ErrNotApplicable},
+		{name: "leading zero", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/01"}]`, err: ErrNotApplicable},
+		{name: "move into itself", doc: `{"a":{}}`, patch: `[{"op":"move","from":"/a","path":"/a/b"}]`, err: ErrInvalidPatch},
+		{name: "missing value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, err: ErrInvalidPatch},
+		{name: "unknown operation", doc: `{}`, patch: `[{"op":"merge","path":"/a"}]`, err: ErrInvalidPatch},
+		{name: "relative path", doc: `{}`, patch: `[{"op":"remove","path":"a"}]`, err: ErrInvalidPatch},
+		{name: "not a list", doc: `{}`, patch: `{"a":1}`, err: ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(JSONPatchContentType, []byte(tt.doc), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestApplyContentType(t *testing.T) {
+	_, err := Apply("application/json", []byte(`{}`), []byte(`{}`))
+	assert.ErrorIs(t, err, ErrContentType)
+}
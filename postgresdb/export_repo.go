@@ -0,0 +1,77 @@
+package postgresdb
+
+import (
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const exportQuery = `SELECT 'item' AS record_type, orders.id AS order_id, orders.created_at, orders.table_number, orders.shift_id,
+	order_items.dish_id, dishes.name AS dish_name, order_items.quantity, order_items.price, order_items.tax_rate,
+	COALESCE(discount_details.discount, 0) AS discount, ` + lineRevenue + ` AS amount, '' AS payment_method
+	FROM order_items
+	JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL
+	JOIN dishes ON dishes.id = order_items.dish_id
+	LEFT JOIN discount_details ON discount_details.order_id = order_items.order_id AND discount_details.dish_id = order_items.dish_id
+	WHERE order_items.deleted_at IS NULL AND order_items.status = '' AND orders.created_at >= @from AND orders.created_at < @to
+	UNION ALL
+	SELECT 'payment', orders.id, orders.created_at, orders.table_number, orders.shift_id,
+	0, '', 0, 0, 0, 0, payments.amount, payments.method
+	FROM payments
+	JOIN orders ON orders.id = payments.order_id AND orders.deleted_at IS NULL
+	WHERE payments.deleted_at IS NULL AND orders.created_at >= @from AND orders.created_at < @to
+	ORDER BY order_id, record_type`
+
+// ExportOrders streams the items and payments of all orders placed between from and to
+// row by row to fn, without loading the whole range into memory.
+func (r PostgresDB) ExportOrders(from time.Time, to time.Time, fn func(entity.OrderExportRow) error) error {
+	rows, err := r.db.Raw(exportQuery, map[string]any{"from": from, "to": to}).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row entity.OrderExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// AddSignature stores the signature also for voided orders, so no shift check is done here.
+func (r PostgresDB) AddSignature(signature *entity.FiscalSignature) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(signature).Error; err != nil {
+			return err
+		}
+		return touchOrder(tx, signature.OrderID)
+	})
+}
+
+// ExportSignatures streams all fiscal signatures made between from and to, including
+// the ones of voided orders.
+func (r PostgresDB) ExportSignatures(from time.Time, to time.Time, fn func(entity.FiscalSignature) error) error {
+	rows, err := r.db.Model(&entity.FiscalSignature{}).
+		Where("end_time >= ? AND end_time < ?", from, to).
+		Order("signature_counter").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s entity.FiscalSignature
+		if err := r.db.ScanRows(rows, &s); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
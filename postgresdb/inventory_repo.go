@@ -0,0 +1,275 @@
+package postgresdb
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r PostgresDB) AddOrderItems(orderId uint, items []entity.OrderItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, orderId); err != nil {
+			return err
+		}
+		if err := addItems(tx, orderId, items); err != nil {
+			return err
+		}
+		return touchOrder(tx, orderId)
+	})
+}
+
+// addItems adds the items to the order, charged with the dish price unless the price is
+// overridden, and takes their ingredients out of stock. Sold out dishes can't be ordered.
+func addItems(tx *gorm.DB, orderId uint, items []entity.OrderItem) error {
+	var result *gorm.DB
+	dishIds := make([]uint, 0, len(items))
+	for i := range items {
+		var d entity.Dish
+		result = tx.First(&d, items[i].DishID)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("Dish", items[i].DishID, result.Error)
+		}
+		if d.SoldOut {
+			return fmt.Errorf("%w: %s", entity.ErrDishSoldOut, d.Name)
+		}
+		items[i].OrderID = orderId
+		// A price set by the caller is an override, which the controller authorized.
+		if items[i].Price == 0 {
+			items[i].Price = d.Price
+		}
+		items[i].TaxRate = d.TaxRate
+		dishIds = append(dishIds, d.ID)
+	}
+	var recipe []entity.RecipeItem
+	if err := tx.Where("dish_id IN ?", dishIds).Find(&recipe).Error; err != nil {
+		return err
+	}
+	ingredientIds := make([]uint, 0)
+	for id, quantity := range entity.StockUsage(items, recipe) {
+		result = tx.Model(&entity.Ingredient{}).Where("id = ?", id).
+			Update("stock", gorm.Expr("stock - ?", quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		ingredientIds = append(ingredientIds, id)
+	}
+	if err := tx.Omit(clause.Associations).Create(&items).Error; err != nil {
+		return writeError(err)
+	}
+	return updateSoldOut(tx, ingredientIds)
+}
+
+// AdjustOrderItem voids or comps an order item. The item is kept with the reason, a part
+// of it is split off into an item of its own. Ingredients of a void which is not waste
+// go back into stock.
+func (r PostgresDB) AdjustOrderItem(orderId uint, itemId uint, adjustment entity.ItemAdjustment) (item entity.OrderItem, err error) {
+	if err := adjustment.Validate(); err != nil {
+		return item, err
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, orderId); err != nil {
+			return err
+		}
+		result := tx.Where("order_id = ?", orderId).First(&item, itemId)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("OrderItem", itemId, result.Error)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if !item.Charged() {
+			return entity.ErrItemAdjusted
+		}
+		quantity := adjustment.Quantity
+		if quantity == 0 {
+			quantity = item.Quantity
+		}
+		if quantity > item.Quantity {
+			return fmt.Errorf("%w: only %d of the item were ordered", entity.ErrInvalidData, item.Quantity)
+		}
+		if quantity < item.Quantity {
+			if err := tx.Model(&item).Update("quantity", item.Quantity-quantity).Error; err != nil {
+				return err
+			}
+			item.ID = 0
+			item.CreatedAt = time.Time{}
+		}
+		item.Quantity = quantity
+		item.Status = adjustment.Status
+		item.Reason = adjustment.Reason
+		item.Waste = adjustment.Waste
+		item.AdjustedBy = adjustment.UserID
+		if err := tx.Omit("Dish").Save(&item).Error; err != nil {
+			return err
+		}
+		if err := touchOrder(tx, orderId); err != nil {
+			return err
+		}
+		if adjustment.Status != entity.ItemVoided || adjustment.Waste {
+			return nil
+		}
+		var recipe []entity.RecipeItem
+		if err := tx.Where("dish_id = ?", item.DishID).Find(&recipe).Error; err != nil {
+			return err
+		}
+		ingredientIds := make([]uint, 0)
+		for id, quantity := range entity.StockUsage([]entity.OrderItem{item}, recipe) {
+			result = tx.Model(&entity.Ingredient{}).Where("id = ?", id).
+				Update("stock", gorm.Expr("stock + ?", quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			ingredientIds = append(ingredientIds, id)
+		}
+		return updateSoldOut(tx, ingredientIds)
+	})
+	return item, err
+}
+
+// lowStock is whether any ingredient of the dish in the outer query is at or below its
+// low stock threshold.
+const lowStock = `EXISTS (
+		SELECT 1 FROM recipe_items JOIN ingredients ON ingredients.id = recipe_items.ingredient_id
+		WHERE recipe_items.dish_id = dishes.id AND ingredients.deleted_at IS NULL
+		AND ingredients.stock <= ingredients.low_stock_threshold)`
+
+// updateSoldOut marks every dish using one of the ingredients as sold out while any
+// of its ingredients is at or below the low stock threshold, and available again otherwise.
+// The dishes which change increment their version.
+func updateSoldOut(tx *gorm.DB, ingredientIds []uint) error {
+	if len(ingredientIds) == 0 {
+		return nil
+	}
+	return tx.Exec(`UPDATE dishes SET sold_out = `+lowStock+`, version = version + 1
+		WHERE id IN (SELECT dish_id FROM recipe_items WHERE ingredient_id IN ?)
+		AND sold_out <> `+lowStock, ingredientIds).Error
+}
+
+func (r PostgresDB) CreateIngredient(ingredient *entity.Ingredient) error {
+	result := r.db.Create(ingredient)
+	if result.Error != nil {
+		return writeError(result.Error)
+	}
+	return nil
+}
+
+func (r PostgresDB) GetIngredients() (i []entity.Ingredient, err error) {
+	result := r.db.Find(&i)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return i, nil
+}
+
+func (r PostgresDB) GetIngredient(id uint) (i entity.Ingredient, err error) {
+	result := r.db.First(&i, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return i, entity.WrapRecordNotFoundError("Ingredient", id, result.Error)
+	}
+	return i, result.Error
+}
+
+func (r PostgresDB) UpdateIngredient(ingredient *entity.Ingredient) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(ingredient).Updates(*ingredient)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return entity.WrapRecordNotFoundError("Ingredient", ingredient.ID, gorm.ErrRecordNotFound)
+		}
+		return updateSoldOut(tx, []uint{ingredient.ID})
+	})
+}
+
+func (r PostgresDB) DeleteIngredient(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.Ingredient{}, id)
+		if result.RowsAffected == 0 {
+			return entity.WrapRecordNotFoundError("Ingredient", id, result.Error)
+		}
+		if err := updateSoldOut(tx, []uint{id}); err != nil {
+			return err
+		}
+		return tx.Where("ingredient_id = ?", id).Delete(&entity.RecipeItem{}).Error
+	})
+}
+
+func (r PostgresDB) SetRecipe(dishId uint, recipe []entity.RecipeItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var d entity.Dish
+		result := tx.First(&d, dishId)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("Dish", dishId, result.Error)
+		}
+		if err := tx.Where("dish_id = ?", dishId).Delete(&entity.RecipeItem{}).Error; err != nil {
+			return err
+		}
+		if len(recipe) == 0 {
+			return tx.Model(&d).Where("sold_out = ?", true).
+				Updates(map[string]any{"sold_out": false, "version": gorm.Expr("version + 1")}).Error
+		}
+		ingredientIds := make([]uint, 0, len(recipe))
+		for i := range recipe {
+			var ingredient entity.Ingredient
+			result = tx.First(&ingredient, recipe[i].IngredientID)
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return entity.WrapRecordNotFoundError("Ingredient", recipe[i].IngredientID, result.Error)
+			}
+			recipe[i].DishID = dishId
+			recipe[i].Ingredient = ingredient
+			ingredientIds = append(ingredientIds, ingredient.ID)
+		}
+		if err := tx.Omit("Ingredient").Create(&recipe).Error; err != nil {
+			return writeError(err)
+		}
+		return updateSoldOut(tx, ingredientIds)
+	})
+}
+
+func (r PostgresDB) GetRecipe(dishId uint) (recipe []entity.RecipeItem, err error) {
+	var d entity.Dish
+	result := r.db.First(&d, dishId)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, entity.WrapRecordNotFoundError("Dish", dishId, result.Error)
+	}
+	result = r.db.Preload("Ingredient").Where("dish_id = ?", dishId).Find(&recipe)
+	return recipe, result.Error
+}
+
+func (r PostgresDB) GetInventory() ([]entity.InventoryItem, error) {
+	var ingredients []entity.Ingredient
+	var recipe []entity.RecipeItem
+	if err := r.db.Order("name").Find(&ingredients).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Find(&recipe).Error; err != nil {
+		return nil, err
+	}
+	return entity.NewInventory(ingredients, recipe), nil
+}
+
+func (r PostgresDB) GetMarginReport(from time.Time, to time.Time) (entity.MarginReport, error) {
+	var dishes []entity.Dish
+	var recipe []entity.RecipeItem
+	var sales []entity.DishSales
+	if err := r.db.Order("category, name").Find(&dishes).Error; err != nil {
+		return entity.MarginReport{}, err
+	}
+	if err := r.db.Preload("Ingredient").Find(&recipe).Error; err != nil {
+		return entity.MarginReport{}, err
+	}
+	result := r.salesLines(from, to).
+		Select("order_items.dish_id, SUM(order_items.quantity) AS quantity, SUM(" + lineRevenue + ") AS revenue").
+		Group("order_items.dish_id").
+		Order("revenue DESC").
+		Scan(&sales)
+	if result.Error != nil {
+		return entity.MarginReport{}, result.Error
+	}
+	return entity.NewMarginReport(dishes, recipe, sales, from, to), nil
+}
@@ -0,0 +1,65 @@
+package postgresdb
+
+import (
+	"errors"
+	"gorestserviceagain/entity"
+
+	"gorm.io/gorm"
+)
+
+var errDryRun = errors.New("dry run")
+
+// ImportDishes creates or updates the dishes matched by SKU in one transaction.
+// A dry run reports the same actions but rolls everything back.
+func (r PostgresDB) ImportDishes(dishes []entity.Dish, dryRun bool) ([]entity.ImportRow, error) {
+	rows := make([]entity.ImportRow, 0, len(dishes))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, 0, len(dishes))
+		for n, d := range dishes {
+			row := entity.ImportRow{Row: n + 1, SKU: d.SKU}
+			var existing entity.Dish
+			result := tx.Where("sku = ?", d.SKU).First(&existing)
+			switch {
+			case errors.Is(result.Error, gorm.ErrRecordNotFound):
+				if err := tx.Create(&d).Error; err != nil {
+					return err
+				}
+				row.Action = entity.ImportCreate
+				row.DishID = d.ID
+			case result.Error != nil:
+				return result.Error
+			default:
+				d.Version = existing.Version + 1
+				result = tx.Model(&existing).Select("Name", "Category", "Price", "TaxRate", "Version").Updates(d)
+				if result.Error != nil {
+					return result.Error
+				}
+				row.Action = entity.ImportUpdate
+				row.DishID = existing.ID
+			}
+			rows = append(rows, row)
+			ids = append(ids, row.DishID)
+		}
+		if len(ids) > 0 {
+			if err := indexDishes(tx, ids...); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if dryRun && errors.Is(err, errDryRun) {
+		for i := range rows {
+			if rows[i].Action == entity.ImportCreate {
+				rows[i].DishID = 0
+			}
+		}
+		return rows, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -20,8 +21,10 @@ func newConnection(dsn string) error {
 	if Postgres != nil {
 		return nil
 	}
+	// TranslateError turns constraint violations into gorm errors, see writeError.
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Warn),
+		Logger:         logger.Default.LogMode(logger.Warn),
+		TranslateError: true,
 	})
 	if err != nil {
 		return entity.ErrDBNotConnected
@@ -42,113 +45,227 @@ func NewPostgres(dsn string) (PostgresDB, error) {
 
 func (r PostgresDB) Migrate() error {
 	return errors.Join(
-		r.db.AutoMigrate(&entity.Order{}, &entity.Dish{}, &entity.DiscountDetail{}),
+		r.db.AutoMigrate(&entity.Order{}, &entity.Dish{}, &entity.DiscountDetail{},
+			&entity.OrderItem{}, &entity.Ingredient{}, &entity.RecipeItem{},
+			&entity.Supplier{}, &entity.PurchaseOrder{}, &entity.PurchaseOrderLine{}, &entity.IngredientCost{},
+			&entity.Payment{}, &entity.Shift{}, &entity.ZReport{}, &entity.ZReportPayment{}, &entity.ZReportTax{},
+			&entity.FiscalSignature{}, &entity.User{}, &entity.TimeEntry{}, &entity.AuditEntry{}, &entity.DishTranslation{}),
+		r.migrateSearch(),
 		errors.New("error migrating db schema"),
 	)
 }
 
+// CreateOrder adds the order to the open shift, with its items, which are charged and taken
+// out of stock like by AddOrderItems. Discounts, payments and signatures have their own
+// methods, the ones of the order are not created.
 func (r PostgresDB) CreateOrder(order *entity.Order) error {
-	result := r.db.Create(&order)
-	if errors.Is(result.Error, gorm.ErrInvalidData) {
-		return fmt.Errorf("%w:%w", entity.ErrInvalidData, result.Error)
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		shiftId, err := lockOpenShift(tx)
+		if err != nil {
+			return err
+		}
+		order.ShiftID = shiftId
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return writeError(err)
+		}
+		if len(order.Items) == 0 {
+			return nil
+		}
+		return addItems(tx, order.ID, order.Items)
+	})
 }
 
-func (r PostgresDB) GetOrders() (o []entity.Order, err error) {
-	result := r.db.Find(&o)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("%w:%w", errors.New("oders not found"), result.Error)
+// GetOrders returns the orders matching the query, sorted by id unless the query sorts them.
+func (r PostgresDB) GetOrders(q entity.OrderQuery) (o []entity.Order, err error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	query, err := listPage(orderFilter(r.db, q), q.ListOptions, orderColumns)
+	if err != nil {
+		return nil, err
+	}
+	result := query.Find(&o)
+	if result.Error != nil {
+		return nil, result.Error
 	}
 	return o, nil
 }
 
 func (r PostgresDB) GetOrder(id uint) (o entity.Order, err error) {
 	o.ID = id
-	result := r.db.First(&o, o.ID)
+	result := r.db.Preload("Items.Dish").Preload("DiscountDetail").Preload("Payments").Preload("Signatures").First(&o, o.ID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return o, entity.WrapRecordNotFoundError("Order", id, result.Error)
 	}
 	return o, nil
 }
+
+// UpdateOrder replaces the table number and the final price of an open order, if its
+// version is still o.Version. It increments the version.
 func (r PostgresDB) UpdateOrder(o *entity.Order) error {
-	result := r.db.Model(o).Updates(*o)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return entity.WrapRecordNotFoundError("Order", o.ID, result.Error)
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, o.ID); err != nil {
+			return err
+		}
+		// Selected columns are written even if they are zero, the items have their own routes.
+		version := o.Version
+		o.Version++
+		result := tx.Model(o).Where("version = ?", version).Select(orderColumnsReplaced).Updates(*o)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionError(tx.Model(&entity.Order{}).Where("id = ?", o.ID), "Order", o.ID)
+		}
+		return nil
+	})
 }
 
+// UpdateDiscount replaces the discount of a dish of an open order, if its version is still
+// d.Version. It increments the version of the discount and of the order.
 func (r PostgresDB) UpdateDiscount(d *entity.DiscountDetail) error {
-	result := r.db.Model(d).Updates(*d)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return fmt.Errorf("OrderId or dishId can not be found, can not update")
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, d.OrderID); err != nil {
+			return err
+		}
+		version := d.Version
+		d.Version++
+		discount := func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&entity.DiscountDetail{}).Where("order_id = ? AND dish_id = ?", d.OrderID, d.DishID)
+		}
+		result := discount(tx).Where("version = ?", version).Select("Discount", "UserID", "Version").Updates(*d)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionError(discount(tx), "Discount", fmt.Sprintf("%d/%d", d.OrderID, d.DishID))
+		}
+		return touchOrder(tx, d.OrderID)
+	})
 }
-func (r PostgresDB) DeleteOrder(id uint) error {
-	var o entity.Order
-	result := r.db.Delete(&o, id)
-	if result.RowsAffected == 0 {
-		return entity.WrapRecordNotFoundError("Order", id, result.Error)
-	}
-	return nil
+
+// DeleteOrder deletes an open order, if its version is still version.
+func (r PostgresDB) DeleteOrder(id uint, version uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, id); err != nil {
+			return err
+		}
+		var o entity.Order
+		result := tx.Where("version = ?", version).Delete(&o, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionError(tx.Model(&entity.Order{}).Where("id = ?", id), "Order", id)
+		}
+		return nil
+	})
 }
 
 func (r PostgresDB) CreateDish(dish *entity.Dish) error {
-	result := r.db.Create(&dish)
-	if errors.Is(result.Error, gorm.ErrInvalidData) {
-		return fmt.Errorf("%w:%w", entity.ErrInvalidData, result.Error)
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(&dish)
+		if result.Error != nil {
+			return writeError(result.Error)
+		}
+		return indexDishes(tx, dish.ID)
+	})
 }
 
-func (r PostgresDB) GetDishes() (d []entity.Dish, err error) {
-	result := r.db.Find(&d)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return d, fmt.Errorf("%w:%w", entity.ErrRecordNotFound, result.Error)
+// GetDishes returns the dishes matching the query, sorted by id unless the query sorts them.
+func (r PostgresDB) GetDishes(q entity.DishQuery) (d []entity.Dish, err error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	query, err := listPage(dishFilter(r.db, q), q.ListOptions, dishColumns)
+	if err != nil {
+		return nil, err
+	}
+	result := query.Find(&d)
+	if result.Error != nil {
+		return nil, result.Error
 	}
 	return d, nil
 }
 
 func (r PostgresDB) GetDish(id uint) (d entity.Dish, err error) {
 	d.ID = id
-	result := r.db.First(&d, d.ID)
+	result := r.db.Preload("Translations").First(&d, d.ID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return d, entity.WrapRecordNotFoundError("Dish", id, result.Error)
 	}
 	return d, nil
 }
 
+// UpdateDish replaces the dish, its fields but SoldOut and its translations, if its version is
+// still dish.Version. It increments the version.
 func (r PostgresDB) UpdateDish(dish *entity.Dish) error {
-	result := r.db.Model(dish).Updates(*dish)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return entity.WrapRecordNotFoundError("Dish", dish.ID, result.Error)
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		version := dish.Version
+		dish.Version++
+		result := tx.Model(dish).Where("version = ?", version).Select(dishColumnsReplaced).Updates(*dish)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionError(tx.Model(&entity.Dish{}).Where("id = ?", dish.ID), "Dish", dish.ID)
+		}
+		if err := tx.Where("dish_id = ?", dish.ID).Delete(&entity.DishTranslation{}).Error; err != nil {
+			return err
+		}
+		for i := range dish.Translations {
+			dish.Translations[i].ID = 0
+			dish.Translations[i].DishID = dish.ID
+		}
+		if len(dish.Translations) > 0 {
+			if err := tx.Create(&dish.Translations).Error; err != nil {
+				return writeError(err)
+			}
+		}
+		return indexDishes(tx, dish.ID)
+	})
 }
 
-func (r PostgresDB) DeleteDish(id uint) error {
-	var dish entity.Dish
-	result := r.db.Delete(&dish, id)
-	if result.RowsAffected == 0 {
-		return entity.WrapRecordNotFoundError("Dish", id, result.Error)
-	}
-	return nil
+// DeleteDish deletes a dish, if its version is still version.
+func (r PostgresDB) DeleteDish(id uint, version uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var dish entity.Dish
+		result := tx.Where("version = ?", version).Delete(&dish, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionError(tx.Model(&entity.Dish{}).Where("id = ?", id), "Dish", id)
+		}
+		return indexDishes(tx, id)
+	})
 }
 
+// CreateDiscount adds the discount of a dish of an open order, which can only have one.
 func (r PostgresDB) CreateDiscount(price *entity.DiscountDetail) error {
-	result := r.db.Create(&price)
-	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
-		return fmt.Errorf("%s:%w", "the discount price with the same orderId and dishIs has existed", result.Error)
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, price.OrderID); err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&entity.DiscountDetail{}).Where("order_id = ? AND dish_id = ?", price.OrderID, price.DishID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: order %d, dish %d", entity.ErrDiscountExists, price.OrderID, price.DishID)
+		}
+		if err := tx.Create(price).Error; err != nil {
+			return err
+		}
+		return touchOrder(tx, price.OrderID)
+	})
 }
 
 func (r PostgresDB) GetPriceAfterDiscount(orderId uint, dishId uint) (discountDetail entity.DiscountDetail, err error) {
-	result := r.db.Joins("Dish").Joins("Order").Where(&discountDetail).First(&discountDetail)
+	result := r.db.Joins("Dish").Joins("Order").Where(&entity.DiscountDetail{OrderID: orderId, DishID: dishId}).First(&discountDetail)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return discountDetail, fmt.Errorf("orderId or itemId does not exist, just ne delete")
+		return discountDetail, entity.WrapRecordNotFoundError("Discount", fmt.Sprintf("%d/%d", orderId, dishId), result.Error)
 	}
-	return discountDetail, nil
+	return discountDetail, result.Error
 }
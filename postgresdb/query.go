@@ -0,0 +1,142 @@
+package postgresdb
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The fields lists can be sorted by, with their columns.
+var (
+	orderColumns = map[string]string{"id": "id", "createdAt": "created_at", "tableNumber": "table_number", "finalPrice": "final_price"}
+	dishColumns  = map[string]string{"id": "id", "name": "name", "category": "category", "price": "price", "createdAt": "created_at"}
+)
+
+// The fields an update replaces, which are written even if they are zero, and the
+// incremented version. The others are set by the server or have their own routes, like
+// SoldOut, which follows the stock of the ingredients, see updateSoldOut.
+var (
+	orderColumnsReplaced = []string{"TableNumber", "FinalPrice", "Version"}
+	dishColumnsReplaced  = []string{"SKU", "Name", "Description", "Category", "Price", "TaxRate", "Version"}
+)
+
+// versionError tells why an update or delete of the record query finds changed no row,
+// either the record is gone or it has another version. kind and id name the record.
+func versionError(query *gorm.DB, kind string, id any) error {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return entity.WrapRecordNotFoundError(kind, id, gorm.ErrRecordNotFound)
+	}
+	return entity.ErrVersionMismatch
+}
+
+// touchOrder increments the version of the order whose items, payments, discounts or
+// signatures changed, as they are part of the order its version and ETag stand for.
+func touchOrder(tx *gorm.DB, id uint) error {
+	return tx.Model(&entity.Order{}).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// orderTotal and orderPaid compute the amount due and paid of the order in the outer query.
+const (
+	orderTotal = "(SELECT COALESCE(SUM(" + lineRevenue + "), 0) FROM order_items " +
+		"LEFT JOIN discount_details ON discount_details.order_id = order_items.order_id AND discount_details.dish_id = order_items.dish_id " +
+		"WHERE order_items.order_id = orders.id AND order_items.deleted_at IS NULL AND " + charged + ")"
+	orderPaid = "(SELECT COALESCE(SUM(payments.amount), 0) FROM payments " +
+		"WHERE payments.order_id = orders.id AND payments.deleted_at IS NULL)"
+	// paidInFull allows for rounding of the float amounts.
+	paidInFull = orderTotal + " > 0 AND " + orderPaid + " >= " + orderTotal + " - 0.005"
+)
+
+// writeError classifies the errors of a write which the client caused, by referring to a
+// record which does not exist or repeating a unique key. Other errors, like a locked
+// database or a lost connection, are returned as they are and answered as internal errors.
+func writeError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return entity.ErrReferenceNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return entity.ErrDuplicateKey
+	}
+	return err
+}
+
+// listPage sorts and pages a list query. Without a sort the rows are sorted by id.
+func listPage(query *gorm.DB, o entity.ListOptions, columns map[string]string) (*gorm.DB, error) {
+	fields, desc := o.SortFields()
+	if len(fields) == 0 {
+		fields, desc = []string{"id"}, []bool{false}
+	}
+	for i, f := range fields {
+		column, ok := columns[f]
+		if !ok {
+			return nil, fmt.Errorf("%w: can not sort by %q", entity.ErrBadRequest, f)
+		}
+		if desc[i] {
+			column += " DESC"
+		}
+		query = query.Order(column)
+	}
+	if o.After != 0 {
+		if desc[0] {
+			query = query.Where("id < ?", o.After)
+		} else {
+			query = query.Where("id > ?", o.After)
+		}
+	}
+	if o.Limit > 0 {
+		query = query.Limit(o.Limit)
+	}
+	if o.Offset > 0 {
+		query = query.Offset(o.Offset)
+	}
+	return query, nil
+}
+
+func orderFilter(db *gorm.DB, q entity.OrderQuery) *gorm.DB {
+	query := db.Model(&entity.Order{})
+	switch q.Status {
+	case entity.OrderOpen:
+		query = query.Where("NOT (" + paidInFull + ")")
+	case entity.OrderPaid:
+		query = query.Where(paidInFull)
+	case entity.OrderVoided:
+		query = query.Unscoped().Where("orders.deleted_at IS NOT NULL")
+	}
+	if q.TableNumber != nil {
+		query = query.Where("table_number = ?", *q.TableNumber)
+	}
+	if !q.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", q.CreatedFrom)
+	}
+	if !q.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", q.CreatedTo)
+	}
+	return query
+}
+
+func dishFilter(db *gorm.DB, q entity.DishQuery) *gorm.DB {
+	query := db.Model(&entity.Dish{})
+	if q.Name != "" {
+		query = query.Where("LOWER(name) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(q.Name))+"%")
+	}
+	if q.Category != "" {
+		query = query.Where("category = ?", q.Category)
+	}
+	if q.PriceMin != nil {
+		query = query.Where("price >= ?", *q.PriceMin)
+	}
+	if q.PriceMax != nil {
+		query = query.Where("price <= ?", *q.PriceMax)
+	}
+	return query
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
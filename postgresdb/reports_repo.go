@@ -0,0 +1,112 @@
+package postgresdb
+
+import (
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const lineRevenue = "order_items.quantity * order_items.price * (100 - COALESCE(discount_details.discount, 0)) / 100"
+const lineDiscount = "order_items.quantity * order_items.price * COALESCE(discount_details.discount, 0) / 100"
+
+// charged leaves out voided and comped items, they are reported with adjustmentLines.
+const charged = "order_items.status = ''"
+
+// salesLines selects every charged order item of the orders placed between from and to,
+// joined with its order and discount.
+func (r PostgresDB) salesLines(from time.Time, to time.Time) *gorm.DB {
+	return r.db.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("LEFT JOIN discount_details ON discount_details.order_id = order_items.order_id AND discount_details.dish_id = order_items.dish_id").
+		Where("order_items.deleted_at IS NULL AND orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where(charged)
+}
+
+// adjustmentLines selects the voided and comped items of the orders placed between from and to.
+func (r PostgresDB) adjustmentLines(from time.Time, to time.Time) *gorm.DB {
+	return r.db.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.deleted_at IS NULL AND orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("order_items.status <> ''")
+}
+
+func (r PostgresDB) GetSalesSummary(from time.Time, to time.Time) (s entity.SalesSummary, err error) {
+	result := r.salesLines(from, to).
+		Select("COUNT(DISTINCT orders.id) AS orders, COALESCE(SUM(order_items.quantity), 0) AS items, " +
+			"COALESCE(SUM(" + lineRevenue + "), 0) AS revenue, COALESCE(SUM(" + lineDiscount + "), 0) AS discounts").
+		Scan(&s)
+	if result.Error != nil {
+		return s, result.Error
+	}
+	var adjustments []entity.AdjustmentSales
+	result = r.adjustmentLines(from, to).
+		Select("order_items.status, COALESCE(SUM(order_items.quantity * order_items.price), 0) AS amount").
+		Group("order_items.status").
+		Scan(&adjustments)
+	if result.Error != nil {
+		return s, result.Error
+	}
+	for _, a := range adjustments {
+		if a.Status == entity.ItemVoided {
+			s.Voids = a.Amount
+		} else {
+			s.Comps = a.Amount
+		}
+	}
+	s.From = from
+	s.To = to
+	if s.Orders > 0 {
+		s.AverageCheck = s.Revenue / float32(s.Orders)
+	}
+	return s, nil
+}
+
+func (r PostgresDB) GetTopDishes(from time.Time, to time.Time, limit int) (d []entity.TopDish, err error) {
+	result := r.salesLines(from, to).
+		Joins("JOIN dishes ON dishes.id = order_items.dish_id").
+		Select("order_items.dish_id, dishes.name, SUM(order_items.quantity) AS quantity, SUM(" + lineRevenue + ") AS revenue").
+		Group("order_items.dish_id, dishes.name").
+		Order("quantity DESC, revenue DESC").
+		Limit(limit).
+		Scan(&d)
+	return d, result.Error
+}
+
+func (r PostgresDB) GetSalesByTable(from time.Time, to time.Time) (t []entity.TableSales, err error) {
+	result := r.salesLines(from, to).
+		Select("orders.table_number, COUNT(DISTINCT orders.id) AS orders, SUM(" + lineRevenue + ") AS revenue").
+		Group("orders.table_number").
+		Order("orders.table_number").
+		Scan(&t)
+	return t, result.Error
+}
+
+func (r PostgresDB) GetSalesByHour(from time.Time, to time.Time) ([]entity.PeriodSales, error) {
+	return r.salesByPeriod(from, to, "CAST(EXTRACT(HOUR FROM orders.created_at) AS INTEGER)")
+}
+
+func (r PostgresDB) GetSalesByWeekday(from time.Time, to time.Time) ([]entity.PeriodSales, error) {
+	return r.salesByPeriod(from, to, "CAST(EXTRACT(DOW FROM orders.created_at) AS INTEGER)")
+}
+
+func (r PostgresDB) salesByPeriod(from time.Time, to time.Time, period string) (p []entity.PeriodSales, err error) {
+	result := r.salesLines(from, to).
+		Select(period + " AS period, COUNT(DISTINCT orders.id) AS orders, SUM(" + lineRevenue + ") AS revenue").
+		Group("period").
+		Order("period").
+		Scan(&p)
+	return p, result.Error
+}
+
+// GetAdjustments sums up the voided and comped items per reason, valued at their price.
+func (r PostgresDB) GetAdjustments(from time.Time, to time.Time) (a []entity.AdjustmentSales, err error) {
+	result := r.adjustmentLines(from, to).
+		Select("order_items.status, order_items.reason, SUM(order_items.quantity) AS items, " +
+			"SUM(order_items.quantity * order_items.price) AS amount, " +
+			"SUM(CASE WHEN order_items.waste THEN order_items.quantity ELSE 0 END) AS wasted").
+		Group("order_items.status, order_items.reason").
+		Order("order_items.status, amount DESC").
+		Scan(&a)
+	return a, result.Error
+}
@@ -0,0 +1,133 @@
+package postgresdb
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The dishes are searched in the dish_search table, which holds the search document of
+// every dish with its tsvector. Typos are found with trigrams if pg_trgm is installed.
+func (r PostgresDB) migrateSearch() error {
+	err := r.db.Exec(`CREATE TABLE IF NOT EXISTS dish_search (
+		dish_id bigint PRIMARY KEY,
+		document text NOT NULL,
+		tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', document)) STORED)`).Error
+	if err != nil {
+		return err
+	}
+	if err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_dish_search_tsv ON dish_search USING GIN (tsv)`).Error; err != nil {
+		return err
+	}
+	// Creating the extension needs more rights than the app may have, searching works without it.
+	err = r.db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error
+	if err == nil {
+		err = r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_dish_search_trgm ON dish_search USING GIN (document gin_trgm_ops)`).Error
+	}
+	if err != nil {
+		fmt.Println("pg_trgm is not available, typos are matched without it:", err)
+	}
+	return indexDishes(r.db)
+}
+
+func (r PostgresDB) trigrams() bool {
+	var installed bool
+	r.db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&installed)
+	return installed
+}
+
+// SearchDishes finds the dishes whose words start with the search terms, best matches
+// first. If there are none, it looks for dishes matching the terms with a few typos.
+func (r PostgresDB) SearchDishes(text string, limit int) ([]entity.Dish, error) {
+	terms := entity.SearchTerms(text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: nothing to search for", entity.ErrBadRequest)
+	}
+	prefixes := make([]string, len(terms))
+	for i, t := range terms {
+		prefixes[i] = t + ":*"
+	}
+	var ids []uint
+	result := r.db.Raw(`SELECT dish_id FROM dish_search, to_tsquery('simple', @query) query
+		WHERE tsv @@ query ORDER BY ts_rank(tsv, query) DESC, dish_id LIMIT @limit`,
+		map[string]any{"query": strings.Join(prefixes, " & "), "limit": limit}).Scan(&ids)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(ids) == 0 {
+		if !r.trigrams() {
+			return fuzzySearch(r.db, terms, limit)
+		}
+		result = r.db.Raw(`SELECT dish_id FROM dish_search WHERE word_similarity(@text, document) > 0.4
+			ORDER BY word_similarity(@text, document) DESC, dish_id LIMIT @limit`,
+			map[string]any{"text": strings.Join(terms, " "), "limit": limit}).Scan(&ids)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+	return dishesByIds(r.db, ids)
+}
+
+// indexDishes updates the search documents of the dishes, or of all dishes without ids.
+func indexDishes(tx *gorm.DB, ids ...uint) error {
+	var dishes []entity.Dish
+	query := tx.Preload("Translations")
+	var err error
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+		err = tx.Exec("DELETE FROM dish_search WHERE dish_id IN ?", ids).Error
+	} else {
+		err = tx.Exec("DELETE FROM dish_search").Error
+	}
+	if err != nil {
+		return err
+	}
+	if err := query.Find(&dishes).Error; err != nil {
+		return err
+	}
+	for _, d := range dishes {
+		err := tx.Exec("INSERT INTO dish_search (dish_id, document) VALUES (?, ?)", d.ID, d.SearchDocument()).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dishesByIds loads the dishes in the order of ids.
+func dishesByIds(tx *gorm.DB, ids []uint) ([]entity.Dish, error) {
+	var dishes []entity.Dish
+	if err := tx.Preload("Translations").Where("id IN ?", ids).Find(&dishes).Error; err != nil {
+		return nil, err
+	}
+	position := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+	sort.Slice(dishes, func(i, j int) bool { return position[dishes[i].ID] < position[dishes[j].ID] })
+	return dishes, nil
+}
+
+// fuzzySearch scores every dish with entity.FuzzyScore, a menu is small enough for that.
+func fuzzySearch(tx *gorm.DB, terms []string, limit int) ([]entity.Dish, error) {
+	var dishes []entity.Dish
+	if err := tx.Preload("Translations").Find(&dishes).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[uint]float64)
+	found := make([]entity.Dish, 0)
+	for _, d := range dishes {
+		if score := entity.FuzzyScore(terms, d.SearchDocument()); score > 0 {
+			scores[d.ID] = score
+			found = append(found, d)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return scores[found[i].ID] > scores[found[j].ID] })
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
@@ -0,0 +1,195 @@
+package postgresdb
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkOrderOpen makes sure the order exists and its shift has not been closed yet. It
+// has to run in the transaction that changes the order: it locks the shift, so CloseShift
+// waits until the change is committed and includes it in the Z-report. SQLite locks the
+// whole database instead. Orders without a shift are in no Z-report and can't be changed.
+func checkOrderOpen(tx *gorm.DB, orderId uint) error {
+	var o entity.Order
+	result := tx.First(&o, orderId)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return entity.WrapRecordNotFoundError("Order", orderId, result.Error)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if o.ShiftID == 0 {
+		return fmt.Errorf("%w: order %d has no shift", entity.ErrShiftClosed, orderId)
+	}
+	var s entity.Shift
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&s, o.ShiftID).Error; err != nil {
+		return err
+	}
+	if s.IsClosed() {
+		return fmt.Errorf("%w: order %d", entity.ErrShiftClosed, orderId)
+	}
+	return nil
+}
+
+// lockOpenShift returns the id of the open shift and locks it like checkOrderOpen, for the
+// transaction which adds an order to it.
+func lockOpenShift(tx *gorm.DB) (uint, error) {
+	var s entity.Shift
+	result := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("closed_at IS NULL").Order("id DESC").First(&s)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return 0, entity.ErrNoShiftOpen
+	}
+	return s.ID, result.Error
+}
+
+// openShiftId is the id of the currently open shift, or 0 if there is none.
+func openShiftId(tx *gorm.DB) uint {
+	var s entity.Shift
+	if err := tx.Where("closed_at IS NULL").Order("id DESC").First(&s).Error; err != nil {
+		return 0
+	}
+	return s.ID
+}
+
+func (r PostgresDB) AddPayment(payment *entity.Payment) error {
+	if err := payment.Validate(); err != nil {
+		return err
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOrderOpen(tx, payment.OrderID); err != nil {
+			return err
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return writeError(err)
+		}
+		return touchOrder(tx, payment.OrderID)
+	})
+}
+
+func (r PostgresDB) OpenShift(shift *entity.Shift) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if openShiftId(tx) != 0 {
+			return entity.ErrShiftOpen
+		}
+		now := time.Now()
+		shift.OpenedAt = now
+		shift.ClosedAt = nil
+		if shift.BusinessDate.IsZero() {
+			shift.BusinessDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		}
+		if err := tx.Create(shift).Error; err != nil {
+			return writeError(err)
+		}
+		return nil
+	})
+}
+
+func (r PostgresDB) GetShifts() (s []entity.Shift, err error) {
+	result := r.db.Order("opened_at DESC").Find(&s)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return s, nil
+}
+
+func (r PostgresDB) GetShift(id uint) (s entity.Shift, err error) {
+	result := r.db.First(&s, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return s, entity.WrapRecordNotFoundError("Shift", id, result.Error)
+	}
+	return s, result.Error
+}
+
+// shiftLines selects the charged order items of all orders of a shift, or of its deleted orders.
+func shiftLines(tx *gorm.DB, shiftId uint, deleted bool) *gorm.DB {
+	orders := "JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL"
+	if deleted {
+		orders = "JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NOT NULL"
+	}
+	return tx.Table("order_items").
+		Joins(orders).
+		Joins("LEFT JOIN discount_details ON discount_details.order_id = order_items.order_id AND discount_details.dish_id = order_items.dish_id").
+		Where("order_items.deleted_at IS NULL AND orders.shift_id = ?", shiftId).
+		Where(charged)
+}
+
+func (r PostgresDB) CloseShift(id uint, countedCash float32) (z entity.ZReport, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var s entity.Shift
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, id)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("Shift", id, result.Error)
+		}
+		if s.IsClosed() {
+			return entity.ErrShiftClosed
+		}
+
+		var sales, voids entity.SalesSummary
+		var payments []entity.ZReportPayment
+		var taxes []entity.ZReportTax
+		totals := "COUNT(DISTINCT orders.id) AS orders, COALESCE(SUM(order_items.quantity), 0) AS items, " +
+			"COALESCE(SUM(" + lineRevenue + "), 0) AS revenue, COALESCE(SUM(" + lineDiscount + "), 0) AS discounts"
+		if err := shiftLines(tx, id, false).Select(totals).Scan(&sales).Error; err != nil {
+			return err
+		}
+		if err := shiftLines(tx, id, true).Select(totals).Scan(&voids).Error; err != nil {
+			return err
+		}
+		var adjustments []entity.AdjustmentSales
+		result = tx.Table("order_items").
+			Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+			Where("order_items.deleted_at IS NULL AND order_items.status <> '' AND orders.shift_id = ?", id).
+			Select("order_items.status, SUM(order_items.quantity * order_items.price) AS amount").
+			Group("order_items.status").
+			Scan(&adjustments)
+		if result.Error != nil {
+			return result.Error
+		}
+		for _, a := range adjustments {
+			if a.Status == entity.ItemVoided {
+				sales.Voids = a.Amount
+			} else {
+				sales.Comps = a.Amount
+			}
+		}
+		result = shiftLines(tx, id, false).
+			Select("order_items.tax_rate AS rate, SUM(" + lineRevenue + ") AS gross").
+			Group("order_items.tax_rate").
+			Order("order_items.tax_rate").
+			Scan(&taxes)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Table("payments").
+			Joins("JOIN orders ON orders.id = payments.order_id AND orders.deleted_at IS NULL").
+			Where("payments.deleted_at IS NULL AND orders.shift_id = ?", id).
+			Select("payments.method, COUNT(*) AS count, SUM(payments.amount) AS amount").
+			Group("payments.method").
+			Order("payments.method").
+			Scan(&payments)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		now := time.Now()
+		z = entity.NewZReport(s, now, countedCash, sales, voids, payments, taxes)
+		if err := tx.Create(&z).Error; err != nil {
+			return err
+		}
+		return tx.Model(&s).Update("closed_at", now).Error
+	})
+	return z, err
+}
+
+func (r PostgresDB) GetZReport(shiftId uint) (z entity.ZReport, err error) {
+	result := r.db.Preload("Payments").Preload("Taxes").Where("shift_id = ?", shiftId).First(&z)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return z, entity.WrapRecordNotFoundError("ZReport", shiftId, result.Error)
+	}
+	return z, result.Error
+}
@@ -0,0 +1,113 @@
+package printing
+
+import (
+	"bytes"
+	"fmt"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/receipt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ESC/POS commands understood by Epson compatible thermal printers.
+var (
+	cmdInit       = []byte{0x1b, '@'}
+	cmdCodePage   = []byte{0x1b, 't', 16} // WPC1252, matches the text encoding below
+	cmdBoldOn     = []byte{0x1b, 'E', 1}
+	cmdBoldOff    = []byte{0x1b, 'E', 0}
+	cmdAlignLeft  = []byte{0x1b, 'a', 0}
+	cmdAlignCent  = []byte{0x1b, 'a', 1}
+	cmdSizeNormal = []byte{0x1d, '!', 0x00}
+	cmdSizeDouble = []byte{0x1d, '!', 0x11}
+	cmdCut        = []byte{0x1d, 'V', 66, 0}
+)
+
+// Document builds an ESC/POS byte stream.
+type Document struct {
+	buf bytes.Buffer
+}
+
+func NewDocument() *Document {
+	d := &Document{}
+	d.buf.Write(cmdInit)
+	d.buf.Write(cmdCodePage)
+	return d
+}
+
+func (d *Document) Bold(on bool) *Document {
+	if on {
+		d.buf.Write(cmdBoldOn)
+	} else {
+		d.buf.Write(cmdBoldOff)
+	}
+	return d
+}
+
+func (d *Document) Center(on bool) *Document {
+	if on {
+		d.buf.Write(cmdAlignCent)
+	} else {
+		d.buf.Write(cmdAlignLeft)
+	}
+	return d
+}
+
+func (d *Document) Double(on bool) *Document {
+	if on {
+		d.buf.Write(cmdSizeDouble)
+	} else {
+		d.buf.Write(cmdSizeNormal)
+	}
+	return d
+}
+
+// Line prints one line of text. Characters the printer code page lacks are printed as '?'.
+func (d *Document) Line(s string) *Document {
+	for _, r := range s {
+		if b, ok := charmap.Windows1252.EncodeRune(r); ok {
+			d.buf.WriteByte(b)
+		} else {
+			d.buf.WriteByte('?')
+		}
+	}
+	d.buf.WriteByte('\n')
+	return d
+}
+
+// Cut feeds the paper past the cutter and cuts it.
+func (d *Document) Cut() *Document {
+	d.buf.Write(cmdCut)
+	return d
+}
+
+func (d *Document) Bytes() []byte {
+	return d.buf.Bytes()
+}
+
+// KitchenTicket prints the items of an order for the kitchen in large letters.
+// The items need their dish loaded.
+func KitchenTicket(order entity.Order, items []entity.OrderItem, columns int) []byte {
+	d := NewDocument()
+	d.Center(true).Double(true).Bold(true).Line(fmt.Sprintf("TABLE %d", order.TableNumber)).Bold(false).Double(false)
+	d.Line(fmt.Sprintf("Order %d  %s", order.ID, order.UpdatedAt.Format("15:04")))
+	d.Center(false).Line(strings.Repeat("=", columns))
+	d.Double(true)
+	for _, item := range items {
+		d.Line(fmt.Sprintf("%d x %s", item.Quantity, item.Dish.Name))
+	}
+	d.Double(false).Line(strings.Repeat("=", columns)).Line("").Cut()
+	return d.Bytes()
+}
+
+// Receipt prints the guest receipt with the layout of the text receipt.
+func Receipt(r receipt.Receipt, columns int) []byte {
+	d := NewDocument()
+	lines := r.Layout(columns)
+	d.Bold(true).Line(lines[0]).Bold(false)
+	for _, l := range lines[1:] {
+		d.Line(l)
+	}
+	d.Line("").Cut()
+	return d.Bytes()
+}
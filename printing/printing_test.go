@@ -0,0 +1,101 @@
+package printing
+
+import (
+	"bytes"
+	"gorestserviceagain/entity"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// standIn is a local TCP printer that hands every print job it receives to jobs.
+func standIn(t *testing.T, addr string) (net.Listener, chan []byte) {
+	t.Helper()
+	l, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	jobs := make(chan []byte, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			jobs <- data
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return l, jobs
+}
+
+func receive(t *testing.T, jobs chan []byte) []byte {
+	t.Helper()
+	select {
+	case data := <-jobs:
+		return data
+	case <-time.After(2 * time.Second):
+		t.Fatal("printer did not receive a job")
+		return nil
+	}
+}
+
+func TestParsePrinters(t *testing.T) {
+	printers, err := ParsePrinters("kitchen=10.0.0.5:9100, counter=10.0.0.6:9100/42")
+	require.NoError(t, err)
+	assert.Equal(t, []Printer{
+		{Name: "kitchen", Addr: "10.0.0.5:9100", Columns: DefaultColumns},
+		{Name: "counter", Addr: "10.0.0.6:9100", Columns: 42},
+	}, printers)
+
+	_, err = ParsePrinters("kitchen")
+	assert.Error(t, err)
+	printers, err = ParsePrinters("")
+	assert.NoError(t, err)
+	assert.Empty(t, printers)
+}
+
+func TestKitchenTicket(t *testing.T) {
+	order := entity.Order{Model: gorm.Model{ID: 7}, TableNumber: 3}
+	items := []entity.OrderItem{{Quantity: 2, Dish: entity.Dish{Name: "Käsespätzle"}}}
+
+	data := KitchenTicket(order, items, 32)
+
+	assert.True(t, bytes.HasPrefix(data, cmdInit))
+	assert.True(t, bytes.HasSuffix(data, cmdCut))
+	assert.Contains(t, string(data), "TABLE 3")
+	assert.Contains(t, string(data), "2 x K\xe4sesp\xe4tzle\n")
+}
+
+func TestSpoolerPrints(t *testing.T) {
+	l, jobs := standIn(t, "127.0.0.1:0")
+	s := NewSpooler([]Printer{{Name: "kitchen", Addr: l.Addr().String(), Columns: 32}})
+	s.Start()
+	defer s.Close()
+
+	require.NoError(t, s.Submit("kitchen", []byte("ticket")))
+	assert.Equal(t, []byte("ticket"), receive(t, jobs))
+	assert.ErrorIs(t, s.Submit("bar", []byte("ticket")), ErrUnknownPrinter)
+}
+
+func TestSpoolerRetriesOfflinePrinter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	s := NewSpooler([]Printer{{Name: "counter", Addr: addr, Columns: 48}})
+	s.RetryInterval = 20 * time.Millisecond
+	s.Start()
+	defer s.Close()
+	require.NoError(t, s.Submit("counter", []byte("receipt")))
+
+	time.Sleep(100 * time.Millisecond)
+	_, jobs := standIn(t, addr)
+	assert.Equal(t, []byte("receipt"), receive(t, jobs))
+}
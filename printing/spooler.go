@@ -0,0 +1,156 @@
+package printing
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultColumns = 48
+
+var ErrUnknownPrinter = entity.NewError(entity.ErrNotFound, "unknown printer")
+var ErrQueueFull = entity.NewError(entity.ErrUnavailable, "print queue is full")
+
+// Printer is a network printer speaking ESC/POS on a raw TCP port, usually 9100.
+type Printer struct {
+	Name    string
+	Addr    string
+	Columns int
+}
+
+// ParsePrinters reads a printer registry like "kitchen=10.0.0.5:9100,counter=10.0.0.6:9100/42",
+// where the optional number after the slash are the characters per line.
+func ParsePrinters(s string) ([]Printer, error) {
+	var printers []Printer
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, addr, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || addr == "" {
+			return nil, fmt.Errorf("invalid printer %q, expected name=host:port", entry)
+		}
+		p := Printer{Name: name, Addr: addr, Columns: DefaultColumns}
+		if addr, columns, ok := strings.Cut(addr, "/"); ok {
+			n, err := strconv.Atoi(columns)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid columns of printer %q", name)
+			}
+			p.Addr = addr
+			p.Columns = n
+		}
+		printers = append(printers, p)
+	}
+	return printers, nil
+}
+
+type job struct {
+	data     []byte
+	attempts int
+}
+
+// Spooler sends print jobs to the printers in the background. Every printer has its own queue,
+// so an offline printer only holds back its own jobs, which are retried until it is back.
+type Spooler struct {
+	RetryInterval time.Duration
+	MaxAttempts   int
+	Timeout       time.Duration
+
+	printers map[string]Printer
+	queues   map[string]chan job
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewSpooler(printers []Printer) *Spooler {
+	s := &Spooler{
+		RetryInterval: 5 * time.Second,
+		MaxAttempts:   60,
+		Timeout:       3 * time.Second,
+		printers:      make(map[string]Printer),
+		queues:        make(map[string]chan job),
+		done:          make(chan struct{}),
+	}
+	for _, p := range printers {
+		s.printers[p.Name] = p
+		s.queues[p.Name] = make(chan job, 100)
+	}
+	return s
+}
+
+// Start runs one worker per printer until Close is called.
+func (s *Spooler) Start() {
+	for name := range s.printers {
+		s.wg.Add(1)
+		go s.work(s.printers[name], s.queues[name])
+	}
+}
+
+// Close stops the workers. Jobs still waiting are dropped.
+func (s *Spooler) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+func (s *Spooler) Printer(name string) (Printer, bool) {
+	p, ok := s.printers[name]
+	return p, ok
+}
+
+// Submit queues the data for the printer and returns without waiting for it to be printed.
+func (s *Spooler) Submit(printer string, data []byte) error {
+	queue, ok := s.queues[printer]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPrinter, printer)
+	}
+	select {
+	case queue <- job{data: data}:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrQueueFull, printer)
+	}
+}
+
+func (s *Spooler) work(p Printer, queue chan job) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case j := <-queue:
+			for {
+				j.attempts++
+				err := s.send(p, j.data)
+				if err == nil {
+					break
+				}
+				if s.MaxAttempts > 0 && j.attempts >= s.MaxAttempts {
+					fmt.Printf("Giving up printing on %s after %d attempts: %v\n", p.Name, j.attempts, err)
+					break
+				}
+				fmt.Printf("Printer %s is offline, retrying: %v\n", p.Name, err)
+				select {
+				case <-s.done:
+					return
+				case <-time.After(s.RetryInterval):
+				}
+			}
+		}
+	}
+}
+
+func (s *Spooler) send(p Printer, data []byte) error {
+	conn, err := net.DialTimeout("tcp", p.Addr, s.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(s.Timeout))
+	_, err = conn.Write(data)
+	return err
+}
@@ -0,0 +1,124 @@
+package receipt
+
+import (
+	"gorestserviceagain/entity"
+	"sort"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// Restaurant is the header printed on every receipt, read from the config.
+type Restaurant struct {
+	Name     string
+	Address  string
+	Phone    string
+	VATID    string
+	Footer   string
+	Currency string
+}
+
+type Line struct {
+	Quantity int
+	Name     string
+	Price    float32
+	Discount float32
+	Amount   float32
+	TaxCode  string
+}
+
+type Tax struct {
+	Code  string
+	Rate  float32
+	Net   float32
+	Tax   float32
+	Gross float32
+}
+
+type Receipt struct {
+	Restaurant  Restaurant
+	OrderID     uint
+	TableNumber int
+	CreatedAt   time.Time
+	Lines       []Line
+	Taxes       []Tax
+	Total       float32
+	Payments    []entity.Payment
+	Paid        float32
+	Change      float32
+	Signature   *entity.FiscalSignature
+}
+
+// New builds the receipt of an order. The order needs its items with dishes,
+// discounts and payments loaded.
+func New(restaurant Restaurant, order entity.Order) Receipt {
+	r := Receipt{
+		Restaurant:  restaurant,
+		OrderID:     order.ID,
+		TableNumber: order.TableNumber,
+		CreatedAt:   order.CreatedAt,
+		Payments:    order.Payments,
+		Signature:   order.Signature(entity.FiscalPaid),
+	}
+	if r.Restaurant.Currency == "" {
+		r.Restaurant.Currency = "EUR"
+	}
+
+	discounts := make(map[uint]float32)
+	for _, d := range order.DiscountDetail {
+		discounts[d.DishID] = d.Discount
+	}
+	gross := make(map[float32]float32)
+	var lineRates []float32
+	for _, item := range order.Items {
+		// Voids are mistakes and not printed, comps are printed as fully discounted.
+		if item.Status == entity.ItemVoided {
+			continue
+		}
+		amount := float32(item.Quantity) * item.Price
+		discount := amount * discounts[item.DishID] / 100
+		if item.Status == entity.ItemComped {
+			discount = amount
+		}
+		r.Lines = append(r.Lines, Line{
+			Quantity: item.Quantity,
+			Name:     item.Dish.Name,
+			Price:    item.Price,
+			Discount: discount,
+			Amount:   amount - discount,
+		})
+		lineRates = append(lineRates, item.TaxRate)
+		if item.Charged() {
+			gross[item.TaxRate] += amount - discount
+			r.Total += amount - discount
+		}
+	}
+
+	rates := make([]float32, 0, len(gross))
+	for rate := range gross {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i] > rates[j] })
+	codes := make(map[float32]string)
+	for i, rate := range rates {
+		code := string(rune('A' + i))
+		codes[rate] = code
+		net, tax := entity.NetOf(gross[rate], rate)
+		r.Taxes = append(r.Taxes, Tax{Code: code, Rate: rate, Net: net, Tax: tax, Gross: gross[rate]})
+	}
+	for i, rate := range lineRates {
+		r.Lines[i].TaxCode = codes[rate]
+	}
+
+	for _, p := range order.Payments {
+		r.Paid += p.Amount
+	}
+	if r.Paid > r.Total {
+		r.Change = r.Paid - r.Total
+	}
+	return r
+}
@@ -0,0 +1,112 @@
+package receipt
+
+import (
+	"bytes"
+	"gorestserviceagain/entity"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func testOrder() entity.Order {
+	return entity.Order{
+		Model:       gorm.Model{ID: 42, CreatedAt: time.Date(2024, 3, 1, 19, 30, 0, 0, time.UTC)},
+		TableNumber: 5,
+		Items: []entity.OrderItem{
+			{DishID: 1, Dish: entity.Dish{Name: "Schnitzel"}, Quantity: 2, Price: 14.5, TaxRate: 19},
+			{DishID: 2, Dish: entity.Dish{Name: "Apfelschorle"}, Quantity: 1, Price: 3.5, TaxRate: 7},
+		},
+		DiscountDetail: []entity.DiscountDetail{{OrderID: 42, DishID: 1, Discount: 10}},
+		Payments:       []entity.Payment{{Method: entity.PaymentCash, Amount: 30}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	r := New(Restaurant{Name: "Zum Hirschen"}, testOrder())
+
+	assert.Equal(t, "EUR", r.Restaurant.Currency)
+	require.Len(t, r.Lines, 2)
+	assert.InDelta(t, 2.9, r.Lines[0].Discount, 0.001)
+	assert.InDelta(t, 26.1, r.Lines[0].Amount, 0.001)
+	assert.Equal(t, "A", r.Lines[0].TaxCode)
+	assert.Equal(t, "B", r.Lines[1].TaxCode)
+	assert.InDelta(t, 29.6, r.Total, 0.001)
+	require.Len(t, r.Taxes, 2)
+	assert.InDelta(t, 26.1/1.19, r.Taxes[0].Net, 0.001)
+	assert.InDelta(t, 0.4, r.Change, 0.001)
+}
+
+func TestNewAdjusted(t *testing.T) {
+	order := testOrder()
+	order.Items = append(order.Items,
+		entity.OrderItem{DishID: 3, Dish: entity.Dish{Name: "Bier"}, Quantity: 1, Price: 4, TaxRate: 19, Status: entity.ItemVoided, Reason: "entered_twice"},
+		entity.OrderItem{DishID: 4, Dish: entity.Dish{Name: "Espresso"}, Quantity: 2, Price: 2.5, TaxRate: 19, Status: entity.ItemComped, Reason: "waiting_time"},
+	)
+	r := New(Restaurant{Name: "Zum Hirschen"}, order)
+
+	require.Len(t, r.Lines, 3)
+	assert.Equal(t, "Espresso", r.Lines[2].Name)
+	assert.InDelta(t, 5, r.Lines[2].Discount, 0.001)
+	assert.Zero(t, r.Lines[2].Amount)
+	assert.Equal(t, "A", r.Lines[2].TaxCode)
+	assert.InDelta(t, 29.6, r.Total, 0.001)
+	assert.InDelta(t, order.Total(), r.Total, 0.001)
+}
+
+func TestRender(t *testing.T) {
+	r := New(Restaurant{Name: "Zum Hirschen", Address: "Hauptstr. 1, Berlin", Footer: "Danke!"}, testOrder())
+
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{format: FormatText, contains: []string{"Zum Hirschen", "2 x Schnitzel", "29.00 A", "Discount", "-2.90", "TOTAL EUR", "29.60", "Change"}},
+		{format: FormatHTML, contains: []string{"<h1>Zum Hirschen</h1>", "2 x Schnitzel", "29.60", "Danke!"}},
+		{format: FormatPDF, contains: []string{"%PDF-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Render(tt.format, &buf, r))
+			for _, s := range tt.contains {
+				assert.Contains(t, buf.String(), s)
+			}
+		})
+	}
+
+	for _, l := range r.TextLines() {
+		assert.LessOrEqual(t, len(l), Width, l)
+	}
+	assert.ErrorIs(t, Render("docx", &bytes.Buffer{}, r), ErrUnknownFormat)
+}
+
+func TestRenderSignature(t *testing.T) {
+	order := testOrder()
+	order.Signatures = []entity.FiscalSignature{{
+		Kind:              entity.FiscalPaid,
+		SerialNumber:      strings.Repeat("ab", 32),
+		TransactionNumber: 7,
+		SignatureCounter:  14,
+		Signature:         strings.Repeat("c2ln", 24),
+		StartTime:         order.CreatedAt,
+		EndTime:           order.CreatedAt.Add(time.Hour),
+	}}
+	r := New(Restaurant{Name: "Zum Hirschen"}, order)
+	require.NotNil(t, r.Signature)
+
+	lines := r.Layout(48)
+	text := strings.Join(lines, "\n")
+	assert.Contains(t, text, "TSE signature counter")
+	assert.Contains(t, text, "2024-03-01T20:30:00")
+	for _, l := range lines {
+		assert.LessOrEqual(t, len(l), 48, l)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(FormatHTML, &buf, r))
+	assert.Contains(t, buf.String(), order.Signatures[0].Signature)
+}
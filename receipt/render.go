@@ -0,0 +1,179 @@
+package receipt
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"gorestserviceagain/entity"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Width is the number of characters per line of the text layout.
+const Width = 80
+
+const tseTimeLayout = "2006-01-02T15:04:05"
+
+var ErrUnknownFormat = entity.NewError(entity.ErrBadRequest, "unknown receipt format")
+
+//go:embed receipt.html
+var htmlLayout string
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money": money,
+	"tseTime": func(t time.Time) string {
+		return t.Format(tseTimeLayout)
+	},
+}).Parse(htmlLayout))
+
+func ContentType(format string) string {
+	switch format {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func Render(format string, w io.Writer, r Receipt) error {
+	switch format {
+	case FormatText, "":
+		_, err := io.WriteString(w, strings.Join(r.TextLines(), "\n")+"\n")
+		return err
+	case FormatHTML:
+		return htmlTemplate.Execute(w, r)
+	case FormatPDF:
+		return renderPDF(w, r)
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+func money(v float32) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+func center(width int, s string) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return s
+	}
+	return strings.Repeat(" ", (width-n)/2) + s
+}
+
+// wrap breaks s into lines of width characters, for serial numbers and signatures.
+func wrap(width int, s string) []string {
+	var lines []string
+	r := []rune(s)
+	for len(r) > width {
+		lines = append(lines, string(r[:width]))
+		r = r[width:]
+	}
+	return append(lines, string(r))
+}
+
+// columns puts left and right on one line, shortening left if both do not fit.
+func columns(width int, left string, right string) string {
+	l := []rune(left)
+	space := width - len(l) - utf8.RuneCountInString(right)
+	if space < 1 {
+		l = l[:max(0, len(l)+space-1)]
+		space = 1
+	}
+	return string(l) + strings.Repeat(" ", space) + right
+}
+
+// TextLines lays the receipt out with Width characters per line.
+func (r Receipt) TextLines() []string {
+	return r.Layout(Width)
+}
+
+// Layout lays the receipt out for a printer with the given number of characters per line.
+func (r Receipt) Layout(width int) []string {
+	rule := strings.Repeat("-", width)
+	lines := []string{center(width, r.Restaurant.Name)}
+	for _, s := range []string{r.Restaurant.Address, r.Restaurant.Phone} {
+		if s != "" {
+			lines = append(lines, center(width, s))
+		}
+	}
+	if r.Restaurant.VATID != "" {
+		lines = append(lines, center(width, "VAT ID "+r.Restaurant.VATID))
+	}
+	lines = append(lines, rule,
+		columns(width, fmt.Sprintf("Order %d", r.OrderID), fmt.Sprintf("Table %d", r.TableNumber)),
+		r.CreatedAt.Format("02.01.2006 15:04"),
+		rule)
+	for _, l := range r.Lines {
+		lines = append(lines, columns(width, fmt.Sprintf("%d x %s", l.Quantity, l.Name),
+			fmt.Sprintf("%s %s", money(l.Amount+l.Discount), l.TaxCode)))
+		if l.Quantity > 1 {
+			lines = append(lines, fmt.Sprintf("    a %s", money(l.Price)))
+		}
+		if l.Discount != 0 {
+			lines = append(lines, columns(width, "    Discount", money(-l.Discount)+"  "))
+		}
+	}
+	lines = append(lines, rule,
+		columns(width, "TOTAL "+r.Restaurant.Currency, money(r.Total)+"  "),
+		rule,
+		columns(width, "Tax", fmt.Sprintf("%10s %10s %10s", "Net", "Tax", "Gross")))
+	for _, t := range r.Taxes {
+		lines = append(lines, columns(width, fmt.Sprintf("%s %.0f%%", t.Code, t.Rate),
+			fmt.Sprintf("%10s %10s %10s", money(t.Net), money(t.Tax), money(t.Gross))))
+	}
+	if len(r.Payments) > 0 {
+		lines = append(lines, rule)
+		for _, p := range r.Payments {
+			lines = append(lines, columns(width, "Paid "+p.Method, money(p.Amount)+"  "))
+		}
+		if r.Change > 0 {
+			lines = append(lines, columns(width, "Change", money(r.Change)+"  "))
+		}
+	}
+	if s := r.Signature; s != nil {
+		lines = append(lines, rule,
+			columns(width, "TSE transaction", fmt.Sprint(s.TransactionNumber)),
+			columns(width, "TSE signature counter", fmt.Sprint(s.SignatureCounter)),
+			columns(width, "TSE start", s.StartTime.Format(tseTimeLayout)),
+			columns(width, "TSE end", s.EndTime.Format(tseTimeLayout)),
+			"TSE serial number")
+		lines = append(lines, wrap(width, s.SerialNumber)...)
+		lines = append(lines, "TSE signature")
+		lines = append(lines, wrap(width, s.Signature)...)
+	}
+	if r.Restaurant.Footer != "" {
+		lines = append(lines, "", center(width, r.Restaurant.Footer))
+	}
+	return lines
+}
+
+// renderPDF prints the text layout in a monospace font, so PDF and paper receipts look alike.
+func renderPDF(w io.Writer, r Receipt) error {
+	lines := r.TextLines()
+	const fontSize = 8.
+	lineHeight := fontSize * 0.45
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: float64(Width)*fontSize*0.2117 + 10, Ht: float64(len(lines))*lineHeight + 10},
+	})
+	pdf.SetMargins(5, 5, 5)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	pdf.SetFont("Courier", "", fontSize)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	for _, l := range lines {
+		pdf.CellFormat(0, lineHeight, tr(l), "", 1, "L", false, 0, "")
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
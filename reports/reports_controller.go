@@ -0,0 +1,127 @@
+package reports
+
+import (
+	"fmt"
+	"gorestserviceagain/api"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultTopDishes = 10
+
+type ReportsController struct {
+	Repo entity.Repo
+}
+
+func (c ReportsController) RegisterRoutes(r chi.Router) {
+	r.Use(auth.RequireRole(entity.RoleManager))
+	r.Get("/summary", c.ReadSummary)
+	r.Get("/topDishes", c.ReadTopDishes)
+	r.Get("/tables", c.ReadSalesByTable)
+	r.Get("/hours", c.ReadSalesByHour)
+	r.Get("/weekdays", c.ReadSalesByWeekday)
+	r.Get("/adjustments", c.ReadAdjustments)
+}
+
+func (c ReportsController) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Pattern: "/summary", Summary: "Report the sales summary", Response: entity.SalesSummary{}, Query: api.DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/topDishes", Summary: "Report the best selling dishes", Response: []entity.TopDish{}, Query: append([]openapi.Param{
+			{Name: "limit", Type: "integer"},
+		}, api.DateRangeParams...)},
+		{Method: http.MethodGet, Pattern: "/tables", Summary: "Report the sales by table", Response: []entity.TableSales{}, Query: api.DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/hours", Summary: "Report the sales by hour", Response: []entity.PeriodSales{}, Query: api.DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/weekdays", Summary: "Report the sales by weekday", Response: []entity.PeriodSales{}, Query: api.DateRangeParams},
+		{Method: http.MethodGet, Pattern: "/adjustments", Summary: "Report the voided and comped items", Response: []entity.AdjustmentSales{}, Query: api.DateRangeParams},
+	}
+}
+
+func (c ReportsController) ReadSummary(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	summary, err := c.Repo.GetSalesSummary(from, to)
+	send(w, r, "summary", summary, err)
+}
+
+func (c ReportsController) ReadTopDishes(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	limit := defaultTopDishes
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			api.SendProblem(w, r, fmt.Errorf("%w: limit", entity.ErrBadRequest))
+			return
+		}
+		limit = l
+	}
+	dishes, err := c.Repo.GetTopDishes(from, to, limit)
+	send(w, r, "top dishes", dishes, err)
+}
+
+func (c ReportsController) ReadSalesByTable(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	tables, err := c.Repo.GetSalesByTable(from, to)
+	send(w, r, "sales by table", tables, err)
+}
+
+func (c ReportsController) ReadSalesByHour(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	hours, err := c.Repo.GetSalesByHour(from, to)
+	send(w, r, "sales by hour", hours, err)
+}
+
+func (c ReportsController) ReadSalesByWeekday(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	weekdays, err := c.Repo.GetSalesByWeekday(from, to)
+	send(w, r, "sales by weekday", weekdays, err)
+}
+
+// ReadAdjustments reports the voided and comped items per reason.
+func (c ReportsController) ReadAdjustments(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	adjustments, err := c.Repo.GetAdjustments(from, to)
+	send(w, r, "adjustments", adjustments, err)
+}
+
+func dateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	from, to, err := api.ParseDateRange(r)
+	if err != nil {
+		api.SendProblem(w, r, err)
+		fmt.Println("Invalid date range", err)
+		return from, to, false
+	}
+	return from, to, true
+}
+
+func send(w http.ResponseWriter, r *http.Request, name string, report any, err error) {
+	if err != nil {
+		api.SendProblem(w, r, err)
+		fmt.Printf("Can not compute %s: %v\n", name, err)
+		return
+	}
+	api.SendJson(w, http.StatusOK, report)
+	fmt.Printf("Found %s\n", name)
+}
@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"gorestserviceagain/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryRead(t *testing.T) {
+	type expectations struct {
+		statusCode  int
+		respPayload any
+	}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)
+	summary := entity.SalesSummary{Orders: 4, Items: 9, Revenue: 120, Discounts: 6, Voids: 12, Comps: 4.5, AverageCheck: 30}
+
+	tests := []struct {
+		name        string
+		query       string
+		existing    entity.SalesSummary
+		expected    expectations
+		respPayload any
+		err         error
+	}{
+		{
+			name:     "successful get summary",
+			query:    "?from=2024-03-01&to=2024-03-01",
+			existing: summary,
+			expected: expectations{
+				statusCode: http.StatusOK,
+				respPayload: map[string]interface{}{
+					"From":         "0001-01-01T00:00:00Z",
+					"To":           "0001-01-01T00:00:00Z",
+					"Orders":       float64(summary.Orders),
+					"Items":        float64(summary.Items),
+					"Revenue":      float64(summary.Revenue),
+					"Discounts":    float64(summary.Discounts),
+					"Voids":        float64(summary.Voids),
+					"Comps":        float64(summary.Comps),
+					"AverageCheck": float64(summary.AverageCheck),
+				},
+			},
+		},
+		{
+			name:  "failed to compute summary",
+			query: "?from=2024-03-01&to=2024-03-01",
+			err:   errors.New("mock repo says no"),
+			expected: expectations{
+				statusCode:  http.StatusInternalServerError,
+				respPayload: map[string]interface{}{"type": "about:blank", "title": "Internal Server Error", "status": float64(http.StatusInternalServerError), "detail": "Unknown error"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/reports/summary"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetSalesSummary", from, to).Return(tt.existing, tt.err)
+			ReportsController{Repo: repo}.ReadSummary(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.respPayload != nil {
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&tt.respPayload))
+				assert.EqualValues(t, tt.expected.respPayload, tt.respPayload)
+			}
+		})
+	}
+}
+
+func TestTopDishesRead(t *testing.T) {
+	type expectations struct {
+		statusCode int
+		limit      int
+	}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	dishes := []entity.TopDish{{DishID: 2, Name: "Fish filet", Quantity: 12, Revenue: 120}}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected expectations
+	}{
+		{
+			name:     "default limit",
+			query:    "?from=2024-03-01&to=2024-03-31",
+			expected: expectations{statusCode: http.StatusOK, limit: defaultTopDishes},
+		},
+		{
+			name:     "custom limit",
+			query:    "?from=2024-03-01&to=2024-03-31&limit=3",
+			expected: expectations{statusCode: http.StatusOK, limit: 3},
+		},
+		{
+			name:     "invalid limit",
+			query:    "?from=2024-03-01&to=2024-03-31&limit=-1",
+			expected: expectations{statusCode: http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/reports/topDishes"+tt.query, nil)
+
+			repo := new(entity.MockRepo)
+			repo.On("GetTopDishes", from, to, tt.expected.limit).Return(dishes, nil)
+			ReportsController{Repo: repo}.ReadTopDishes(w, r)
+
+			res := w.Result()
+			assert.Equal(t, tt.expected.statusCode, res.StatusCode)
+			if tt.expected.statusCode == http.StatusOK {
+				var respPayload []entity.TopDish
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&respPayload))
+				assert.Equal(t, dishes, respPayload)
+			}
+		})
+	}
+}
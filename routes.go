@@ -0,0 +1,127 @@
+package main
+
+import (
+	"gorestserviceagain/api"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/dto"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"gorestserviceagain/printing"
+	"gorestserviceagain/reports"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const apiTitle = "Menu API"
+
+// v1Deprecated is the date the v1 routes of the controllers with v2 routes were deprecated.
+var v1Deprecated = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+// controller is mounted by newRouter and describes its routes in the OpenAPI document.
+type controller interface {
+	RegisterRoutes(r chi.Router)
+	Operations() []openapi.Operation
+}
+
+// mount is a controller at a pattern, which is empty for controllers adding their own
+// prefixes. Controllers in v2 are mounted under /v2 with the v2 request and response types as well.
+type mount struct {
+	pattern    string
+	tag        string
+	controller controller
+	v2         bool
+}
+
+func (m mount) register(r chi.Router) {
+	if m.pattern == "" {
+		m.controller.RegisterRoutes(r)
+		return
+	}
+	r.Route(m.pattern, m.controller.RegisterRoutes)
+}
+
+// mounts are the controllers. Approver PINs are locked out with the lockout of the PIN
+// logins, so the attempts of both count together.
+func mounts(db entity.Repo, cfg config, lockout *auth.Lockout, spooler *printing.Spooler, signer entity.FiscalSigner) []mount {
+	return []mount{
+		{pattern: "/orders", tag: "orders", controller: api.OrdersController{Repo: db, Restaurant: cfg.Restaurant, Spooler: spooler, Signer: signer, Lockout: lockout}, v2: true},
+		{pattern: "/dishes", tag: "dishes", controller: api.DishesController{Repo: db, Lockout: lockout}, v2: true},
+		{pattern: "/menu", tag: "menu", controller: api.MenuController{Repo: db}},
+		{pattern: "/inventory", tag: "inventory", controller: api.InventoryController{Repo: db}},
+		{pattern: "/suppliers", tag: "suppliers", controller: api.SuppliersController{Repo: db}},
+		{pattern: "/purchaseOrders", tag: "purchase orders", controller: api.PurchaseOrdersController{Repo: db}},
+		{pattern: "/shifts", tag: "shifts", controller: api.ShiftsController{Repo: db}},
+		{pattern: "/reports", tag: "reports", controller: reports.ReportsController{Repo: db}},
+		{pattern: "/users", tag: "users", controller: api.UsersController{Repo: db}},
+		{pattern: "/timesheets", tag: "timesheets", controller: api.TimesheetsController{Repo: db}},
+		{pattern: "/audit", tag: "audit", controller: api.AuditController{Repo: db}},
+		{tag: "discounts", controller: api.DiscountDetailController{Repo: db, Lockout: lockout}, v2: true},
+	}
+}
+
+// docsOperations describe the OpenAPI document and its Swagger UI.
+var docsOperations = []openapi.Operation{
+	{Method: http.MethodGet, Pattern: "/openapi.json", Summary: "Read this OpenAPI document", Produces: []string{"application/json"}, Public: true},
+	{Method: http.MethodGet, Pattern: "/docs", Summary: "Show this OpenAPI document in Swagger UI", Produces: []string{"text/html"}, Public: true},
+}
+
+// newRouter returns the routes of the service and their OpenAPI document. The document
+// is served at /openapi.json and shown by Swagger UI at /docs.
+func newRouter(db entity.Repo, cfg config, tokens auth.Tokens, lockout *auth.Lockout, spooler *printing.Spooler, signer entity.FiscalSigner) (*chi.Mux, *openapi.Spec) {
+	spec := openapi.New(apiTitle, "2", dto.ProblemContentType, dto.Problem{})
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Get("/openapi.json", spec.ServeHTTP)
+	r.Get("/docs", openapi.UI(apiTitle, "/openapi.json"))
+	spec.Add("", "docs", docsOperations)
+
+	login := api.AuthController{Repo: db, Tokens: tokens, Lockout: lockout}
+	r.Route("/auth", login.RegisterRoutes)
+	spec.Add("/auth", "auth", login.Operations())
+
+	controllers := mounts(db, cfg, lockout, spooler, signer)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Authenticate(tokens, db))
+		// The routes without version are v1, as used by the clients from before the versions.
+		mountV1(r, spec, "", controllers, cfg.V1Sunset)
+		r.Route("/v1", func(r chi.Router) {
+			r.Use(api.WithVersion(api.V1))
+			mountV1(r, spec, "/v1", controllers, cfg.V1Sunset)
+		})
+		r.Route("/v2", func(r chi.Router) {
+			r.Use(api.WithVersion(api.V2))
+			mountV2(r, spec, "/v2", controllers)
+		})
+	})
+	return r, spec
+}
+
+// mountV1 mounts all controllers in v1 at prefix. The controllers which are in v2 as well
+// announce that their v1 routes are deprecated.
+func mountV1(r chi.Router, spec *openapi.Spec, prefix string, controllers []mount, sunset time.Time) {
+	deprecated := r.With(api.Deprecate(v1Deprecated, sunset))
+	for _, m := range controllers {
+		ops := m.controller.Operations()
+		if m.v2 {
+			m.register(deprecated)
+			ops = openapi.Deprecate(ops)
+		} else {
+			m.register(r)
+		}
+		spec.Add(prefix+m.pattern, m.tag, ops)
+	}
+}
+
+// mountV2 mounts the controllers which have v2 request and response types at prefix.
+func mountV2(r chi.Router, spec *openapi.Spec, prefix string, controllers []mount) {
+	for _, m := range controllers {
+		if !m.v2 {
+			continue
+		}
+		m.register(r)
+		spec.Add(prefix+m.pattern, m.tag, api.V2Operations(m.controller.Operations()))
+	}
+}
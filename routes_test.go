@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"gorestserviceagain/auth"
+	"gorestserviceagain/entity"
+	"gorestserviceagain/openapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestOpenAPIDescribesAllRoutes(t *testing.T) {
+	r, spec := newRouter(new(entity.MockRepo), config{}, auth.Tokens{Secret: []byte("secret")}, nil, nil, nil)
+
+	routes := make(map[string]bool)
+	err := chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes[method+" "+route] = true
+		assert.True(t, spec.Has(method, route), "%s %s is missing in the OpenAPI document", method, route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The other way round, the document has no operations which are not routed.
+	for path, methods := range spec.Document().Paths {
+		for method := range methods {
+			assert.True(t, routes[strings.ToUpper(method)+" "+path], "%s %s is not routed", method, path)
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	r, _ := newRouter(new(entity.MockRepo), config{}, auth.Tokens{Secret: []byte("secret")}, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var doc openapi.Document
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	order := doc.Paths["/v2/orders/{id}"]["get"]
+	require.NotNil(t, order)
+	assert.Equal(t, &openapi.Schema{Ref: "#/components/schemas/OrderResponse"}, order.Responses["200"].Content["application/json"].Schema)
+	assert.Contains(t, doc.Components.Schemas["OrderResponse"].Properties, "tableNumber")
+	assert.Contains(t, doc.Components.Schemas["Order"].Properties, "TableNumber")
+	assert.True(t, doc.Paths["/v1/orders/{id}"]["get"].Deprecated)
+	assert.NotNil(t, doc.Paths["/auth/login"]["post"].Security)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
+
+// TestPinAttemptsShared checks that wrong PINs of PIN logins and approvals count together.
+func TestPinAttemptsShared(t *testing.T) {
+	pin, err := auth.HashPassword("1234")
+	require.NoError(t, err)
+	maria := entity.User{Model: gorm.Model{ID: 7}, Username: "maria", PinHash: pin, Role: entity.RoleManager}
+	tom := entity.User{Model: gorm.Model{ID: 8}, Username: "tom", Role: entity.RoleWaiter}
+	repo := new(entity.MockRepo)
+	repo.On("GetUserByUsername", "maria").Return(maria, nil)
+	repo.On("GetUser", tom.ID).Return(tom, nil)
+	tokens := auth.Tokens{Secret: []byte("secret"), TTL: time.Hour}
+	r, _ := newRouter(repo, config{}, tokens, auth.NewLockout(5, time.Minute), nil, nil)
+	token, _, err := tokens.Issue(tom)
+	require.NoError(t, err)
+
+	pinLogin := func(pin string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/pin", strings.NewReader(`{"Username": "maria", "Pin": "`+pin+`"}`)))
+		return w.Result().StatusCode
+	}
+	approvedVoid := func(pin string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v2/orders/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", `"1"`)
+		req.Header.Set(auth.ApproverHeader, "maria")
+		req.Header.Set(auth.ApproverPinHeader, pin)
+		r.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+	for range 3 {
+		assert.Equal(t, http.StatusUnauthorized, pinLogin("0000"))
+	}
+	for range 2 {
+		assert.Equal(t, http.StatusForbidden, approvedVoid("0000"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, approvedVoid("1234"))
+	assert.Equal(t, http.StatusTooManyRequests, pinLogin("1234"))
+	repo.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+}
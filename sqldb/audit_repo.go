@@ -0,0 +1,34 @@
+package sqldb
+
+import (
+	"gorestserviceagain/entity"
+
+	"gorm.io/gorm"
+)
+
+// Atomic runs fn in a transaction, see entity.Repo.
+func (r SqliteDB) Atomic(fn func(tx entity.Repo) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(SqliteDB{db: tx})
+	})
+}
+
+func (r SqliteDB) AddAuditEntry(entry *entity.AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAuditEntries returns the newest entries first. An empty kind or entityId matches all.
+func (r SqliteDB) GetAuditEntries(kind string, entityId string) (e []entity.AuditEntry, err error) {
+	query := r.db.Order("id DESC")
+	if kind != "" {
+		query = query.Where("entity = ?", kind)
+	}
+	if entityId != "" {
+		query = query.Where("entity_id = ?", entityId)
+	}
+	result := query.Find(&e)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return e, nil
+}
@@ -0,0 +1,155 @@
+package sqldb
+
+import (
+	"errors"
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r SqliteDB) CreateSupplier(supplier *entity.Supplier) error {
+	result := r.db.Create(supplier)
+	if result.Error != nil {
+		return writeError(result.Error)
+	}
+	return nil
+}
+
+func (r SqliteDB) GetSuppliers() (s []entity.Supplier, err error) {
+	result := r.db.Find(&s)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return s, nil
+}
+
+func (r SqliteDB) GetSupplier(id uint) (s entity.Supplier, err error) {
+	result := r.db.First(&s, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return s, entity.WrapRecordNotFoundError("Supplier", id, result.Error)
+	}
+	return s, result.Error
+}
+
+func (r SqliteDB) UpdateSupplier(supplier *entity.Supplier) error {
+	result := r.db.Model(supplier).Updates(*supplier)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.WrapRecordNotFoundError("Supplier", supplier.ID, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r SqliteDB) DeleteSupplier(id uint) error {
+	result := r.db.Delete(&entity.Supplier{}, id)
+	if result.RowsAffected == 0 {
+		return entity.WrapRecordNotFoundError("Supplier", id, result.Error)
+	}
+	return nil
+}
+
+func (r SqliteDB) CreatePurchaseOrder(order *entity.PurchaseOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.First(&order.Supplier, order.SupplierID)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("Supplier", order.SupplierID, result.Error)
+		}
+		for _, l := range order.Lines {
+			result = tx.First(&entity.Ingredient{}, l.IngredientID)
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return entity.WrapRecordNotFoundError("Ingredient", l.IngredientID, result.Error)
+			}
+		}
+		order.Status = entity.PurchaseOrderOpen
+		order.ReceivedAt = nil
+		if err := tx.Omit("Supplier").Create(order).Error; err != nil {
+			return writeError(err)
+		}
+		return nil
+	})
+}
+
+func (r SqliteDB) GetPurchaseOrders() (p []entity.PurchaseOrder, err error) {
+	result := r.db.Preload("Supplier").Preload("Lines").Find(&p)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return p, nil
+}
+
+func (r SqliteDB) GetPurchaseOrder(id uint) (p entity.PurchaseOrder, err error) {
+	result := r.db.Preload("Supplier").Preload("Lines").First(&p, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return p, entity.WrapRecordNotFoundError("PurchaseOrder", id, result.Error)
+	}
+	return p, result.Error
+}
+
+func (r SqliteDB) ReceivePurchaseOrder(id uint, received []entity.PurchaseOrderLine) (p entity.PurchaseOrder, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Preload("Supplier").Preload("Lines").First(&p, id)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("PurchaseOrder", id, result.Error)
+		}
+		if err := p.Receive(received); err != nil {
+			return err
+		}
+		now := time.Now()
+		ingredientIds := make([]uint, 0, len(received))
+		for _, rec := range received {
+			var line entity.PurchaseOrderLine
+			for _, l := range p.Lines {
+				if l.IngredientID == rec.IngredientID {
+					line = l
+				}
+			}
+			result = tx.Model(&entity.Ingredient{}).Where("id = ?", rec.IngredientID).Updates(map[string]any{
+				"stock":     gorm.Expr("stock + ?", rec.Quantity),
+				"unit_cost": line.UnitCost,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			cost := entity.IngredientCost{
+				IngredientID:    rec.IngredientID,
+				PurchaseOrderID: p.ID,
+				Quantity:        rec.Quantity,
+				UnitCost:        line.UnitCost,
+				ReceivedAt:      now,
+			}
+			if err := tx.Create(&cost).Error; err != nil {
+				return err
+			}
+			ingredientIds = append(ingredientIds, rec.IngredientID)
+		}
+		p.ReceivedAt = &now
+		if err := tx.Omit("Supplier").Save(&p).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&p.Lines).Error; err != nil {
+			return err
+		}
+		return updateSoldOut(tx, ingredientIds)
+	})
+	return p, err
+}
+
+func (r SqliteDB) GetReorderSuggestions() ([]entity.ReorderSuggestion, error) {
+	var ingredients []entity.Ingredient
+	if err := r.db.Where("stock < par_level").Order("name").Find(&ingredients).Error; err != nil {
+		return nil, err
+	}
+	return entity.NewReorderSuggestions(ingredients), nil
+}
+
+func (r SqliteDB) GetIngredientCosts(ingredientId uint) (c []entity.IngredientCost, err error) {
+	result := r.db.First(&entity.Ingredient{}, ingredientId)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, entity.WrapRecordNotFoundError("Ingredient", ingredientId, result.Error)
+	}
+	result = r.db.Where("ingredient_id = ?", ingredientId).Order("received_at").Find(&c)
+	return c, result.Error
+}
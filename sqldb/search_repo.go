@@ -0,0 +1,125 @@
+package sqldb
+
+import (
+	"fmt"
+	"gorestserviceagain/entity"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The dishes are searched in the dish_search table, which holds the search document of
+// every dish. With FTS5 it is a full-text index, otherwise a plain table searched with
+// LIKE. go-sqlite3 only has FTS5 when it is built with the sqlite_fts5 tag.
+func (r SqliteDB) migrateSearch() error {
+	create := `CREATE TABLE IF NOT EXISTS dish_search (dish_id INTEGER PRIMARY KEY, document TEXT NOT NULL)`
+	if r.fts5() {
+		create = `CREATE VIRTUAL TABLE IF NOT EXISTS dish_search USING fts5(dish_id UNINDEXED, document, tokenize = 'unicode61 remove_diacritics 2')`
+	} else {
+		fmt.Println("SQLite has no FTS5, dishes are searched without a full-text index")
+	}
+	if err := r.db.Exec(create).Error; err != nil {
+		return err
+	}
+	return indexDishes(r.db)
+}
+
+func (r SqliteDB) fts5() bool {
+	var enabled bool
+	r.db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled)
+	return enabled
+}
+
+// SearchDishes finds the dishes whose words start with the search terms, best matches
+// first. If there are none, it looks for dishes matching the terms with a few typos.
+func (r SqliteDB) SearchDishes(text string, limit int) ([]entity.Dish, error) {
+	terms := entity.SearchTerms(text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: nothing to search for", entity.ErrBadRequest)
+	}
+	var ids []uint
+	var result *gorm.DB
+	if r.fts5() {
+		match := make([]string, len(terms))
+		for i, t := range terms {
+			match[i] = `"` + t + `"*`
+		}
+		result = r.db.Raw("SELECT dish_id FROM dish_search WHERE dish_search MATCH ? ORDER BY bm25(dish_search) LIMIT ?",
+			strings.Join(match, " "), limit).Scan(&ids)
+	} else {
+		query := r.db.Table("dish_search").Select("dish_id")
+		for _, t := range terms {
+			query = query.Where("(' ' || document) LIKE ?", "% "+t+"%")
+		}
+		result = query.Order("dish_id").Limit(limit).Scan(&ids)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(ids) == 0 {
+		return fuzzySearch(r.db, terms, limit)
+	}
+	return dishesByIds(r.db, ids)
+}
+
+// indexDishes updates the search documents of the dishes, or of all dishes without ids.
+func indexDishes(tx *gorm.DB, ids ...uint) error {
+	var dishes []entity.Dish
+	query := tx.Preload("Translations")
+	var err error
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+		err = tx.Exec("DELETE FROM dish_search WHERE dish_id IN ?", ids).Error
+	} else {
+		err = tx.Exec("DELETE FROM dish_search").Error
+	}
+	if err != nil {
+		return err
+	}
+	if err := query.Find(&dishes).Error; err != nil {
+		return err
+	}
+	for _, d := range dishes {
+		err := tx.Exec("INSERT INTO dish_search (dish_id, document) VALUES (?, ?)", d.ID, d.SearchDocument()).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dishesByIds loads the dishes in the order of ids.
+func dishesByIds(tx *gorm.DB, ids []uint) ([]entity.Dish, error) {
+	var dishes []entity.Dish
+	if err := tx.Preload("Translations").Where("id IN ?", ids).Find(&dishes).Error; err != nil {
+		return nil, err
+	}
+	position := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+	sort.Slice(dishes, func(i, j int) bool { return position[dishes[i].ID] < position[dishes[j].ID] })
+	return dishes, nil
+}
+
+// fuzzySearch scores every dish with entity.FuzzyScore, a menu is small enough for that.
+func fuzzySearch(tx *gorm.DB, terms []string, limit int) ([]entity.Dish, error) {
+	var dishes []entity.Dish
+	if err := tx.Preload("Translations").Find(&dishes).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[uint]float64)
+	found := make([]entity.Dish, 0)
+	for _, d := range dishes {
+		if score := entity.FuzzyScore(terms, d.SearchDocument()); score > 0 {
+			scores[d.ID] = score
+			found = append(found, d)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return scores[found[i].ID] > scores[found[j].ID] })
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
@@ -0,0 +1,550 @@
+package sqldb
+
+import (
+	"errors"
+	"gorestserviceagain/audit"
+	"gorestserviceagain/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens a migrated in-memory database of its own for the test.
+func newTestDB(t *testing.T) SqliteDB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	require.NoError(t, err)
+	db.Exec("PRAGMA foreign_keys = ON")
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	r := SqliteDB{db: db}
+	r.Migrate()
+	return r
+}
+
+func TestOrderChangesInClosedShift(t *testing.T) {
+	r := newTestDB(t)
+	shift := entity.Shift{}
+	require.NoError(t, r.OpenShift(&shift))
+	order := entity.Order{TableNumber: 1}
+	require.NoError(t, r.CreateOrder(&order))
+	dish := entity.Dish{Name: "Fries", Price: 4}
+	require.NoError(t, r.CreateDish(&dish))
+
+	require.NoError(t, r.CreateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 1}))
+	order, err := r.GetOrder(order.ID)
+	require.NoError(t, err)
+	order.TableNumber = 2
+	require.NoError(t, r.UpdateOrder(&order))
+	_, err = r.CloseShift(shift.ID, 0)
+	require.NoError(t, err)
+
+	order.TableNumber = 3
+	assert.ErrorIs(t, r.UpdateOrder(&order), entity.ErrShiftClosed)
+	assert.ErrorIs(t, r.UpdateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 2, Version: 1}), entity.ErrShiftClosed)
+	assert.ErrorIs(t, r.DeleteOrder(order.ID, order.Version), entity.ErrShiftClosed)
+	got, err := r.GetOrder(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.TableNumber)
+}
+
+func TestUsernameOfDeletedUserTaken(t *testing.T) {
+	r := newTestDB(t)
+	user := entity.User{Username: "anna", PasswordHash: "hash", Role: entity.RoleWaiter}
+	require.NoError(t, r.CreateUser(&user))
+	require.NoError(t, r.DeleteUser(user.ID))
+
+	err := r.CreateUser(&entity.User{Username: "anna", PasswordHash: "hash", Role: entity.RoleWaiter})
+	assert.ErrorIs(t, err, entity.ErrUsernameTaken)
+}
+
+func TestDiscountErrors(t *testing.T) {
+	r := newTestDB(t)
+	require.NoError(t, r.OpenShift(&entity.Shift{}))
+	order := entity.Order{TableNumber: 1}
+	require.NoError(t, r.CreateOrder(&order))
+	dish := entity.Dish{Name: "Fries", Price: 4}
+	require.NoError(t, r.CreateDish(&dish))
+
+	_, err := r.GetPriceAfterDiscount(order.ID, dish.ID)
+	assert.ErrorIs(t, err, entity.ErrNotFound)
+
+	require.NoError(t, r.CreateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 10}))
+	got, err := r.GetPriceAfterDiscount(order.ID, dish.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(10), got.Discount)
+	assert.Equal(t, "Fries", got.Dish.Name)
+
+	err = r.CreateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 20})
+	assert.ErrorIs(t, err, entity.ErrConflict)
+	assert.ErrorIs(t, err, entity.ErrDiscountExists)
+}
+
+func TestWriteErrors(t *testing.T) {
+	r := newTestDB(t)
+
+	dish := entity.Dish{Name: "Fries", Translations: []entity.DishTranslation{{Language: "de", Name: "Pommes"}, {Language: "de", Name: "Fritten"}}}
+	err := r.CreateDish(&dish)
+	assert.ErrorIs(t, err, entity.ErrConflict)
+	assert.Equal(t, entity.ErrDuplicateKey.Error(), err.Error())
+
+	err = r.db.Create(&entity.DishTranslation{DishID: 99, Language: "en"}).Error
+	assert.ErrorIs(t, writeError(err), entity.ErrInvalidData)
+
+	// Errors the client did not cause are not classified, they are internal errors.
+	sqlDB, err := r.db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	err = r.CreateIngredient(&entity.Ingredient{Name: "Potato"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, entity.ErrInvalidData)
+	assert.NotErrorIs(t, err, entity.ErrConflict)
+}
+
+func TestVersionsOfChildChanges(t *testing.T) {
+	r := newTestDB(t)
+	require.NoError(t, r.OpenShift(&entity.Shift{}))
+	potato := entity.Ingredient{Name: "Potato", Stock: 2, LowStockThreshold: 1}
+	require.NoError(t, r.CreateIngredient(&potato))
+	dish := entity.Dish{Name: "Fries", Price: 4}
+	require.NoError(t, r.CreateDish(&dish))
+	require.NoError(t, r.SetRecipe(dish.ID, []entity.RecipeItem{{IngredientID: potato.ID, Quantity: 1}}))
+	order := entity.Order{TableNumber: 1}
+	require.NoError(t, r.CreateOrder(&order))
+
+	orderVersion := func() uint {
+		o, err := r.GetOrder(order.ID)
+		require.NoError(t, err)
+		return o.Version
+	}
+	dishVersion := func() uint {
+		d, err := r.GetDish(dish.ID)
+		require.NoError(t, err)
+		return d.Version
+	}
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"add items", func() error {
+			return r.AddOrderItems(order.ID, []entity.OrderItem{{DishID: dish.ID, Quantity: 1}})
+		}},
+		{"adjust item", func() error {
+			o, err := r.GetOrder(order.ID)
+			require.NoError(t, err)
+			_, err = r.AdjustOrderItem(order.ID, o.Items[0].ID, entity.ItemAdjustment{Status: entity.ItemVoided, Reason: "wrong_table"})
+			return err
+		}},
+		{"create discount", func() error {
+			return r.CreateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 1})
+		}},
+		{"update discount", func() error {
+			return r.UpdateDiscount(&entity.DiscountDetail{OrderID: order.ID, DishID: dish.ID, Discount: 2, Version: 1})
+		}},
+		{"add payment", func() error {
+			return r.AddPayment(&entity.Payment{OrderID: order.ID, Amount: 4, Method: entity.PaymentCash})
+		}},
+		{"add signature", func() error {
+			return r.AddSignature(&entity.FiscalSignature{OrderID: order.ID})
+		}},
+	}
+	for _, c := range changes {
+		before := orderVersion()
+		require.NoError(t, c.change(), c.name)
+		assert.Equal(t, before+1, orderVersion(), c.name)
+	}
+
+	// Selling the last potato but one makes the fries sold out, voiding it makes them available.
+	before := dishVersion()
+	require.NoError(t, r.AddOrderItems(order.ID, []entity.OrderItem{{DishID: dish.ID, Quantity: 1}}))
+	assert.Equal(t, before+1, dishVersion())
+	o, err := r.GetOrder(order.ID)
+	require.NoError(t, err)
+	_, err = r.AdjustOrderItem(order.ID, o.Items[len(o.Items)-1].ID, entity.ItemAdjustment{Status: entity.ItemVoided, Reason: "wrong_table"})
+	require.NoError(t, err)
+	assert.Equal(t, before+2, dishVersion())
+	require.NoError(t, r.UpdateIngredient(&entity.Ingredient{Model: potato.Model, Stock: 5}))
+	assert.Equal(t, before+2, dishVersion(), "dishes which stay available keep their version")
+}
+
+func TestCreateOrderWithItems(t *testing.T) {
+	r := newTestDB(t)
+	require.NoError(t, r.OpenShift(&entity.Shift{}))
+	potato := entity.Ingredient{Name: "Potato", Stock: 3, LowStockThreshold: 1}
+	require.NoError(t, r.CreateIngredient(&potato))
+	fries := entity.Dish{Name: "Fries", Price: 4, TaxRate: 7}
+	require.NoError(t, r.CreateDish(&fries))
+	require.NoError(t, r.SetRecipe(fries.ID, []entity.RecipeItem{{IngredientID: potato.ID, Quantity: 1}}))
+
+	order := entity.Order{
+		TableNumber: 1,
+		Items:       []entity.OrderItem{{DishID: fries.ID, Quantity: 2, Dish: entity.Dish{Name: "Free fries"}}},
+		Payments:    []entity.Payment{{Method: entity.PaymentCash, Amount: 8}},
+		Signatures:  []entity.FiscalSignature{{Kind: "paid", Signature: "forged"}},
+	}
+	require.NoError(t, r.CreateOrder(&order))
+
+	got, err := r.GetOrder(order.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, float32(4), got.Items[0].Price, "items without a price are charged with the dish price")
+	assert.Equal(t, float32(7), got.Items[0].TaxRate)
+	assert.Empty(t, got.Payments)
+	assert.Empty(t, got.Signatures)
+	assert.False(t, got.PaidInFull())
+	dishes, err := r.GetDishes(entity.DishQuery{})
+	require.NoError(t, err)
+	assert.Len(t, dishes, 1, "the dish of an item is not created")
+
+	stock, err := r.GetIngredient(potato.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1), stock.Stock)
+	dish, err := r.GetDish(fries.ID)
+	require.NoError(t, err)
+	assert.True(t, dish.SoldOut)
+	err = r.CreateOrder(&entity.Order{TableNumber: 2, Items: []entity.OrderItem{{DishID: fries.ID, Quantity: 1}}})
+	assert.ErrorIs(t, err, entity.ErrDishSoldOut)
+}
+
+func TestOrdersNeedOpenShift(t *testing.T) {
+	r := newTestDB(t)
+	assert.ErrorIs(t, r.CreateOrder(&entity.Order{TableNumber: 1}), entity.ErrNoShiftOpen)
+
+	shift := entity.Shift{}
+	require.NoError(t, r.OpenShift(&shift))
+	order := entity.Order{TableNumber: 1}
+	require.NoError(t, r.CreateOrder(&order))
+	assert.Equal(t, shift.ID, order.ShiftID)
+	_, err := r.CloseShift(shift.ID, 0)
+	require.NoError(t, err)
+	assert.ErrorIs(t, r.CreateOrder(&entity.Order{TableNumber: 2}), entity.ErrNoShiftOpen)
+
+	// Orders from before shifts are in no Z-report, so they can't be changed either.
+	legacy := entity.Order{TableNumber: 3}
+	require.NoError(t, r.db.Create(&legacy).Error)
+	assert.ErrorIs(t, r.AddPayment(&entity.Payment{OrderID: legacy.ID, Method: entity.PaymentCash, Amount: 1}), entity.ErrShiftClosed)
+}
+
+func TestImportKeepsSoldOut(t *testing.T) {
+	r := newTestDB(t)
+	potato := entity.Ingredient{Name: "Potato", Stock: 0, LowStockThreshold: 1}
+	require.NoError(t, r.CreateIngredient(&potato))
+	fries := entity.Dish{SKU: "F1", Name: "Fries", Price: 4}
+	require.NoError(t, r.CreateDish(&fries))
+	require.NoError(t, r.SetRecipe(fries.ID, []entity.RecipeItem{{IngredientID: potato.ID, Quantity: 1}}))
+
+	rows, err := r.ImportDishes([]entity.Dish{{SKU: "F1", Name: "Fries", Price: 5}}, false)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, entity.ImportUpdate, rows[0].Action)
+	dish, err := r.GetDish(fries.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(5), dish.Price)
+	assert.True(t, dish.SoldOut, "the stock keeps the dish sold out")
+}
+
+func TestUpdateKeepsSoldOut(t *testing.T) {
+	r := newTestDB(t)
+	potato := entity.Ingredient{Name: "Potato", Stock: 0, LowStockThreshold: 1}
+	require.NoError(t, r.CreateIngredient(&potato))
+	fries := entity.Dish{Name: "Fries", Price: 4}
+	require.NoError(t, r.CreateDish(&fries))
+	require.NoError(t, r.SetRecipe(fries.ID, []entity.RecipeItem{{IngredientID: potato.ID, Quantity: 1}}))
+	fries, err := r.GetDish(fries.ID)
+	require.NoError(t, err)
+	require.True(t, fries.SoldOut)
+
+	// A client replacing the dish with an older copy does not make it available.
+	fries.Price, fries.SoldOut = 5, false
+	require.NoError(t, r.UpdateDish(&fries))
+	dish, err := r.GetDish(fries.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(5), dish.Price)
+	assert.True(t, dish.SoldOut, "the stock keeps the dish sold out")
+
+	potato.Stock = 10
+	require.NoError(t, r.UpdateIngredient(&potato))
+	dish, err = r.GetDish(fries.ID)
+	require.NoError(t, err)
+	assert.False(t, dish.SoldOut)
+}
+
+func TestAuditInTransaction(t *testing.T) {
+	r := newTestDB(t)
+	repo := audit.New(r)
+	dish := entity.Dish{Name: "Fries", Price: 4}
+	require.NoError(t, repo.CreateDish(&dish))
+	dish.Price = 5
+	require.NoError(t, repo.UpdateDish(&dish))
+	entries, err := r.GetAuditEntries("dish", "")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.JSONEq(t, `{"Price":{"Before":4,"After":5},"Version":{"Before":1,"After":2}}`, entries[0].Diff)
+
+	// Without the audit log the change is rolled back.
+	require.NoError(t, r.db.Migrator().DropTable(&entity.AuditEntry{}))
+	err = repo.CreateDish(&entity.Dish{Name: "Salad", Price: 6})
+	require.Error(t, err)
+	dishes, err := r.GetDishes(entity.DishQuery{})
+	require.NoError(t, err)
+	assert.Len(t, dishes, 1)
+}
+
+// TestSearchDishes searches with LIKE, or with FTS5 when run with -tags sqlite_fts5.
+func TestSearchDishes(t *testing.T) {
+	r := newTestDB(t)
+	carbonara := entity.Dish{Name: "Spaghetti Carbonara", Description: "With bacon and egg", Price: 12}
+	margherita := entity.Dish{Name: "Pizza Margherita", Description: "Tomato and mozzarella", Price: 9}
+	soup := entity.Dish{Name: "Tomato Soup", Price: 6, Translations: []entity.DishTranslation{{Language: "de", Name: "Tomatensuppe"}}}
+	for _, d := range []*entity.Dish{&carbonara, &margherita, &soup} {
+		require.NoError(t, r.CreateDish(d))
+	}
+	names := func(text string, limit int) []string {
+		dishes, err := r.SearchDishes(text, limit)
+		require.NoError(t, err)
+		names := make([]string, len(dishes))
+		for i, d := range dishes {
+			names[i] = d.Name
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Spaghetti Carbonara"}, names("carb", 10))
+	assert.Equal(t, []string{"Spaghetti Carbonara"}, names("Bacon, SPAG", 10))
+	assert.ElementsMatch(t, []string{"Pizza Margherita", "Tomato Soup"}, names("tomat", 10))
+	assert.Len(t, names("tomat", 1), 1)
+	assert.Equal(t, []string{"Tomato Soup"}, names("tomatensuppe", 10), "translations are searched")
+	assert.Equal(t, []string{"Pizza Margherita"}, names("margarita", 10), "typos are found by the fuzzy search")
+	assert.Empty(t, names("burger", 10))
+
+	carbonara.Name = "Spaghetti Bolognese"
+	carbonara.Description = "With beef"
+	require.NoError(t, r.UpdateDish(&carbonara))
+	assert.Empty(t, names("bacon", 10))
+	assert.Equal(t, []string{"Spaghetti Bolognese"}, names("bolo", 10))
+	require.NoError(t, r.DeleteDish(carbonara.ID, carbonara.Version))
+	assert.Empty(t, names("spaghetti", 10))
+
+	_, err := r.SearchDishes(" ,. ", 10)
+	assert.ErrorIs(t, err, entity.ErrBadRequest)
+}
+
+// sales fills the database with the orders of a shift: fries with half off and a beer
+// paid cash at table 1, fries comped and three beers with one voided paid by card at
+// table 2, and a beer at table 3 whose order was voided.
+func sales(t *testing.T, r SqliteDB) (shift entity.Shift, fries entity.Dish, beer entity.Dish, orders []entity.Order) {
+	shift = entity.Shift{OpeningCash: 50}
+	require.NoError(t, r.OpenShift(&shift))
+	fries = entity.Dish{Name: "Fries", Price: 4, TaxRate: 19}
+	beer = entity.Dish{Name: "Beer", Price: 5, TaxRate: 7}
+	require.NoError(t, r.CreateDish(&fries))
+	require.NoError(t, r.CreateDish(&beer))
+
+	orders = []entity.Order{
+		{TableNumber: 1, Items: []entity.OrderItem{{DishID: fries.ID, Quantity: 2}, {DishID: beer.ID, Quantity: 1}}},
+		{TableNumber: 2, Items: []entity.OrderItem{{DishID: fries.ID, Quantity: 1}, {DishID: beer.ID, Quantity: 3}}},
+		{TableNumber: 3, Items: []entity.OrderItem{{DishID: beer.ID, Quantity: 1}}},
+	}
+	for i := range orders {
+		require.NoError(t, r.CreateOrder(&orders[i]))
+	}
+	require.NoError(t, r.CreateDiscount(&entity.DiscountDetail{OrderID: orders[0].ID, DishID: fries.ID, Discount: 50}))
+	require.NoError(t, r.AddPayment(&entity.Payment{OrderID: orders[0].ID, Method: entity.PaymentCash, Amount: 9}))
+	_, err := r.AdjustOrderItem(orders[1].ID, orders[1].Items[0].ID, entity.ItemAdjustment{Status: entity.ItemComped, Reason: "quality"})
+	require.NoError(t, err)
+	_, err = r.AdjustOrderItem(orders[1].ID, orders[1].Items[1].ID, entity.ItemAdjustment{Status: entity.ItemVoided, Reason: "wrong_item", Waste: true, Quantity: 1})
+	require.NoError(t, err)
+	require.NoError(t, r.AddPayment(&entity.Payment{OrderID: orders[1].ID, Method: entity.PaymentCard, Amount: 10}))
+	voided, err := r.GetOrder(orders[2].ID)
+	require.NoError(t, err)
+	require.NoError(t, r.DeleteOrder(voided.ID, voided.Version))
+	return shift, fries, beer, orders
+}
+
+func TestSalesReports(t *testing.T) {
+	r := newTestDB(t)
+	_, fries, beer, orders := sales(t, r)
+	from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	summary, err := r.GetSalesSummary(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, entity.SalesSummary{From: from, To: to, Orders: 2, Items: 5, Revenue: 19, Discounts: 4, Voids: 5, Comps: 4, AverageCheck: 9.5}, summary)
+	summary, err = r.GetSalesSummary(from.Add(-time.Hour), from)
+	require.NoError(t, err)
+	assert.Zero(t, summary.Orders)
+	assert.Zero(t, summary.Revenue)
+
+	top, err := r.GetTopDishes(from, to, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.TopDish{{DishID: beer.ID, Name: "Beer", Quantity: 3, Revenue: 15}, {DishID: fries.ID, Name: "Fries", Quantity: 2, Revenue: 4}}, top)
+	top, err = r.GetTopDishes(from, to, 1)
+	require.NoError(t, err)
+	assert.Len(t, top, 1)
+
+	tables, err := r.GetSalesByTable(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.TableSales{{TableNumber: 1, Orders: 1, Revenue: 9}, {TableNumber: 2, Orders: 1, Revenue: 10}}, tables)
+
+	placed := orders[0].CreatedAt.Local()
+	hours, err := r.GetSalesByHour(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.PeriodSales{{Period: placed.Hour(), Orders: 2, Revenue: 19}}, hours)
+	weekdays, err := r.GetSalesByWeekday(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.PeriodSales{{Period: int(placed.Weekday()), Orders: 2, Revenue: 19}}, weekdays)
+
+	adjustments, err := r.GetAdjustments(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.AdjustmentSales{
+		{Status: entity.ItemComped, Reason: "quality", Items: 1, Amount: 4},
+		{Status: entity.ItemVoided, Reason: "wrong_item", Items: 1, Amount: 5, Wasted: 1},
+	}, adjustments)
+
+	margins, err := r.GetMarginReport(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, float32(19), margins.Revenue)
+	require.Len(t, margins.Sales, 2)
+	assert.Equal(t, beer.ID, margins.Sales[0].DishID)
+	assert.Equal(t, 3, margins.Sales[0].Quantity)
+}
+
+func TestExport(t *testing.T) {
+	r := newTestDB(t)
+	_, fries, beer, orders := sales(t, r)
+	from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	var rows []entity.OrderExportRow
+	require.NoError(t, r.ExportOrders(from, to, func(row entity.OrderExportRow) error {
+		assert.WithinRange(t, row.CreatedAt, from, to)
+		row.CreatedAt = time.Time{}
+		rows = append(rows, row)
+		return nil
+	}))
+	first, second := orders[0], orders[1]
+	assert.ElementsMatch(t, []entity.OrderExportRow{
+		{RecordType: entity.ExportItem, OrderID: first.ID, TableNumber: 1, ShiftID: first.ShiftID, DishID: fries.ID, DishName: "Fries", Quantity: 2, Price: 4, TaxRate: 19, Discount: 50, Amount: 4},
+		{RecordType: entity.ExportItem, OrderID: first.ID, TableNumber: 1, ShiftID: first.ShiftID, DishID: beer.ID, DishName: "Beer", Quantity: 1, Price: 5, TaxRate: 7, Amount: 5},
+		{RecordType: entity.ExportPayment, OrderID: first.ID, TableNumber: 1, ShiftID: first.ShiftID, Amount: 9, PaymentMethod: entity.PaymentCash},
+		{RecordType: entity.ExportItem, OrderID: second.ID, TableNumber: 2, ShiftID: second.ShiftID, DishID: beer.ID, DishName: "Beer", Quantity: 2, Price: 5, TaxRate: 7, Amount: 10},
+		{RecordType: entity.ExportPayment, OrderID: second.ID, TableNumber: 2, ShiftID: second.ShiftID, Amount: 10, PaymentMethod: entity.PaymentCard},
+	}, rows)
+	assert.Equal(t, first.ID, rows[2].OrderID, "the rows are sorted by order")
+	assert.Equal(t, entity.ExportPayment, rows[2].RecordType, "the payments follow the items")
+
+	rows = nil
+	require.NoError(t, r.ExportOrders(to, to.Add(time.Hour), func(row entity.OrderExportRow) error {
+		rows = append(rows, row)
+		return nil
+	}))
+	assert.Empty(t, rows)
+	stop := errors.New("stop")
+	assert.ErrorIs(t, r.ExportOrders(from, to, func(entity.OrderExportRow) error { return stop }), stop)
+
+	for i, order := range []entity.Order{second, first, orders[2]} {
+		require.NoError(t, r.AddSignature(&entity.FiscalSignature{OrderID: order.ID, SignatureCounter: uint64(10 - i), EndTime: time.Now()}))
+	}
+	require.NoError(t, r.AddSignature(&entity.FiscalSignature{OrderID: first.ID, SignatureCounter: 1, EndTime: from.Add(-time.Hour)}))
+	var counters []uint64
+	require.NoError(t, r.ExportSignatures(from, to, func(s entity.FiscalSignature) error {
+		counters = append(counters, s.SignatureCounter)
+		return nil
+	}))
+	assert.Equal(t, []uint64{8, 9, 10}, counters, "signatures of voided orders are exported as well")
+}
+
+func TestAdjustOrderItem(t *testing.T) {
+	r := newTestDB(t)
+	require.NoError(t, r.OpenShift(&entity.Shift{}))
+	keg := entity.Ingredient{Name: "Beer", Stock: 10}
+	require.NoError(t, r.CreateIngredient(&keg))
+	beer := entity.Dish{Name: "Beer", Price: 5, TaxRate: 7}
+	require.NoError(t, r.CreateDish(&beer))
+	require.NoError(t, r.SetRecipe(beer.ID, []entity.RecipeItem{{IngredientID: keg.ID, Quantity: 0.5}}))
+	order := entity.Order{TableNumber: 1, Items: []entity.OrderItem{{DishID: beer.ID, Quantity: 4}}}
+	require.NoError(t, r.CreateOrder(&order))
+	itemId := order.Items[0].ID
+	stock := func() float32 {
+		i, err := r.GetIngredient(keg.ID)
+		require.NoError(t, err)
+		return i.Stock
+	}
+	require.Equal(t, float32(8), stock())
+
+	voided, err := r.AdjustOrderItem(order.ID, itemId, entity.ItemAdjustment{Status: entity.ItemVoided, Reason: "entered_twice", Quantity: 1, UserID: 2})
+	require.NoError(t, err)
+	assert.NotEqual(t, itemId, voided.ID, "a part is split off")
+	assert.Equal(t, float32(8.5), stock(), "the ingredients of a void go back into stock")
+	_, err = r.AdjustOrderItem(order.ID, itemId, entity.ItemAdjustment{Status: entity.ItemVoided, Reason: "kitchen_error", Waste: true, Quantity: 1})
+	require.NoError(t, err)
+	_, err = r.AdjustOrderItem(order.ID, itemId, entity.ItemAdjustment{Status: entity.ItemComped, Reason: "regular_guest", Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, float32(8.5), stock(), "wasted and comped items stay used")
+
+	_, err = r.AdjustOrderItem(order.ID, itemId, entity.ItemAdjustment{Status: entity.ItemComped, Reason: "quality", Quantity: 2})
+	assert.ErrorIs(t, err, entity.ErrInvalidData)
+	_, err = r.AdjustOrderItem(order.ID, voided.ID, entity.ItemAdjustment{Status: entity.ItemComped, Reason: "quality"})
+	assert.ErrorIs(t, err, entity.ErrItemAdjusted)
+	_, err = r.AdjustOrderItem(order.ID+1, itemId, entity.ItemAdjustment{Status: entity.ItemComped, Reason: "quality"})
+	assert.ErrorAs(t, err, &entity.RecordNotFoundError{})
+
+	order, err = r.GetOrder(order.ID)
+	require.NoError(t, err)
+	type line struct {
+		Quantity   int
+		Status     string
+		Reason     string
+		Waste      bool
+		AdjustedBy uint
+	}
+	lines := make([]line, len(order.Items))
+	for i, item := range order.Items {
+		lines[i] = line{item.Quantity, item.Status, item.Reason, item.Waste, item.AdjustedBy}
+	}
+	assert.ElementsMatch(t, []line{
+		{1, "", "", false, 0},
+		{1, entity.ItemVoided, "entered_twice", false, 2},
+		{1, entity.ItemVoided, "kitchen_error", true, 0},
+		{1, entity.ItemComped, "regular_guest", false, 0},
+	}, lines)
+}
+
+func TestCloseShift(t *testing.T) {
+	r := newTestDB(t)
+	shift, _, _, _ := sales(t, r)
+
+	z, err := r.CloseShift(shift.ID, 60)
+	require.NoError(t, err)
+	assert.Equal(t, 2, z.Orders)
+	assert.Equal(t, float32(19), z.Revenue)
+	assert.Equal(t, float32(4), z.Discounts)
+	assert.Equal(t, 1, z.VoidedOrders)
+	assert.Equal(t, float32(5), z.Voids)
+	assert.Equal(t, float32(5), z.ItemVoids)
+	assert.Equal(t, float32(4), z.Comps)
+	assert.Equal(t, float32(59), z.CashExpected, "the opening cash and the cash payments")
+	assert.Equal(t, float32(1), z.CashDifference)
+	require.Len(t, z.Payments, 2)
+	assert.Equal(t, []any{entity.PaymentCard, 1, float32(10)}, []any{z.Payments[0].Method, z.Payments[0].Count, z.Payments[0].Amount})
+	assert.Equal(t, []any{entity.PaymentCash, 1, float32(9)}, []any{z.Payments[1].Method, z.Payments[1].Count, z.Payments[1].Amount})
+	require.Len(t, z.Taxes, 2)
+	assert.Equal(t, []float32{7, 15}, []float32{z.Taxes[0].Rate, z.Taxes[0].Gross})
+	assert.Equal(t, []float32{19, 4}, []float32{z.Taxes[1].Rate, z.Taxes[1].Gross})
+	assert.InDelta(t, 15/1.07, z.Taxes[0].Net, 0.001)
+
+	stored, err := r.GetZReport(shift.ID)
+	require.NoError(t, err)
+	assert.Equal(t, z.ID, stored.ID)
+	assert.Equal(t, z.Revenue, stored.Revenue)
+	assert.Len(t, stored.Payments, 2)
+	assert.Len(t, stored.Taxes, 2)
+	_, err = r.CloseShift(shift.ID, 60)
+	assert.ErrorIs(t, err, entity.ErrShiftClosed)
+}
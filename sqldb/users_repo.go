@@ -0,0 +1,125 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"gorestserviceagain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateUser adds a user with a username no other user has. Deleted users keep their
+// usernames, so the orders and audit entries attributed to them stay unambiguous.
+func (r SqliteDB) CreateUser(user *entity.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Unscoped().Model(&entity.User{}).Where("username = ?", user.Username).Count(&count)
+		if count > 0 {
+			return fmt.Errorf("%w: %s", entity.ErrUsernameTaken, user.Username)
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return writeError(err)
+		}
+		return nil
+	})
+}
+
+func (r SqliteDB) GetUsers() (u []entity.User, err error) {
+	result := r.db.Find(&u)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return u, nil
+}
+
+func (r SqliteDB) GetUser(id uint) (u entity.User, err error) {
+	result := r.db.First(&u, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return u, entity.WrapRecordNotFoundError("User", id, result.Error)
+	}
+	return u, result.Error
+}
+
+func (r SqliteDB) GetUserByUsername(username string) (u entity.User, err error) {
+	result := r.db.Where("username = ?", username).First(&u)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return u, entity.WrapRecordNotFoundError("User", username, result.Error)
+	}
+	return u, result.Error
+}
+
+// UpdateUser keeps the password hash unless a new one is set.
+func (r SqliteDB) UpdateUser(user *entity.User) error {
+	result := r.db.Model(user).Omit("Username").Updates(*user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.WrapRecordNotFoundError("User", user.ID, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r SqliteDB) DeleteUser(id uint) error {
+	result := r.db.Delete(&entity.User{}, id)
+	if result.RowsAffected == 0 {
+		return entity.WrapRecordNotFoundError("User", id, result.Error)
+	}
+	return nil
+}
+
+func (r SqliteDB) ClockIn(userId uint) (e entity.TimeEntry, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&entity.User{}, userId).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.WrapRecordNotFoundError("User", userId, err)
+		}
+		var open int64
+		tx.Model(&entity.TimeEntry{}).Where("user_id = ? AND clock_out IS NULL", userId).Count(&open)
+		if open > 0 {
+			return entity.ErrClockedIn
+		}
+		e = entity.TimeEntry{UserID: userId, ClockIn: time.Now()}
+		return tx.Create(&e).Error
+	})
+	return e, err
+}
+
+func (r SqliteDB) ClockOut(userId uint) (e entity.TimeEntry, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND clock_out IS NULL", userId).First(&e)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return entity.ErrNotClockedIn
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		now := time.Now()
+		e.ClockOut = &now
+		return tx.Model(&e).Update("clock_out", now).Error
+	})
+	return e, err
+}
+
+// GetTimesheets returns the time entries overlapping from and to, per user, or only
+// for the given user if userId is not 0.
+func (r SqliteDB) GetTimesheets(from time.Time, to time.Time, userId uint) ([]entity.Timesheet, error) {
+	var users []entity.User
+	var entries []entity.TimeEntry
+	usersQuery := r.db.Order("username")
+	entriesQuery := r.db.Where("clock_in < ? AND (clock_out IS NULL OR clock_out > ?)", to, from).Order("clock_in")
+	if userId != 0 {
+		usersQuery = usersQuery.Where("id = ?", userId)
+		entriesQuery = entriesQuery.Where("user_id = ?", userId)
+	}
+	if err := usersQuery.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if userId != 0 && len(users) == 0 {
+		return nil, entity.WrapRecordNotFoundError("User", userId, gorm.ErrRecordNotFound)
+	}
+	if err := entriesQuery.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entity.NewTimesheets(users, entries, from, to, time.Now()), nil
+}